@@ -1,86 +1,281 @@
 package main
 
 import (
+	"Merch_store-Avito_test_task/db/migrations"
+	"Merch_store-Avito_test_task/internal/models"
+	adminHandler "Merch_store-Avito_test_task/internal/pkg/admin/delivery/http"
+	adminRepo "Merch_store-Avito_test_task/internal/pkg/admin/repository"
+	adminUsecase "Merch_store-Avito_test_task/internal/pkg/admin/usecase"
+	apiKeyHandler "Merch_store-Avito_test_task/internal/pkg/apikey/delivery/http"
+	apiKeyRepo "Merch_store-Avito_test_task/internal/pkg/apikey/repository"
+	apiKeyUsecase "Merch_store-Avito_test_task/internal/pkg/apikey/usecase"
+	"Merch_store-Avito_test_task/internal/pkg/auth"
 	authHandler "Merch_store-Avito_test_task/internal/pkg/auth/delivery/http"
 	authRepo "Merch_store-Avito_test_task/internal/pkg/auth/repository"
 	authUsecase "Merch_store-Avito_test_task/internal/pkg/auth/usecase"
 	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/database"
+	"Merch_store-Avito_test_task/internal/pkg/grpcapi"
+	merchstorev1 "Merch_store-Avito_test_task/internal/pkg/grpcapi/pb/merchstore/v1"
 	httpresponse "Merch_store-Avito_test_task/internal/pkg/httpresponses"
 	"Merch_store-Avito_test_task/internal/pkg/jwt"
+	jwtHandlers "Merch_store-Avito_test_task/internal/pkg/jwt/delivery/http"
 	"Merch_store-Avito_test_task/internal/pkg/middleware"
+	"Merch_store-Avito_test_task/internal/pkg/openapi"
+	openapiHandlers "Merch_store-Avito_test_task/internal/pkg/openapi/delivery/http"
+	outboxPublisher "Merch_store-Avito_test_task/internal/pkg/outbox/publisher"
+	outboxRepo "Merch_store-Avito_test_task/internal/pkg/outbox/repository"
+	outboxUsecase "Merch_store-Avito_test_task/internal/pkg/outbox/usecase"
+	"Merch_store-Avito_test_task/internal/pkg/payments"
 	paymentsHandler "Merch_store-Avito_test_task/internal/pkg/payments/delivery/http"
 	paymentsRepo "Merch_store-Avito_test_task/internal/pkg/payments/repository"
 	paymentsUsecase "Merch_store-Avito_test_task/internal/pkg/payments/usecase"
+	"Merch_store-Avito_test_task/internal/pkg/service"
 	serviceHandler "Merch_store-Avito_test_task/internal/pkg/service/delivery/http"
 	serviceRepo "Merch_store-Avito_test_task/internal/pkg/service/repository"
 	serviceUsecase "Merch_store-Avito_test_task/internal/pkg/service/usecase"
+	webhookHandler "Merch_store-Avito_test_task/internal/pkg/webhook/delivery/http"
+	webhookRepo "Merch_store-Avito_test_task/internal/pkg/webhook/repository"
+	webhookUsecase "Merch_store-Avito_test_task/internal/pkg/webhook/usecase"
 	"context"
-	"database/sql"
 	"errors"
+	"expvar"
+	"flag"
 	"fmt"
 	"github.com/gorilla/mux"
+	"google.golang.org/grpc"
 	"log"
 	"log/slog"
+	"net"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
+	"text/tabwriter"
 	"time"
 )
 
 func main() {
+	printConfig := flag.Bool("print-config", false, "print the effective config with secrets redacted and exit")
+	migrate := flag.String("migrate", "", "run schema migrations (up, down or status) and exit")
+	migrateSteps := flag.Int("steps", 1, "number of migrations to revert with -migrate down")
+	flag.Parse()
 
-	cfg := config.Load()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("failed to load config: %v", err)
+	}
+	if *printConfig {
+		if err = cfg.WriteRedacted(os.Stdout); err != nil {
+			log.Fatalf("failed to print config: %v", err)
+		}
+		return
+	}
 
 	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
-	jwtSecret := os.Getenv("JWT_SECRET")
-	jwtHandler := jwt.NewJTW(jwtSecret, logger)
+	jwtKeys := jwt.NewKeySet()
+	for kid, secret := range cfg.JWT.HMACKeys() {
+		if err = jwtKeys.AddHMAC(kid, secret); err != nil {
+			log.Fatalf("invalid JWT configuration: %v", err)
+		}
+	}
+	for kid, path := range cfg.JWT.PrivateKeys {
+		if err = jwtKeys.AddPrivateKeyFile(kid, path); err != nil {
+			log.Fatalf("invalid JWT configuration: %v", err)
+		}
+	}
+	jwtOptions := jwt.Options{
+		Issuer:    cfg.JWT.Issuer,
+		Audience:  cfg.JWT.Audience,
+		TokenTTL:  cfg.JWT.TokenTTL,
+		ClockSkew: cfg.JWT.ClockSkew,
+	}
+	jwtHandler, err := jwt.NewJWTWithKeySet(jwtKeys, cfg.JWT.CurrentKeyID(), jwtOptions, logger)
+	if err != nil {
+		log.Fatalf("invalid JWT configuration: %v", err)
+	}
+	jwksHandler := jwtHandlers.NewJWKSHandler(jwtHandler, logger)
 
-	db, err := sql.Open("postgres", fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable", cfg.Database.DbHost, cfg.Database.DbPort, cfg.Database.DbUser, cfg.Database.DbPass, cfg.Database.DbName))
+	db, err := database.Open(cfg.Database.ConnString(), cfg.Database)
 	if err != nil {
 		log.Fatalf("failed to connect to database: %v", err)
 	}
 	defer db.Close()
-	db.SetMaxOpenConns(100)                 // Maximum number of open connections
-	db.SetMaxIdleConns(50)                  // Maximum number of idle connections
-	db.SetConnMaxLifetime(10 * time.Minute) // Maximum lifetime of a connection
-	db.SetConnMaxIdleTime(10 * time.Minute)
-	log.Printf("DB_HOST: %s, DB_PORT: %d, DB_USER: %s, DB_PASS: %s, DB_NAME: %s", cfg.Database.DbHost, cfg.Database.DbPort, cfg.Database.DbUser, cfg.Database.DbPass, cfg.Database.DbName)
-	err = db.Ping()
+
+	readDB := db
+	if cfg.Database.ReplicaDSN != "" {
+		readDB, err = database.Open(cfg.Database.ReplicaDSN, cfg.Database)
+		if err != nil {
+			log.Fatalf("failed to connect to read replica: %v", err)
+		}
+		defer readDB.Close()
+	}
+	logger.Info("connected to database", slog.Any("database", cfg.Database))
+
+	migrator, err := database.NewMigrator(db, migrations.FS, logger)
 	if err != nil {
-		log.Fatalf("failed to ping database: %v", err)
+		log.Fatalf("failed to load migrations: %v", err)
+	}
+	if *migrate != "" {
+		if err = runMigrations(context.Background(), migrator, *migrate, *migrateSteps); err != nil {
+			log.Fatalf("migrate %s: %v", *migrate, err)
+		}
+		return
+	}
+	if cfg.Migrations.OnStart {
+		if _, err = migrator.Up(context.Background()); err != nil {
+			log.Fatalf("failed to migrate database: %v", err)
+		}
 	}
 
+	var attemptStore auth.AttemptStore = authRepo.NewAttemptsRepositoryImpl(db)
+	if cfg.LoginGuard.Store == "memory" {
+		attemptStore = authRepo.NewMemoryAttemptStore()
+	}
+	loginGuard := authUsecase.NewLoginGuard(attemptStore, cfg.LoginGuard)
 	authRepo := authRepo.NewAuthRepositoryImpl(db)
-	authUsecase := authUsecase.NewAuthUsecase(authRepo)
-	authHandler := authHandler.NewAuthHandler(authUsecase, logger, jwtHandler)
+	authUsecase := authUsecase.NewAuthUsecase(authRepo, cfg.Password)
+	authHandler := authHandler.NewAuthHandler(authUsecase, logger, jwtHandler, loginGuard)
+
+	// Uncached, /info and the catalog tolerate replica lag and are served from readDB. The cache is
+	// filled from the primary instead: an entry reloaded right after a commit invalidated it must not
+	// come from a replica that has not replayed that commit yet. Both only serve display; purchases
+	// read the price in their own transaction on the primary.
+	var serviceRepository service.ServiceRepository = serviceRepo.NewServiceRepo(readDB)
+	var userInfoCache payments.UserInfoInvalidator
+	if !cfg.Cache.Disabled {
+		cached := serviceRepo.NewCachedServiceRepo(serviceRepo.NewServiceRepo(db), cfg.Cache)
+		serviceRepository, userInfoCache = cached, cached
+	}
 
 	paymentsRepo := paymentsRepo.NewAuthRepositoryImpl(db)
-	paymentsUsecase := paymentsUsecase.NewPaymentsUsecase(paymentsRepo)
+	paymentsUsecase := paymentsUsecase.NewPaymentsUsecase(paymentsRepo, outboxRepo.NewOutboxRepositoryImpl(db),
+		database.NewTxManager(db), userInfoCache)
 	paymentsHandler := paymentsHandler.NewPaymentsHandler(paymentsUsecase, logger)
 
-	serviceRepo := serviceRepo.NewServiceRepo(db)
-	serviceUsecase := serviceUsecase.NewServiceUsecase(serviceRepo)
+	apiKeyRepo := apiKeyRepo.NewAPIKeyRepositoryImpl(db)
+	apiKeyUsecase := apiKeyUsecase.NewAPIKeyUsecase(apiKeyRepo)
+	apiKeyHandler := apiKeyHandler.NewAPIKeyHandler(apiKeyUsecase, logger)
+
+	webhookRepo := webhookRepo.NewWebhookRepositoryImpl(db)
+	webhookDispatcher := webhookUsecase.NewDispatcher(webhookRepo, nil, cfg.Webhooks, logger)
+	webhookUsecase := webhookUsecase.NewWebhookUsecase(webhookRepo)
+	webhookHandler := webhookHandler.NewWebhookHandler(webhookUsecase, logger)
+
+	serviceUsecase := serviceUsecase.NewServiceUsecase(serviceRepository)
 	serviceHandler := serviceHandler.NewServiceHandler(serviceUsecase, logger)
 
-	r := mux.NewRouter().PathPrefix("/api").Subrouter()
+	adminUsecase := adminUsecase.NewAdminUsecase(adminRepo.NewAdminRepositoryImpl(db), authRepo, serviceRepository, cfg.Password)
+	adminHandler := adminHandler.NewAdminHandler(adminUsecase, logger)
 
-	r.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		response := httpresponse.Response{
-			Message: "Not found",
-		}
-		httpresponse.SendJSONResponse(r.Context(), w, response, http.StatusNotFound, logger)
+	spec, err := openapi.Load()
+	if err != nil {
+		log.Fatalf("failed to load openapi document: %v", err)
+	}
+	docsHandler, err := openapiHandlers.NewDocsHandler(spec, logger)
+	if err != nil {
+		log.Fatalf("failed to create docs handler: %v", err)
+	}
+
+	router := mux.NewRouter()
+	router.NotFoundHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpresponse.SendError(w, r, models.ErrNotFound, logger)
 	})
+	router.HandleFunc("/.well-known/jwks.json", jwksHandler.GetJWKS).Methods(http.MethodGet)
+
+	r := router.PathPrefix("/api").Subrouter()
+	r.NotFoundHandler = router.NotFoundHandler
 	r.HandleFunc("/healthcheck", healthcheckHandler).Methods(http.MethodGet)
+	r.HandleFunc("/openapi.json", docsHandler.GetOpenAPI).Methods(http.MethodGet)
+	r.HandleFunc("/docs", docsHandler.GetDocs).Methods(http.MethodGet)
+	r.HandleFunc("/docs/docs.js", docsHandler.GetDocsScript).Methods(http.MethodGet)
 
 	r.HandleFunc("/auth", authHandler.Login).Methods(http.MethodPost)
-	r.Handle("/sendCoin", middleware.AuthMiddleware(jwtHandler, http.HandlerFunc(paymentsHandler.SendCoins), logger)).Methods(http.MethodPost)
-	r.Handle("/buy/{item}", middleware.AuthMiddleware(jwtHandler, http.HandlerFunc(paymentsHandler.BuyItem), logger)).Methods(http.MethodGet)
-	r.Handle("/info", middleware.AuthMiddleware(jwtHandler, http.HandlerFunc(serviceHandler.GetUserInfo), logger)).Methods(http.MethodGet)
+	rateLimited := func(rule config.RateLimitRule, next http.HandlerFunc) http.Handler {
+		if cfg.RateLimit.Disabled {
+			return next
+		}
+		return middleware.RateLimitMiddleware(middleware.NewRateLimiter(rule, nil), next, logger)
+	}
+	// clientLimited throttles a client IP across all authenticated routes before revocation checks and
+	// API key lookups hit the database; rateLimited then applies the per-user route limits.
+	clientLimiter := middleware.NewRateLimiter(cfg.RateLimit.Client.Rule(), nil)
+	clientLimited := func(next http.Handler) http.Handler {
+		if cfg.RateLimit.Disabled {
+			return next
+		}
+		return middleware.RateLimitMiddleware(clientLimiter, next, logger)
+	}
+	tokenAuthenticated := func(next http.Handler) http.Handler {
+		return middleware.AuthMiddleware(jwtHandler, middleware.RevocationMiddleware(authUsecase, next, logger), logger)
+	}
+	// authenticate accepts either an API key or a user token; handlers see the same context for both.
+	// API keys act as their owner and additionally need keyScope, if set. Token users' role scopes are
+	// only loaded where withRoles asks for them.
+	authenticate := func(next http.Handler, keyScope string, withRoles bool) http.Handler {
+		keyNext := next
+		if keyScope != "" {
+			keyNext = middleware.RequireScopeMiddleware(keyScope, next, logger)
+		}
+		byKey := middleware.APIKeyMiddleware(apiKeyUsecase, keyNext, logger)
+		tokenNext := next
+		if withRoles {
+			tokenNext = middleware.RoleScopesMiddleware(authUsecase, next, logger)
+		}
+		byToken := tokenAuthenticated(middleware.AdminScopesMiddleware(cfg.Admin.Usernames, tokenNext))
+		return clientLimited(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get(middleware.APIKeyHeader) != "" {
+				byKey.ServeHTTP(w, r)
+				return
+			}
+			byToken.ServeHTTP(w, r)
+		}))
+	}
+	// authenticated serves users their own coins and info; API keys need keyScope to do it for their owner.
+	authenticated := func(keyScope string, next http.Handler) http.Handler {
+		return authenticate(next, keyScope, false)
+	}
+	scoped := func(scope string, next http.HandlerFunc) http.Handler {
+		return authenticate(middleware.RequireScopeMiddleware(scope, next, logger), "", true)
+	}
+	r.Handle("/auth/password", clientLimited(tokenAuthenticated(http.HandlerFunc(authHandler.ChangePassword)))).Methods(http.MethodPost)
+	r.Handle("/sendCoin", authenticated(models.ScopeGrantWrite, rateLimited(cfg.RateLimit.SendCoin, paymentsHandler.SendCoins))).Methods(http.MethodPost)
+	r.Handle("/buy/{item}", authenticated(models.ScopeGrantWrite, rateLimited(cfg.RateLimit.Buy, paymentsHandler.BuyItem))).Methods(http.MethodGet)
+	r.Handle("/info", authenticated(models.ScopeUsersRead, rateLimited(cfg.RateLimit.Info, serviceHandler.GetUserInfo))).Methods(http.MethodGet)
+
+	r.Handle("/admin/keys", scoped(models.ScopeKeysAdmin, apiKeyHandler.ListKeys)).Methods(http.MethodGet)
+	r.Handle("/admin/keys", scoped(models.ScopeKeysAdmin, apiKeyHandler.IssueKey)).Methods(http.MethodPost)
+	r.Handle("/admin/keys/{id}", scoped(models.ScopeKeysAdmin, apiKeyHandler.RevokeKey)).Methods(http.MethodDelete)
+	r.Handle("/admin/users/import", scoped(models.ScopeUsersWrite, adminHandler.ImportUsers)).Methods(http.MethodPost)
+	r.Handle("/admin/catalog/sync", scoped(models.ScopeCatalogWrite, adminHandler.SyncCatalog)).Methods(http.MethodPost)
+	r.Handle("/admin/metrics", scoped(models.ScopeMetricsRead, expvar.Handler().ServeHTTP)).Methods(http.MethodGet)
+	r.Handle("/admin/webhooks", scoped(models.ScopeWebhooksAdmin, webhookHandler.List)).Methods(http.MethodGet)
+	r.Handle("/admin/webhooks", scoped(models.ScopeWebhooksAdmin, webhookHandler.Subscribe)).Methods(http.MethodPost)
+	r.Handle("/admin/webhooks/{id}", scoped(models.ScopeWebhooksAdmin, webhookHandler.Delete)).Methods(http.MethodDelete)
+	r.Handle("/admin/webhooks/{id}/deliveries", scoped(models.ScopeWebhooksAdmin, webhookHandler.Deliveries)).Methods(http.MethodGet)
+	r.Handle("/admin/webhooks/{id}/deliveries/{delivery}/redeliver", scoped(models.ScopeWebhooksAdmin, webhookHandler.Redeliver)).Methods(http.MethodPost)
+
+	var handler http.Handler = router
+	if cfg.OpenAPI.Validate {
+		validator, err := middleware.NewOpenAPIValidator(spec, func(r *http.Request, err error) {
+			logger.ErrorContext(r.Context(), "OpenAPI violation", slog.String("error", err.Error()))
+		})
+		if err != nil {
+			log.Fatalf("failed to create openapi validator: %v", err)
+		}
+		handler = middleware.OpenAPIValidationMiddleware(validator, router, logger)
+	}
 
-	httpSrv := &http.Server{Handler: r, Addr: fmt.Sprintf(":%d", cfg.HttpServer.Address)}
+	httpSrv := &http.Server{
+		Handler:      middleware.RequestIDMiddleware(handler),
+		Addr:         fmt.Sprintf(":%d", cfg.HttpServer.Address),
+		IdleTimeout:  cfg.HttpServer.IdleTimeout,
+		ReadTimeout:  cfg.HttpServer.ReadTimeout,
+		WriteTimeout: cfg.HttpServer.WriteTimeout,
+	}
 	go func() {
 		logger.Info(fmt.Sprintf("HTTP server listening on :%d", cfg.HttpServer.Address))
 		if err := httpSrv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -89,6 +284,44 @@ func main() {
 		}
 	}()
 
+	var grpcSrv *grpc.Server
+	if !cfg.GRPCServer.Disabled {
+		grpcSrv = grpc.NewServer(grpc.ChainUnaryInterceptor(grpcapi.AuthInterceptor(jwtHandler, authUsecase, logger)))
+		merchstorev1.RegisterMerchStoreServer(grpcSrv, grpcapi.NewMerchStoreServer(paymentsUsecase, serviceUsecase, logger))
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%d", cfg.GRPCServer.Address))
+		if err != nil {
+			log.Fatalf("failed to listen for gRPC: %v", err)
+		}
+		go func() {
+			logger.Info(fmt.Sprintf("gRPC server listening on :%d", cfg.GRPCServer.Address))
+			if err := grpcSrv.Serve(listener); err != nil {
+				logger.Error("failed to serve gRPC", slog.String("error", err.Error()))
+				os.Exit(1)
+			}
+		}()
+	}
+
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	var workersWG sync.WaitGroup
+	if !cfg.Outbox.Disabled {
+		publisher := outboxPublisher.Fanout{outboxPublisher.NewLogPublisher(logger), webhookUsecase}
+		relay := outboxUsecase.NewRelay(outboxRepo.NewOutboxRepositoryImpl(db), publisher, cfg.Outbox, logger)
+		workersWG.Add(1)
+		go func() {
+			defer workersWG.Done()
+			logger.Info("outbox relay started")
+			relay.Run(workersCtx)
+		}()
+	}
+	if !cfg.Webhooks.Disabled {
+		workersWG.Add(1)
+		go func() {
+			defer workersWG.Done()
+			logger.Info("webhook dispatcher started")
+			webhookDispatcher.Run(workersCtx)
+		}()
+	}
+
 	stop := make(chan os.Signal, 1)
 	signal.Notify(stop, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
@@ -100,6 +333,16 @@ func main() {
 		os.Exit(1)
 	}
 	logger.Info("HTTP server gracefully stopped")
+
+	if grpcSrv != nil {
+		logger.Info("Shutting down gRPC server...")
+		grpcSrv.GracefulStop()
+		logger.Info("gRPC server gracefully stopped")
+	}
+
+	stopWorkers()
+	workersWG.Wait()
+	logger.Info("background workers stopped")
 }
 
 func healthcheckHandler(w http.ResponseWriter, r *http.Request) {
@@ -108,9 +351,37 @@ func healthcheckHandler(w http.ResponseWriter, r *http.Request) {
 	_, err := fmt.Fprintf(w, "STATUS: OK")
 	if err != nil {
 		logger.Error("Failed to write healthcheck response", slog.Any("error", err))
-		response := httpresponse.Response{
-			Message: "Invalid request",
+		httpresponse.SendError(w, r, err, logger)
+	}
+}
+
+// runMigrations executes a -migrate command and reports the result on stdout.
+func runMigrations(ctx context.Context, migrator *database.Migrator, command string, steps int) error {
+	switch command {
+	case "up":
+		n, err := migrator.Up(ctx)
+		fmt.Printf("applied %d migration(s)\n", n)
+		return err
+	case "down":
+		n, err := migrator.Down(ctx, steps)
+		fmt.Printf("reverted %d migration(s)\n", n)
+		return err
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range status {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\n", s.Version, s.Name, appliedAt)
 		}
-		httpresponse.SendJSONResponse(r.Context(), w, response, http.StatusBadRequest, logger)
+		return w.Flush()
+	default:
+		return fmt.Errorf("unknown command %q, expected up, down or status", command)
 	}
 }
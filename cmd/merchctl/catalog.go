@@ -0,0 +1,71 @@
+package main
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/admin"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+func runCatalog(ctx context.Context, uc admin.AdminUsecase, out output, command string, args []string) error {
+	fs := flag.NewFlagSet("catalog "+command, flag.ContinueOnError)
+	format := fs.String("format", admin.FormatCSV, "file format: csv or json")
+	file := fs.String("file", "-", "file to read or write, - for stdin/stdout")
+	retire := fs.Bool("retire", false, "sync: retire products missing from the file")
+	dryRun := fs.Bool("dry-run", false, "sync: show the changes without applying them")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch command {
+	case "export":
+		products, err := uc.ExportCatalog(ctx)
+		if err != nil {
+			return err
+		}
+		var w io.Writer = out.w
+		if *file != "-" {
+			f, err := os.Create(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			w = f
+		}
+		return admin.EncodeCatalog(w, *format, products)
+
+	case "sync":
+		var r io.Reader = os.Stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		products, err := admin.DecodeCatalog(r, *format)
+		if err != nil {
+			return err
+		}
+		diff, err := uc.SyncCatalog(ctx, products, *retire, *dryRun)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(diff.Changes))
+		for _, change := range diff.Changes {
+			oldPrice := ""
+			if change.OldPrice != nil {
+				oldPrice = strconv.Itoa(*change.OldPrice)
+			}
+			rows = append(rows, []string{change.Action, change.Name, oldPrice, strconv.Itoa(change.Price)})
+		}
+		return out.print(diff, []string{"ACTION", "NAME", "OLD PRICE", "PRICE"}, rows)
+
+	default:
+		return fmt.Errorf("unknown catalog command %q", command)
+	}
+}
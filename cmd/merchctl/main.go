@@ -0,0 +1,86 @@
+// Command merchctl is the operator CLI for the merch store. It uses the service's config and
+// repositories directly, so it needs the same environment (DB_*, config file) as the service.
+package main
+
+import (
+	"Merch_store-Avito_test_task/db/migrations"
+	adminRepo "Merch_store-Avito_test_task/internal/pkg/admin/repository"
+	adminUsecase "Merch_store-Avito_test_task/internal/pkg/admin/usecase"
+	authRepo "Merch_store-Avito_test_task/internal/pkg/auth/repository"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/database"
+	serviceRepo "Merch_store-Avito_test_task/internal/pkg/service/repository"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+const usage = `Usage: merchctl [-o table|json] <command> [flags]
+
+Commands:
+  users list
+  users create -username NAME [-password PASS]
+  users reset-password -username NAME [-password PASS]
+  users adjust-balance -username NAME -amount N -reason TEXT
+  users info -username NAME
+  users import [-file PATH] [-dry-run] [-atomic]
+  catalog export [-format csv|json] [-file PATH]
+  catalog sync [-format csv|json] [-file PATH] [-retire] [-dry-run]
+  migrate up|down|status [-steps N]
+
+Without -password a random password is generated and printed. Files default to stdin/stdout.
+`
+
+func main() {
+	flag.Usage = func() { fmt.Fprint(flag.CommandLine.Output(), usage) }
+	outputFormat := flag.String("o", "table", "output format: table or json")
+	flag.Parse()
+	if flag.NArg() < 2 || (*outputFormat != "table" && *outputFormat != "json") {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if err := run(flag.Args(), output{w: os.Stdout, json: *outputFormat == "json"}); err != nil {
+		fmt.Fprintf(os.Stderr, "merchctl: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out output) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	db, err := database.Open(cfg.Database.ConnString(), cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	uc := adminUsecase.NewAdminUsecase(adminRepo.NewAdminRepositoryImpl(db), authRepo.NewAuthRepositoryImpl(db),
+		serviceRepo.NewServiceRepo(db), cfg.Password)
+
+	group, command, flags := args[0], args[1], args[2:]
+	switch group {
+	case "users":
+		return runUsers(ctx, uc, out, command, flags)
+	case "catalog":
+		return runCatalog(ctx, uc, out, command, flags)
+	case "migrate":
+		migrator, err := database.NewMigrator(db, migrations.FS, logger)
+		if err != nil {
+			return err
+		}
+		return runMigrate(ctx, migrator, out, command, flags)
+	default:
+		return fmt.Errorf("unknown command %q", group)
+	}
+}
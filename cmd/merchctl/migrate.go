@@ -0,0 +1,54 @@
+package main
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/database"
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+func runMigrate(ctx context.Context, migrator *database.Migrator, out output, command string, args []string) error {
+	fs := flag.NewFlagSet("migrate "+command, flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "number of migrations to revert")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch command {
+	case "up", "down":
+		var n int
+		var err error
+		if command == "up" {
+			n, err = migrator.Up(ctx)
+		} else {
+			n, err = migrator.Down(ctx, *steps)
+		}
+		if err != nil {
+			return err
+		}
+		result := struct {
+			Migrations int `json:"migrations"`
+		}{n}
+		return out.print(result, []string{"MIGRATIONS"}, [][]string{{strconv.Itoa(n)}})
+
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(status))
+		for _, s := range status {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format(time.RFC3339)
+			}
+			rows = append(rows, []string{strconv.FormatInt(s.Version, 10), s.Name, appliedAt})
+		}
+		return out.print(status, []string{"VERSION", "NAME", "APPLIED AT"}, rows)
+
+	default:
+		return fmt.Errorf("unknown migrate command %q", command)
+	}
+}
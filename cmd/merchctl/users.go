@@ -0,0 +1,136 @@
+package main
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/admin"
+	passwords "Merch_store-Avito_test_task/internal/pkg/auth/password"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func runUsers(ctx context.Context, uc admin.AdminUsecase, out output, command string, args []string) error {
+	fs := flag.NewFlagSet("users "+command, flag.ContinueOnError)
+	username := fs.String("username", "", "username")
+	password := fs.String("password", "", "password; generated when empty")
+	amount := fs.Int("amount", 0, "coins to credit, negative to debit")
+	reason := fs.String("reason", "", "reason recorded with the adjustment")
+	file := fs.String("file", "-", "provisioning CSV, - for stdin")
+	dryRun := fs.Bool("dry-run", false, "check the rows without creating anyone")
+	atomic := fs.Bool("atomic", false, "create all users or none")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if command != "list" && command != "import" && *username == "" {
+		return errors.New("-username is required")
+	}
+
+	switch command {
+	case "list":
+		users, err := uc.ListUsers(ctx)
+		if err != nil {
+			return err
+		}
+		rows := make([][]string, 0, len(users))
+		for _, u := range users {
+			rows = append(rows, []string{strconv.FormatUint(uint64(u.ID), 10), u.Username, strconv.Itoa(u.Coins),
+				strings.Join(u.Roles, ","), u.CreatedAt.Format(time.RFC3339)})
+		}
+		return out.print(users, []string{"ID", "USERNAME", "COINS", "ROLES", "CREATED AT"}, rows)
+
+	case "create", "reset-password":
+		generated := *password == ""
+		if generated {
+			var err error
+			if *password, err = passwords.Generate(); err != nil {
+				return err
+			}
+		}
+		create := uc.CreateUser
+		if command == "reset-password" {
+			create = uc.ResetPassword
+		}
+		user, err := create(ctx, *username, *password)
+		if err != nil {
+			return err
+		}
+		result := struct {
+			ID       uint   `json:"id"`
+			Username string `json:"username"`
+			Password string `json:"password,omitempty"`
+		}{ID: user.ID, Username: user.Username}
+		if generated {
+			result.Password = *password
+		}
+		return out.print(result, []string{"ID", "USERNAME", "PASSWORD"},
+			[][]string{{strconv.FormatUint(uint64(result.ID), 10), result.Username, result.Password}})
+
+	case "adjust-balance":
+		adjustment, err := uc.AdjustBalance(ctx, *username, *amount, *reason)
+		if err != nil {
+			return err
+		}
+		return out.print(adjustment, []string{"ID", "USERNAME", "AMOUNT", "BALANCE", "REASON"},
+			[][]string{{strconv.FormatUint(uint64(adjustment.ID), 10), adjustment.Username,
+				strconv.Itoa(adjustment.Amount), strconv.Itoa(adjustment.Balance), adjustment.Reason}})
+
+	case "info":
+		info, err := uc.UserInfo(ctx, *username)
+		if err != nil {
+			return err
+		}
+		rows := [][]string{{"coins", "", strconv.Itoa(info.Coins)}}
+		for _, item := range info.Inventory {
+			rows = append(rows, []string{"inventory", item.Type, strconv.Itoa(item.Quantity)})
+		}
+		for _, t := range info.CoinHistory.Received {
+			rows = append(rows, []string{"received", t.FromUser, strconv.Itoa(t.Amount)})
+		}
+		for _, t := range info.CoinHistory.Sent {
+			rows = append(rows, []string{"sent", t.ToUser, strconv.Itoa(t.Amount)})
+		}
+		return out.print(info, []string{"KIND", "ITEM/USER", "AMOUNT"}, rows)
+
+	case "import":
+		var r io.Reader = os.Stdin
+		if *file != "-" {
+			f, err := os.Open(*file)
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+			r = f
+		}
+		rows, err := admin.ParseUsersCSV(r)
+		if err != nil {
+			return err
+		}
+		report, err := uc.ProvisionUsers(ctx, rows, *atomic, *dryRun)
+		if err != nil {
+			return err
+		}
+		table := make([][]string, 0, len(report.Rows))
+		for _, row := range report.Rows {
+			id := ""
+			if row.UserID != 0 {
+				id = strconv.FormatUint(uint64(row.UserID), 10)
+			}
+			table = append(table, []string{strconv.Itoa(row.Line), row.Username, row.Status, id, row.Password, row.Error})
+		}
+		if err = out.print(report, []string{"LINE", "USERNAME", "STATUS", "ID", "PASSWORD", "ERROR"}, table); err != nil {
+			return err
+		}
+		if report.Failed > 0 {
+			return fmt.Errorf("%d of %d row(s) failed", report.Failed, len(report.Rows))
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("unknown users command %q", command)
+	}
+}
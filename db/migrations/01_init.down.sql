@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS "purchase";
+DROP TABLE IF EXISTS "transaction";
+DROP TABLE IF EXISTS "product";
+DROP TABLE IF EXISTS "user";
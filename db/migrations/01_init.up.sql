@@ -44,8 +44,6 @@ COPY "product"(name, price)
     FROM '/docker-entrypoint-initdb.d/products.csv'
     WITH (FORMAT csv, HEADER true,  DELIMITER ';');
 
-CREATE UNIQUE INDEX idx_user_username ON "user" (username);
-CREATE INDEX idx_user_id ON "user" (id);
-CREATE INDEX idx_purchase_user_id ON "purchase" (user_id);
-CREATE INDEX idx_transaction_from_user ON "transaction" (from_user_id);
-CREATE INDEX idx_transaction_to_user ON "transaction" (to_user_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_user_id ON "purchase" (user_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_from_user ON "transaction" (from_user_id);
+CREATE INDEX IF NOT EXISTS idx_transaction_to_user ON "transaction" (to_user_id);
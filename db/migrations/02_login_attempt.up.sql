@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS "login_attempt"
+(
+    key TEXT PRIMARY KEY,
+    failures INTEGER NOT NULL CHECK (failures > 0),
+    last_failure TIMESTAMPTZ NOT NULL
+);
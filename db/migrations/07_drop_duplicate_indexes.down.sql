@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_username ON "user" (username);
+CREATE INDEX IF NOT EXISTS idx_user_id ON "user" (id);
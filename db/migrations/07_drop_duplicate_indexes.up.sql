@@ -0,0 +1,4 @@
+-- Databases bootstrapped before the migration runner carry indexes that duplicate
+-- the UNIQUE constraint on "user".username and the primary key on "user".id.
+DROP INDEX IF EXISTS idx_user_username;
+DROP INDEX IF EXISTS idx_user_id;
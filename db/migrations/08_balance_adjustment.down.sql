@@ -0,0 +1 @@
+DROP TABLE IF EXISTS "balance_adjustment";
@@ -0,0 +1 @@
+ALTER TABLE "user" ADD COLUMN IF NOT EXISTS roles TEXT[] NOT NULL DEFAULT '{}';
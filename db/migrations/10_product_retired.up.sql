@@ -0,0 +1 @@
+ALTER TABLE "product" ADD COLUMN IF NOT EXISTS retired_at TIMESTAMP;
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_login_attempt_last_failure;
@@ -0,0 +1,2 @@
+-- Increment deletes counters whose last failure fell out of the lockout window.
+CREATE INDEX IF NOT EXISTS idx_login_attempt_last_failure ON "login_attempt" (last_failure);
@@ -0,0 +1,4 @@
+-- Bought products stay, their purchases reference them.
+DELETE FROM "product" p
+WHERE p.name IN ('t-shirt', 'cup', 'book', 'pen', 'powerbank', 'hoody', 'umbrella', 'socks', 'wallet', 'pink-hoody')
+  AND NOT EXISTS (SELECT 1 FROM "purchase" pu WHERE pu.product_id = p.id);
@@ -0,0 +1,14 @@
+-- Initial catalog. Products already loaded by 01_init are left as they are; later changes go through
+-- catalog sync (POST /api/admin/catalog/sync, merchctl catalog sync).
+INSERT INTO "product" (name, price)
+VALUES ('t-shirt', 80),
+       ('cup', 20),
+       ('book', 50),
+       ('pen', 10),
+       ('powerbank', 200),
+       ('hoody', 300),
+       ('umbrella', 200),
+       ('socks', 10),
+       ('wallet', 50),
+       ('pink-hoody', 500)
+ON CONFLICT (name) DO NOTHING;
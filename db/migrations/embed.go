@@ -0,0 +1,10 @@
+// Package migrations embeds the versioned schema migrations into the binary.
+//
+// Every version NN has an NN_name.up.sql file and, if it can be reverted, an
+// NN_name.down.sql file. Applied versions are recorded in schema_migrations.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
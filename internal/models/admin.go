@@ -0,0 +1,58 @@
+package models
+
+import "time"
+
+type UserSummary struct {
+	ID        uint      `json:"id"`
+	Username  string    `json:"username"`
+	Coins     int       `json:"coins"`
+	Roles     []string  `json:"roles"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// BalanceAdjustment is an operator's manual credit (positive Amount) or debit (negative Amount).
+// Balance is the user's balance after the adjustment.
+type BalanceAdjustment struct {
+	ID        uint      `json:"id"`
+	UserID    uint      `json:"userId"`
+	Username  string    `json:"user"`
+	Amount    int       `json:"amount"`
+	Reason    string    `json:"reason"`
+	Balance   int       `json:"balance"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewUser is an account created by bulk provisioning. A nil Coins keeps the default starting balance
+// and an empty PasswordHash lets the user choose the password on the first login.
+type NewUser struct {
+	Username     string
+	PasswordHash string
+	Coins        *int
+	Roles        []string
+}
+
+// Outcomes of a provisioning row.
+const (
+	ProvisionCreated = "created"
+	ProvisionValid   = "valid" // dry run: the row would be created
+	ProvisionFailed  = "failed"
+	ProvisionSkipped = "skipped" // all-or-nothing run: the row was fine but another one failed
+)
+
+type ProvisionRow struct {
+	Line     int    `json:"line"`
+	Username string `json:"username"`
+	Status   string `json:"status"`
+	UserID   uint   `json:"userId,omitempty"`
+	// Password is generated for a created user whose row had none; it is not stored and shown only here.
+	Password string `json:"password,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+type ProvisionReport struct {
+	DryRun  bool           `json:"dryRun"`
+	Atomic  bool           `json:"atomic"`
+	Created int            `json:"created"`
+	Failed  int            `json:"failed"`
+	Rows    []ProvisionRow `json:"rows"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// Scopes an API key can be granted. Keys act as their owner: grant:write lets them send and spend the
+// owner's coins and users:read read the owner's info, the rest guard the admin endpoints.
+const (
+	ScopeGrantWrite    = "grant:write"
+	ScopeCatalogWrite  = "catalog:write"
+	ScopeUsersRead     = "users:read"
+	ScopeUsersWrite    = "users:write"
+	ScopeKeysAdmin     = "keys:admin"
+	ScopeWebhooksAdmin = "webhooks:admin"
+	ScopeMetricsRead   = "metrics:read"
+)
+
+// Scopes lists every known scope.
+var Scopes = []string{ScopeGrantWrite, ScopeCatalogWrite, ScopeUsersRead, ScopeUsersWrite, ScopeKeysAdmin, ScopeWebhooksAdmin, ScopeMetricsRead}
+
+func ValidScope(scope string) bool {
+	return slices.Contains(Scopes, scope)
+}
+
+// APIKey is a service credential acting on behalf of UserID, limited to Scopes.
+// Only a hash of the key is stored; Prefix identifies it in listings.
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	UserID     uint       `json:"userId"`
+	Username   string     `json:"user"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	RevokedAt  *time.Time `json:"revokedAt,omitempty"`
+}
@@ -7,4 +7,9 @@ var (
 	ErrNotFound      = errors.New("not found")
 	ErrMismatch      = errors.New("mismatch")
 	ErrNotEnough     = errors.New("not enough")
+	ErrWeakPassword  = errors.New("weak password")
+	ErrBadUsername   = errors.New("invalid username")
+	ErrBadScope      = errors.New("invalid scope")
+	ErrInvalidInput  = errors.New("invalid input")
+	ErrForbidden     = errors.New("forbidden")
 )
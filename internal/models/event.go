@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Domain event types written to the outbox.
+const (
+	EventCoinsTransferred = "coins.transferred"
+	EventItemPurchased    = "item.purchased"
+)
+
+// Event is a domain event stored in the outbox. Payload holds one of the *Event structs below.
+type Event struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"-"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+type CoinsTransferredEvent struct {
+	FromUserID uint   `json:"fromUserId"`
+	FromUser   string `json:"fromUser"`
+	ToUserID   uint   `json:"toUserId"`
+	ToUser     string `json:"toUser"`
+	Amount     uint   `json:"amount"`
+}
+
+type ItemPurchasedEvent struct {
+	UserID    uint   `json:"userId"`
+	Username  string `json:"user"`
+	ProductID uint   `json:"productId"`
+	Product   string `json:"product"`
+	Price     uint   `json:"price"`
+}
@@ -0,0 +1,8 @@
+package models
+
+import "time"
+
+type LoginAttempts struct {
+	Failures    int
+	LastFailure time.Time
+}
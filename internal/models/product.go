@@ -0,0 +1,34 @@
+package models
+
+type Product struct {
+	ID    uint   `json:"id"`
+	Name  string `json:"name"`
+	Price int    `json:"price"`
+	// Retired products are hidden from the catalog and cannot be bought, but keep their purchase history.
+	Retired bool `json:"-"`
+}
+
+// Catalog sync actions.
+const (
+	CatalogInsert  = "insert"
+	CatalogUpdate  = "update"
+	CatalogRetire  = "retire"
+	CatalogRestore = "restore"
+)
+
+// CatalogChange is one step of a catalog sync. OldPrice is set when the price changes.
+type CatalogChange struct {
+	Action   string `json:"action"`
+	ID       uint   `json:"id,omitempty"`
+	Name     string `json:"name"`
+	Price    int    `json:"price"`
+	OldPrice *int   `json:"oldPrice,omitempty"`
+}
+
+// CatalogDiff reports a catalog sync: the changes applied, or that would be applied on a dry run.
+type CatalogDiff struct {
+	DryRun    bool            `json:"dryRun"`
+	Retire    bool            `json:"retire"`
+	Unchanged int             `json:"unchanged"`
+	Changes   []CatalogChange `json:"changes"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "slices"
+
+// Roles a user can hold. A role grants its scopes to the user's own tokens; API keys stay limited
+// to the scopes they were issued with. RoleService grants nothing and marks accounts of automated
+// callers, which may hold API keys issued by someone else.
+const (
+	RoleAdmin   = "admin"
+	RoleHR      = "hr"
+	RoleService = "service"
+)
+
+var roleScopes = map[string][]string{
+	RoleAdmin:   Scopes,
+	RoleHR:      {ScopeUsersRead, ScopeUsersWrite, ScopeGrantWrite},
+	RoleService: nil,
+}
+
+func ValidRole(role string) bool {
+	_, ok := roleScopes[role]
+	return ok
+}
+
+// RoleScopes returns the union of the scopes granted by roles, ignoring unknown roles.
+func RoleScopes(roles []string) []string {
+	var scopes []string
+	for _, role := range roles {
+		for _, scope := range roleScopes[role] {
+			if !slices.Contains(scopes, scope) {
+				scopes = append(scopes, scope)
+			}
+		}
+	}
+	return scopes
+}
@@ -4,4 +4,5 @@ type User struct {
 	ID           uint   `json:"id"`
 	Username     string `json:"username"`
 	PasswordHash string `json:"-"`
+	TokenVersion int    `json:"-"`
 }
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"slices"
+	"time"
+)
+
+// AllEvents subscribes a webhook to every event type.
+const AllEvents = "*"
+
+// EventTypes lists the event types a webhook can subscribe to.
+var EventTypes = []string{EventCoinsTransferred, EventItemPurchased}
+
+func ValidEventType(eventType string) bool {
+	return eventType == AllEvents || slices.Contains(EventTypes, eventType)
+}
+
+// WebhookSubscription receives the events listed in Events at URL, signed with Secret.
+// The secret is only returned when the subscription is created.
+type WebhookSubscription struct {
+	ID        uint      `json:"id"`
+	URL       string    `json:"url"`
+	Events    []string  `json:"events"`
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Webhook delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed"
+)
+
+// WebhookDelivery is one event sent to one subscription, with the outcome of the last attempt.
+type WebhookDelivery struct {
+	ID             int64      `json:"id"`
+	SubscriptionID uint       `json:"subscriptionId"`
+	EventID        int64      `json:"eventId"`
+	EventType      string     `json:"eventType"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	LastStatusCode int        `json:"lastStatusCode,omitempty"`
+	LastError      string     `json:"lastError,omitempty"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	DeliveredAt    *time.Time `json:"deliveredAt,omitempty"`
+}
@@ -0,0 +1,152 @@
+package admin
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatJSON = "json"
+)
+
+// catalogHeader is the header row of the catalog CSV, which uses ';' as the delimiter.
+var catalogHeader = []string{"name", "price"}
+
+// DecodeCatalog reads products from a `name;price` CSV with a header row or from a JSON array of
+// products. IDs in the input are ignored; products are matched by name.
+func DecodeCatalog(r io.Reader, format string) ([]models.Product, error) {
+	var products []models.Product
+	switch format {
+	case FormatCSV:
+		reader := csv.NewReader(r)
+		reader.Comma = ';'
+		reader.FieldsPerRecord = len(catalogHeader)
+		reader.TrimLeadingSpace = true
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to read header: %v", models.ErrInvalidInput, err)
+		}
+		if !strings.EqualFold(strings.Join(header, ";"), strings.Join(catalogHeader, ";")) {
+			return nil, fmt.Errorf("%w: header must be %q", models.ErrInvalidInput, strings.Join(catalogHeader, ";"))
+		}
+		for {
+			record, err := reader.Read()
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+			}
+			line, _ := reader.FieldPos(0)
+			price, err := strconv.Atoi(record[1])
+			if err != nil {
+				return nil, fmt.Errorf("%w: line %d: invalid price %q", models.ErrInvalidInput, line, record[1])
+			}
+			products = append(products, models.Product{Name: record[0], Price: price})
+		}
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&products); err != nil {
+			return nil, fmt.Errorf("%w: %v", models.ErrInvalidInput, err)
+		}
+		for i := range products {
+			products[i].ID = 0
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown catalog format %q", models.ErrInvalidInput, format)
+	}
+	return products, ValidateCatalog(products)
+}
+
+// EncodeCatalog writes products in the format DecodeCatalog reads.
+func EncodeCatalog(w io.Writer, format string, products []models.Product) error {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		writer.Comma = ';'
+		if err := writer.Write(catalogHeader); err != nil {
+			return err
+		}
+		for _, product := range products {
+			if err := writer.Write([]string{product.Name, strconv.Itoa(product.Price)}); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	case FormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(products)
+	default:
+		return fmt.Errorf("%w: unknown catalog format %q", models.ErrInvalidInput, format)
+	}
+}
+
+// ValidateCatalog checks that every product has a name, a positive price and that names are unique.
+func ValidateCatalog(products []models.Product) error {
+	seen := make(map[string]struct{}, len(products))
+	for i, product := range products {
+		if strings.TrimSpace(product.Name) == "" {
+			return fmt.Errorf("%w: product %d: name is required", models.ErrInvalidInput, i+1)
+		}
+		if product.Price <= 0 {
+			return fmt.Errorf("%w: product %q: price must be positive", models.ErrInvalidInput, product.Name)
+		}
+		if _, ok := seen[product.Name]; ok {
+			return fmt.Errorf("%w: product %q is listed twice", models.ErrInvalidInput, product.Name)
+		}
+		seen[product.Name] = struct{}{}
+	}
+	return nil
+}
+
+// DiffCatalog plans the changes that turn current into desired, matching products by name. New
+// products are inserted, repriced ones updated and retired ones listed again are restored. Products
+// missing from desired are retired only with retire; retired products are never deleted.
+// It returns the changes and the number of products already up to date.
+func DiffCatalog(current, desired []models.Product, retire bool) ([]models.CatalogChange, int) {
+	byName := make(map[string]models.Product, len(current))
+	for _, product := range current {
+		byName[product.Name] = product
+	}
+
+	changes := []models.CatalogChange{}
+	unchanged := 0
+	listed := make(map[string]struct{}, len(desired))
+	for _, product := range desired {
+		listed[product.Name] = struct{}{}
+		existing, ok := byName[product.Name]
+		change := models.CatalogChange{ID: existing.ID, Name: product.Name, Price: product.Price}
+		if existing.Price != product.Price {
+			change.OldPrice = &existing.Price
+		}
+		switch {
+		case !ok:
+			change.Action, change.OldPrice = models.CatalogInsert, nil
+		case existing.Retired:
+			change.Action = models.CatalogRestore
+		case change.OldPrice != nil:
+			change.Action = models.CatalogUpdate
+		default:
+			unchanged++
+			continue
+		}
+		changes = append(changes, change)
+	}
+
+	if retire {
+		for _, product := range current {
+			if _, ok := listed[product.Name]; !ok && !product.Retired {
+				changes = append(changes, models.CatalogChange{Action: models.CatalogRetire, ID: product.ID, Name: product.Name, Price: product.Price})
+			}
+		}
+	}
+	return changes, unchanged
+}
@@ -0,0 +1,103 @@
+package admin
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeCatalog(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   string
+		input    string
+		expected []models.Product
+		err      string
+	}{
+		{
+			name:     "CSV",
+			format:   FormatCSV,
+			input:    "name;price\nt-shirt;80\n\"cup; large\";25\n",
+			expected: []models.Product{{Name: "t-shirt", Price: 80}, {Name: "cup; large", Price: 25}},
+		},
+		{
+			name:     "JSON ignores ids",
+			format:   FormatJSON,
+			input:    `[{"id":7,"name":"pen","price":10}]`,
+			expected: []models.Product{{Name: "pen", Price: 10}},
+		},
+		{name: "Wrong header", format: FormatCSV, input: "title;cost\npen;10\n", err: `header must be "name;price"`},
+		{name: "Bad price", format: FormatCSV, input: "name;price\npen;ten\n", err: `line 2: invalid price "ten"`},
+		{name: "Non-positive price", format: FormatJSON, input: `[{"name":"pen","price":0}]`, err: `product "pen": price must be positive`},
+		{name: "Duplicate", format: FormatCSV, input: "name;price\npen;10\npen;12\n", err: `product "pen" is listed twice`},
+		{name: "Unknown format", format: "xml", input: "", err: `unknown catalog format "xml"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			products, err := DecodeCatalog(strings.NewReader(tt.input), tt.format)
+			if tt.err != "" {
+				assert.ErrorIs(t, err, models.ErrInvalidInput)
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, products)
+		})
+	}
+}
+
+func TestEncodeCatalog_RoundTrip(t *testing.T) {
+	products := []models.Product{{Name: "hoody", Price: 300}, {Name: "cup; large", Price: 25}}
+	for _, format := range []string{FormatCSV, FormatJSON} {
+		var buf bytes.Buffer
+		assert.NoError(t, EncodeCatalog(&buf, format, products))
+
+		decoded, err := DecodeCatalog(&buf, format)
+		assert.NoError(t, err, format)
+		assert.Equal(t, products, decoded, format)
+	}
+}
+
+func TestDiffCatalog(t *testing.T) {
+	current := []models.Product{
+		{ID: 1, Name: "pen", Price: 10},
+		{ID: 2, Name: "cup", Price: 20},
+		{ID: 3, Name: "hoody", Price: 300},
+		{ID: 4, Name: "socks", Price: 10, Retired: true},
+	}
+	desired := []models.Product{{Name: "pen", Price: 10}, {Name: "cup", Price: 25}, {Name: "socks", Price: 12}, {Name: "umbrella", Price: 200}}
+	oldCup, oldSocks := 20, 10
+
+	t.Run("Without retirements", func(t *testing.T) {
+		changes, unchanged := DiffCatalog(current, desired, false)
+		assert.Equal(t, 1, unchanged)
+		assert.Equal(t, []models.CatalogChange{
+			{Action: models.CatalogUpdate, ID: 2, Name: "cup", Price: 25, OldPrice: &oldCup},
+			{Action: models.CatalogRestore, ID: 4, Name: "socks", Price: 12, OldPrice: &oldSocks},
+			{Action: models.CatalogInsert, Name: "umbrella", Price: 200},
+		}, changes)
+	})
+
+	t.Run("With retirements", func(t *testing.T) {
+		changes, _ := DiffCatalog(current, desired, true)
+		assert.Len(t, changes, 4)
+		assert.Equal(t, models.CatalogChange{Action: models.CatalogRetire, ID: 3, Name: "hoody", Price: 300}, changes[3])
+	})
+
+	t.Run("Idempotent", func(t *testing.T) {
+		synced := []models.Product{
+			{ID: 1, Name: "pen", Price: 10},
+			{ID: 2, Name: "cup", Price: 25},
+			{ID: 3, Name: "hoody", Price: 300, Retired: true},
+			{ID: 4, Name: "socks", Price: 12},
+			{ID: 5, Name: "umbrella", Price: 200},
+		}
+		changes, unchanged := DiffCatalog(synced, desired, true)
+		assert.Empty(t, changes)
+		assert.Equal(t, 4, unchanged)
+	})
+}
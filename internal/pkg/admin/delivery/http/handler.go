@@ -0,0 +1,129 @@
+package http
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/admin"
+	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
+	"Merch_store-Avito_test_task/internal/pkg/middleware"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// maxImportSize bounds an uploaded provisioning or catalog file.
+const maxImportSize = 1 << 20
+
+var (
+	errInvalidFlag  = httpresponses.NewError(http.StatusBadRequest, httpresponses.CodeInvalidRequest, "dryRun and atomic must be true or false")
+	errFileTooLarge = httpresponses.NewError(http.StatusRequestEntityTooLarge, httpresponses.CodeInvalidRequest, "file is too large")
+	errSyncFlag     = httpresponses.NewError(http.StatusBadRequest, httpresponses.CodeInvalidRequest, "dryRun and retire must be true or false")
+	errMediaType    = httpresponses.NewError(http.StatusUnsupportedMediaType, httpresponses.CodeInvalidRequest, "content type must be text/csv or application/json")
+)
+
+type AdminHandler struct {
+	uc     admin.AdminUsecase
+	logger *slog.Logger
+}
+
+func NewAdminHandler(uc admin.AdminUsecase, logger *slog.Logger) *AdminHandler {
+	return &AdminHandler{uc: uc, logger: logger}
+}
+
+// ImportUsers provisions users from a CSV body. The report lists the outcome of every row; failed
+// rows do not change the status code, only an unreadable file does. Rows assigning roles that grant
+// scopes the caller lacks fail.
+func (h *AdminHandler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	dryRun, err := queryBool(r, "dryRun")
+	if err != nil {
+		httpresponses.SendError(w, r, errInvalidFlag, h.logger)
+		return
+	}
+	atomic, err := queryBool(r, "atomic")
+	if err != nil {
+		httpresponses.SendError(w, r, errInvalidFlag, h.logger)
+		return
+	}
+
+	rows, err := admin.ParseUsersCSV(http.MaxBytesReader(w, r.Body, maxImportSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = errFileTooLarge
+		}
+		h.logger.WarnContext(ctx, "invalid provisioning file", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+
+	scopes, _ := ctx.Value(middleware.ScopesKey).([]string)
+	admin.RestrictRoles(rows, scopes)
+
+	report, err := h.uc.ProvisionUsers(ctx, rows, atomic, dryRun)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to provision users", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	h.logger.InfoContext(ctx, "users provisioned", slog.Bool("dryRun", dryRun), slog.Bool("atomic", atomic),
+		slog.Int("rows", len(rows)), slog.Int("created", report.Created), slog.Int("failed", report.Failed))
+	httpresponses.SendJSONResponse(ctx, w, report, http.StatusOK, h.logger)
+}
+
+// SyncCatalog makes the catalog match the uploaded CSV or JSON file, chosen by Content-Type, and
+// returns the diff. With dryRun nothing is changed; with retire unlisted products are retired.
+func (h *AdminHandler) SyncCatalog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	dryRun, err := queryBool(r, "dryRun")
+	if err != nil {
+		httpresponses.SendError(w, r, errSyncFlag, h.logger)
+		return
+	}
+	retire, err := queryBool(r, "retire")
+	if err != nil {
+		httpresponses.SendError(w, r, errSyncFlag, h.logger)
+		return
+	}
+
+	var format string
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		format = admin.FormatCSV
+	case "application/json":
+		format = admin.FormatJSON
+	default:
+		httpresponses.SendError(w, r, errMediaType, h.logger)
+		return
+	}
+
+	products, err := admin.DecodeCatalog(http.MaxBytesReader(w, r.Body, maxImportSize), format)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			err = errFileTooLarge
+		}
+		h.logger.WarnContext(ctx, "invalid catalog file", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+
+	diff, err := h.uc.SyncCatalog(ctx, products, retire, dryRun)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to sync catalog", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	h.logger.InfoContext(ctx, "catalog synced", slog.Bool("dryRun", dryRun), slog.Bool("retire", retire),
+		slog.Int("products", len(products)), slog.Int("changes", len(diff.Changes)))
+	httpresponses.SendJSONResponse(ctx, w, diff, http.StatusOK, h.logger)
+}
+
+func queryBool(r *http.Request, name string) (bool, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(value)
+}
@@ -0,0 +1,179 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/admin"
+	mocks "Merch_store-Avito_test_task/internal/pkg/admin/mocks"
+	"Merch_store-Avito_test_task/internal/pkg/middleware"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportUsers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsecase := mocks.NewMockAdminUsecase(ctrl)
+	handler := NewAdminHandler(mockUsecase, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+
+	tests := []struct {
+		name           string
+		query          string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:  "Dry run report",
+			query: "?dryRun=true&atomic=1",
+			body:  "username\nalice\n",
+			setupMock: func() {
+				mockUsecase.EXPECT().ProvisionUsers(gomock.Any(), []admin.UserRow{{Line: 2, Username: "alice"}}, true, true).
+					Return(models.ProvisionReport{DryRun: true, Atomic: true, Rows: []models.ProvisionRow{{Line: 2, Username: "alice", Status: models.ProvisionValid}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"dryRun":true,"atomic":true,"created":0,"failed":0,"rows":[{"line":2,"username":"alice","status":"valid"}]}`,
+		},
+		{
+			name:           "Invalid flag",
+			query:          "?dryRun=maybe",
+			body:           "username\nalice\n",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Missing username column",
+			body:           "coins\n100\n",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "File too large",
+			body:           "username\n" + strings.Repeat("a", maxImportSize),
+			setupMock:      func() {},
+			expectedStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/users/import"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "text/csv")
+			rr := httptest.NewRecorder()
+
+			handler.ImportUsers(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+
+	t.Run("Roles above the caller's scopes are rejected", func(t *testing.T) {
+		mockUsecase.EXPECT().ProvisionUsers(gomock.Any(), gomock.Any(), false, false).
+			DoAndReturn(func(_ context.Context, rows []admin.UserRow, _, _ bool) (models.ProvisionReport, error) {
+				assert.NoError(t, rows[0].Err)
+				assert.ErrorIs(t, rows[1].Err, models.ErrInvalidInput)
+				assert.ErrorContains(t, rows[1].Err, `role "admin"`)
+				return models.ProvisionReport{}, nil
+			})
+		req := httptest.NewRequest(http.MethodPost, "/api/admin/users/import", strings.NewReader("username,roles\nalice,hr\nmallory,admin|hr\n"))
+		req = req.WithContext(context.WithValue(req.Context(), middleware.ScopesKey, models.RoleScopes([]string{models.RoleHR})))
+		rr := httptest.NewRecorder()
+
+		handler.ImportUsers(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	})
+}
+
+func TestSyncCatalog(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsecase := mocks.NewMockAdminUsecase(ctrl)
+	handler := NewAdminHandler(mockUsecase, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	oldPrice := 20
+
+	tests := []struct {
+		name           string
+		query          string
+		contentType    string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "CSV dry run",
+			query:       "?dryRun=true",
+			contentType: "text/csv; charset=utf-8",
+			body:        "name;price\ncup;25\n",
+			setupMock: func() {
+				mockUsecase.EXPECT().SyncCatalog(gomock.Any(), []models.Product{{Name: "cup", Price: 25}}, false, true).
+					Return(models.CatalogDiff{DryRun: true, Changes: []models.CatalogChange{{Action: models.CatalogUpdate, ID: 2, Name: "cup", Price: 25, OldPrice: &oldPrice}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"dryRun":true,"retire":false,"unchanged":0,"changes":[{"action":"update","id":2,"name":"cup","price":25,"oldPrice":20}]}`,
+		},
+		{
+			name:        "JSON with retirements",
+			query:       "?retire=true",
+			contentType: "application/json",
+			body:        `[{"name":"pen","price":10}]`,
+			setupMock: func() {
+				mockUsecase.EXPECT().SyncCatalog(gomock.Any(), []models.Product{{Name: "pen", Price: 10}}, true, false).
+					Return(models.CatalogDiff{Retire: true, Unchanged: 1, Changes: []models.CatalogChange{}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"dryRun":false,"retire":true,"unchanged":1,"changes":[]}`,
+		},
+		{
+			name:           "Unsupported content type",
+			contentType:    "application/xml",
+			body:           "<catalog/>",
+			setupMock:      func() {},
+			expectedStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:           "Invalid flag",
+			query:          "?retire=sometimes",
+			contentType:    "text/csv",
+			body:           "name;price\ncup;25\n",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+		{
+			name:           "Invalid price",
+			contentType:    "text/csv",
+			body:           "name;price\ncup;free\n",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/catalog/sync"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+
+			handler.SyncCatalog(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, rr.Body.String())
+			}
+		})
+	}
+}
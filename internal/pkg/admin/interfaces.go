@@ -0,0 +1,35 @@
+package admin
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+)
+
+//go:generate mockgen -source=interfaces.go -destination=mocks/mock.go
+type AdminUsecase interface {
+	ListUsers(ctx context.Context) ([]models.UserSummary, error)
+	CreateUser(ctx context.Context, username, password string) (models.User, error)
+	ResetPassword(ctx context.Context, username, password string) (models.User, error)
+	AdjustBalance(ctx context.Context, username string, amount int, reason string) (models.BalanceAdjustment, error)
+	UserInfo(ctx context.Context, username string) (models.UserData, error)
+	ExportCatalog(ctx context.Context) ([]models.Product, error)
+	SyncCatalog(ctx context.Context, products []models.Product, retire, dryRun bool) (models.CatalogDiff, error)
+	ProvisionUsers(ctx context.Context, rows []UserRow, atomic, dryRun bool) (models.ProvisionReport, error)
+}
+
+type AdminRepository interface {
+	ListUsers(ctx context.Context) ([]models.UserSummary, error)
+	AdjustBalance(ctx context.Context, adjustment models.BalanceAdjustment) (models.BalanceAdjustment, error)
+	SyncCatalog(ctx context.Context, plan CatalogPlan, dryRun bool) ([]models.CatalogChange, int, error)
+	CreateUsers(ctx context.Context, users []models.NewUser, atomic, dryRun bool) ([]RowResult, error)
+}
+
+// CatalogPlan computes the changes to apply to the current catalog, including retired products, and
+// the number of products left unchanged.
+type CatalogPlan func(current []models.Product) ([]models.CatalogChange, int)
+
+// RowResult is the outcome of creating one user in CreateUsers.
+type RowResult struct {
+	ID  uint
+	Err error
+}
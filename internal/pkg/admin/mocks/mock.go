@@ -0,0 +1,241 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: interfaces.go
+
+// Package mock_admin is a generated GoMock package.
+package mock_admin
+
+import (
+	models "Merch_store-Avito_test_task/internal/models"
+	admin "Merch_store-Avito_test_task/internal/pkg/admin"
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockAdminUsecase is a mock of AdminUsecase interface.
+type MockAdminUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminUsecaseMockRecorder
+}
+
+// MockAdminUsecaseMockRecorder is the mock recorder for MockAdminUsecase.
+type MockAdminUsecaseMockRecorder struct {
+	mock *MockAdminUsecase
+}
+
+// NewMockAdminUsecase creates a new mock instance.
+func NewMockAdminUsecase(ctrl *gomock.Controller) *MockAdminUsecase {
+	mock := &MockAdminUsecase{ctrl: ctrl}
+	mock.recorder = &MockAdminUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminUsecase) EXPECT() *MockAdminUsecaseMockRecorder {
+	return m.recorder
+}
+
+// AdjustBalance mocks base method.
+func (m *MockAdminUsecase) AdjustBalance(ctx context.Context, username string, amount int, reason string) (models.BalanceAdjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustBalance", ctx, username, amount, reason)
+	ret0, _ := ret[0].(models.BalanceAdjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustBalance indicates an expected call of AdjustBalance.
+func (mr *MockAdminUsecaseMockRecorder) AdjustBalance(ctx, username, amount, reason interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustBalance", reflect.TypeOf((*MockAdminUsecase)(nil).AdjustBalance), ctx, username, amount, reason)
+}
+
+// CreateUser mocks base method.
+func (m *MockAdminUsecase) CreateUser(ctx context.Context, username, password string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUser", ctx, username, password)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUser indicates an expected call of CreateUser.
+func (mr *MockAdminUsecaseMockRecorder) CreateUser(ctx, username, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAdminUsecase)(nil).CreateUser), ctx, username, password)
+}
+
+// ExportCatalog mocks base method.
+func (m *MockAdminUsecase) ExportCatalog(ctx context.Context) ([]models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExportCatalog", ctx)
+	ret0, _ := ret[0].([]models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExportCatalog indicates an expected call of ExportCatalog.
+func (mr *MockAdminUsecaseMockRecorder) ExportCatalog(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExportCatalog", reflect.TypeOf((*MockAdminUsecase)(nil).ExportCatalog), ctx)
+}
+
+// ListUsers mocks base method.
+func (m *MockAdminUsecase) ListUsers(ctx context.Context) ([]models.UserSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx)
+	ret0, _ := ret[0].([]models.UserSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAdminUsecaseMockRecorder) ListUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAdminUsecase)(nil).ListUsers), ctx)
+}
+
+// ProvisionUsers mocks base method.
+func (m *MockAdminUsecase) ProvisionUsers(ctx context.Context, rows []admin.UserRow, atomic, dryRun bool) (models.ProvisionReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ProvisionUsers", ctx, rows, atomic, dryRun)
+	ret0, _ := ret[0].(models.ProvisionReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ProvisionUsers indicates an expected call of ProvisionUsers.
+func (mr *MockAdminUsecaseMockRecorder) ProvisionUsers(ctx, rows, atomic, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ProvisionUsers", reflect.TypeOf((*MockAdminUsecase)(nil).ProvisionUsers), ctx, rows, atomic, dryRun)
+}
+
+// ResetPassword mocks base method.
+func (m *MockAdminUsecase) ResetPassword(ctx context.Context, username, password string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResetPassword", ctx, username, password)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResetPassword indicates an expected call of ResetPassword.
+func (mr *MockAdminUsecaseMockRecorder) ResetPassword(ctx, username, password interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetPassword", reflect.TypeOf((*MockAdminUsecase)(nil).ResetPassword), ctx, username, password)
+}
+
+// SyncCatalog mocks base method.
+func (m *MockAdminUsecase) SyncCatalog(ctx context.Context, products []models.Product, retire, dryRun bool) (models.CatalogDiff, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncCatalog", ctx, products, retire, dryRun)
+	ret0, _ := ret[0].(models.CatalogDiff)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SyncCatalog indicates an expected call of SyncCatalog.
+func (mr *MockAdminUsecaseMockRecorder) SyncCatalog(ctx, products, retire, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncCatalog", reflect.TypeOf((*MockAdminUsecase)(nil).SyncCatalog), ctx, products, retire, dryRun)
+}
+
+// UserInfo mocks base method.
+func (m *MockAdminUsecase) UserInfo(ctx context.Context, username string) (models.UserData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UserInfo", ctx, username)
+	ret0, _ := ret[0].(models.UserData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UserInfo indicates an expected call of UserInfo.
+func (mr *MockAdminUsecaseMockRecorder) UserInfo(ctx, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UserInfo", reflect.TypeOf((*MockAdminUsecase)(nil).UserInfo), ctx, username)
+}
+
+// MockAdminRepository is a mock of AdminRepository interface.
+type MockAdminRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminRepositoryMockRecorder
+}
+
+// MockAdminRepositoryMockRecorder is the mock recorder for MockAdminRepository.
+type MockAdminRepositoryMockRecorder struct {
+	mock *MockAdminRepository
+}
+
+// NewMockAdminRepository creates a new mock instance.
+func NewMockAdminRepository(ctrl *gomock.Controller) *MockAdminRepository {
+	mock := &MockAdminRepository{ctrl: ctrl}
+	mock.recorder = &MockAdminRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAdminRepository) EXPECT() *MockAdminRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AdjustBalance mocks base method.
+func (m *MockAdminRepository) AdjustBalance(ctx context.Context, adjustment models.BalanceAdjustment) (models.BalanceAdjustment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustBalance", ctx, adjustment)
+	ret0, _ := ret[0].(models.BalanceAdjustment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustBalance indicates an expected call of AdjustBalance.
+func (mr *MockAdminRepositoryMockRecorder) AdjustBalance(ctx, adjustment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustBalance", reflect.TypeOf((*MockAdminRepository)(nil).AdjustBalance), ctx, adjustment)
+}
+
+// CreateUsers mocks base method.
+func (m *MockAdminRepository) CreateUsers(ctx context.Context, users []models.NewUser, atomic, dryRun bool) ([]admin.RowResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateUsers", ctx, users, atomic, dryRun)
+	ret0, _ := ret[0].([]admin.RowResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateUsers indicates an expected call of CreateUsers.
+func (mr *MockAdminRepositoryMockRecorder) CreateUsers(ctx, users, atomic, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUsers", reflect.TypeOf((*MockAdminRepository)(nil).CreateUsers), ctx, users, atomic, dryRun)
+}
+
+// ListUsers mocks base method.
+func (m *MockAdminRepository) ListUsers(ctx context.Context) ([]models.UserSummary, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsers", ctx)
+	ret0, _ := ret[0].([]models.UserSummary)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsers indicates an expected call of ListUsers.
+func (mr *MockAdminRepositoryMockRecorder) ListUsers(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsers", reflect.TypeOf((*MockAdminRepository)(nil).ListUsers), ctx)
+}
+
+// SyncCatalog mocks base method.
+func (m *MockAdminRepository) SyncCatalog(ctx context.Context, plan admin.CatalogPlan, dryRun bool) ([]models.CatalogChange, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SyncCatalog", ctx, plan, dryRun)
+	ret0, _ := ret[0].([]models.CatalogChange)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SyncCatalog indicates an expected call of SyncCatalog.
+func (mr *MockAdminRepositoryMockRecorder) SyncCatalog(ctx, plan, dryRun interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SyncCatalog", reflect.TypeOf((*MockAdminRepository)(nil).SyncCatalog), ctx, plan, dryRun)
+}
@@ -0,0 +1,135 @@
+package admin
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// MaxProvisionRows bounds a single provisioning file.
+const MaxProvisionRows = 1000
+
+// UserRow is one data row of a provisioning CSV. Err is set when the row itself could not be parsed.
+type UserRow struct {
+	Line     int
+	Username string
+	Coins    *int
+	Roles    []string
+	Password string
+	Err      error
+}
+
+// ParseUsersCSV reads a provisioning CSV. The header names the columns in any order: username is
+// required, coins, roles ('|'-separated) and password are optional. Fields are separated by ',' or,
+// if the header contains one, by ';'. Row problems are reported in UserRow.Err; only an unreadable
+// file or header fails the whole parse.
+func ParseUsersCSV(r io.Reader) ([]UserRow, error) {
+	buffered := bufio.NewReader(r)
+	firstLine, err := buffered.Peek(buffered.Size())
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, bufio.ErrBufferFull) {
+		return nil, fmt.Errorf("%w: %w", models.ErrInvalidInput, err)
+	}
+	if i := slices.Index(firstLine, '\n'); i >= 0 {
+		firstLine = firstLine[:i]
+	}
+
+	reader := csv.NewReader(buffered)
+	reader.TrimLeadingSpace = true
+	if slices.Contains(firstLine, ';') {
+		reader.Comma = ';'
+	}
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read header: %w", models.ErrInvalidInput, err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "username", "coins", "roles", "password":
+		default:
+			return nil, fmt.Errorf("%w: unknown column %q", models.ErrInvalidInput, name)
+		}
+		if _, ok := columns[name]; ok {
+			return nil, fmt.Errorf("%w: column %q is listed twice", models.ErrInvalidInput, name)
+		}
+		columns[name] = i
+	}
+	if _, ok := columns["username"]; !ok {
+		return nil, fmt.Errorf("%w: a username column is required", models.ErrInvalidInput)
+	}
+
+	var rows []UserRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line, _ := reader.FieldPos(0)
+		if err != nil && !errors.Is(err, csv.ErrFieldCount) {
+			return nil, fmt.Errorf("%w: %w", models.ErrInvalidInput, err)
+		}
+		if len(rows) == MaxProvisionRows {
+			return nil, fmt.Errorf("%w: at most %d rows are allowed", models.ErrInvalidInput, MaxProvisionRows)
+		}
+		row := UserRow{Line: line}
+		if err != nil {
+			row.Err = fmt.Errorf("expected %d fields, got %d", len(header), len(record))
+			rows = append(rows, row)
+			continue
+		}
+		field := func(name string) string {
+			if i, ok := columns[name]; ok {
+				return strings.TrimSpace(record[i])
+			}
+			return ""
+		}
+		row.Username = field("username")
+		row.Password = field("password")
+		if coins := field("coins"); coins != "" {
+			n, err := strconv.Atoi(coins)
+			if err != nil {
+				row.Err = fmt.Errorf("invalid coins %q", coins)
+			}
+			row.Coins = &n
+		}
+		for _, role := range strings.Split(field("roles"), "|") {
+			if role = strings.TrimSpace(role); role != "" && !slices.Contains(row.Roles, role) {
+				row.Roles = append(row.Roles, role)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// RestrictRoles fails the rows whose roles grant a scope missing from granted, so a caller cannot
+// provision an account with more privileges than its own. Unknown roles are left to validation.
+func RestrictRoles(rows []UserRow, granted []string) {
+	for i := range rows {
+		if rows[i].Err != nil {
+			continue
+		}
+		for _, role := range rows[i].Roles {
+			if scope, ok := missingScope(role, granted); ok {
+				rows[i].Err = fmt.Errorf("%w: role %q grants %s, which the caller does not hold", models.ErrInvalidInput, role, scope)
+				break
+			}
+		}
+	}
+}
+
+func missingScope(role string, granted []string) (string, bool) {
+	for _, scope := range models.RoleScopes([]string{role}) {
+		if !slices.Contains(granted, scope) {
+			return scope, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,47 @@
+package admin
+
+import (
+	"strings"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUsersCSV(t *testing.T) {
+	coins := 1500
+
+	t.Run("Columns in any order", func(t *testing.T) {
+		rows, err := ParseUsersCSV(strings.NewReader("roles,username,coins\nhr|admin|hr,alice,1500\n,bob,\n"))
+		assert.NoError(t, err)
+		assert.Equal(t, []UserRow{
+			{Line: 2, Username: "alice", Coins: &coins, Roles: []string{models.RoleHR, models.RoleAdmin}},
+			{Line: 3, Username: "bob"},
+		}, rows)
+	})
+
+	t.Run("Semicolons and row errors", func(t *testing.T) {
+		rows, err := ParseUsersCSV(strings.NewReader("username;coins;password\ncarol;lots;\ndave\nerin;10;S3cret-pass\n"))
+		assert.NoError(t, err)
+		assert.Len(t, rows, 3)
+		assert.EqualError(t, rows[0].Err, `invalid coins "lots"`)
+		assert.EqualError(t, rows[1].Err, "expected 3 fields, got 1")
+		assert.Equal(t, 4, rows[2].Line)
+		assert.Equal(t, "S3cret-pass", rows[2].Password)
+		assert.NoError(t, rows[2].Err)
+	})
+
+	t.Run("Bad header", func(t *testing.T) {
+		for _, input := range []string{"", "coins,roles\n", "username,email\n", "username,username\n"} {
+			_, err := ParseUsersCSV(strings.NewReader(input))
+			assert.ErrorIs(t, err, models.ErrInvalidInput, input)
+		}
+	})
+
+	t.Run("Too many rows", func(t *testing.T) {
+		input := "username\n" + strings.Repeat("someone\n", MaxProvisionRows+1)
+		_, err := ParseUsersCSV(strings.NewReader(input))
+		assert.ErrorIs(t, err, models.ErrInvalidInput)
+	})
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/admin"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+)
+
+type AdminRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAdminRepositoryImpl(db *sql.DB) *AdminRepositoryImpl {
+	return &AdminRepositoryImpl{db: db}
+}
+
+func (repo *AdminRepositoryImpl) ListUsers(ctx context.Context) ([]models.UserSummary, error) {
+	query := `SELECT id, username, coins, roles, created_at FROM "user" ORDER BY id`
+	rows, err := repo.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.UserSummary{}
+	for rows.Next() {
+		var user models.UserSummary
+		if err = rows.Scan(&user.ID, &user.Username, &user.Coins, pq.Array(&user.Roles), &user.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// AdjustBalance changes the user's balance by adjustment.Amount and records the adjustment with its
+// reason in the same transaction. A debit below zero fails with models.ErrNotEnough.
+func (repo *AdminRepositoryImpl) AdjustBalance(ctx context.Context, adjustment models.BalanceAdjustment) (models.BalanceAdjustment, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return models.BalanceAdjustment{}, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `UPDATE "user" SET coins = coins + $1, updated_at = NOW() WHERE username = $2 RETURNING id, coins`
+	err = tx.QueryRowContext(ctx, query, adjustment.Amount, adjustment.Username).Scan(&adjustment.UserID, &adjustment.Balance)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23514" {
+			return models.BalanceAdjustment{}, models.ErrNotEnough
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.BalanceAdjustment{}, fmt.Errorf("user not found: %w", models.ErrNotFound)
+		}
+		return models.BalanceAdjustment{}, fmt.Errorf("failed to update balance: %w", err)
+	}
+
+	query = `INSERT INTO "balance_adjustment" (user_id, amount, reason) VALUES ($1, $2, $3) RETURNING id, created_at`
+	err = tx.QueryRowContext(ctx, query, adjustment.UserID, adjustment.Amount, adjustment.Reason).Scan(&adjustment.ID, &adjustment.CreatedAt)
+	if err != nil {
+		return models.BalanceAdjustment{}, fmt.Errorf("failed to record adjustment: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return models.BalanceAdjustment{}, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return adjustment, nil
+}
+
+// SyncCatalog applies the changes plan computes from the current catalog. The product table is locked
+// against concurrent writers from reading it to commit, so two syncs cannot interleave and a sync never
+// works from a stale catalog. With dryRun the changes are only computed and nothing is locked.
+func (repo *AdminRepositoryImpl) SyncCatalog(ctx context.Context, plan admin.CatalogPlan, dryRun bool) ([]models.CatalogChange, int, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if !dryRun {
+		if _, err = tx.ExecContext(ctx, `LOCK TABLE "product" IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+			return nil, 0, fmt.Errorf("failed to lock catalog: %w", err)
+		}
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT id, name, price, retired_at IS NOT NULL FROM "product" ORDER BY id`)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get products: %w", err)
+	}
+	var current []models.Product
+	for rows.Next() {
+		var product models.Product
+		if err = rows.Scan(&product.ID, &product.Name, &product.Price, &product.Retired); err != nil {
+			rows.Close()
+			return nil, 0, fmt.Errorf("failed to scan product: %w", err)
+		}
+		current = append(current, product)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to get products: %w", err)
+	}
+
+	changes, unchanged := plan(current)
+	if dryRun {
+		return changes, unchanged, nil
+	}
+	for i, change := range changes {
+		switch change.Action {
+		case models.CatalogInsert:
+			err = tx.QueryRowContext(ctx, `INSERT INTO "product" (name, price) VALUES ($1, $2) RETURNING id`,
+				change.Name, change.Price).Scan(&changes[i].ID)
+		case models.CatalogUpdate:
+			_, err = tx.ExecContext(ctx, `UPDATE "product" SET price = $1, updated_at = NOW() WHERE id = $2`, change.Price, change.ID)
+		case models.CatalogRestore:
+			_, err = tx.ExecContext(ctx, `UPDATE "product" SET price = $1, retired_at = NULL, updated_at = NOW() WHERE id = $2`,
+				change.Price, change.ID)
+		case models.CatalogRetire:
+			_, err = tx.ExecContext(ctx, `UPDATE "product" SET retired_at = NOW(), updated_at = NOW() WHERE id = $1`, change.ID)
+		default:
+			err = fmt.Errorf("unknown action %q", change.Action)
+		}
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to %s product %q: %w", change.Action, change.Name, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return changes, unchanged, nil
+}
+
+// CreateUsers creates users and reports the outcome per user. Without atomic every user is created in
+// its own transaction. With atomic all of them share one transaction, each behind a savepoint so the
+// remaining users are still checked after a failure, and nothing is committed unless all succeed.
+// With dryRun every transaction is rolled back.
+func (repo *AdminRepositoryImpl) CreateUsers(ctx context.Context, users []models.NewUser, atomic, dryRun bool) ([]admin.RowResult, error) {
+	results := make([]admin.RowResult, len(users))
+	if !atomic {
+		for i, user := range users {
+			results[i].ID, results[i].Err = repo.createUserTx(ctx, user, dryRun)
+		}
+		return results, nil
+	}
+
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	failed := false
+	for i, user := range users {
+		if _, err = tx.ExecContext(ctx, `SAVEPOINT provision_user`); err != nil {
+			return nil, fmt.Errorf("failed to create savepoint: %w", err)
+		}
+		results[i].ID, results[i].Err = createUser(ctx, tx, user)
+		if results[i].Err != nil {
+			failed = true
+			if _, err = tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT provision_user`); err != nil {
+				return nil, fmt.Errorf("failed to roll back to savepoint: %w", err)
+			}
+		}
+	}
+	if failed || dryRun {
+		return results, nil
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return results, nil
+}
+
+func (repo *AdminRepositoryImpl) createUserTx(ctx context.Context, user models.NewUser, dryRun bool) (uint, error) {
+	tx, err := repo.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	id, err := createUser(ctx, tx, user)
+	if err != nil || dryRun {
+		return id, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return id, nil
+}
+
+func createUser(ctx context.Context, tx *sql.Tx, user models.NewUser) (uint, error) {
+	var id uint
+	var err error
+	if user.Coins == nil {
+		query := `INSERT INTO "user" (username, password_hash, roles) VALUES ($1, $2, $3) RETURNING id`
+		err = tx.QueryRowContext(ctx, query, user.Username, user.PasswordHash, pq.Array(user.Roles)).Scan(&id)
+	} else {
+		query := `INSERT INTO "user" (username, password_hash, roles, coins) VALUES ($1, $2, $3, $4) RETURNING id`
+		err = tx.QueryRowContext(ctx, query, user.Username, user.PasswordHash, pq.Array(user.Roles), *user.Coins).Scan(&id)
+	}
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("user %s %w", user.Username, models.ErrAlreadyExists)
+		}
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+	return id, nil
+}
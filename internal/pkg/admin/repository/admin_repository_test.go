@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdminRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAdminRepositoryImpl(db)
+	ctx := context.Background()
+	created := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("ListUsers", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, username, coins, roles, created_at FROM "user" ORDER BY id`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins", "roles", "created_at"}).AddRow(1, "alice", 1000, "{hr}", created))
+
+		users, err := repo.ListUsers(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.UserSummary{{ID: 1, Username: "alice", Coins: 1000, Roles: []string{models.RoleHR}, CreatedAt: created}}, users)
+	})
+
+	t.Run("AdjustBalance", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE "user" SET coins = coins \+ \$1, updated_at = NOW\(\) WHERE username = \$2 RETURNING id, coins`).
+			WithArgs(200, "alice").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}).AddRow(1, 1200))
+		mock.ExpectQuery(`INSERT INTO "balance_adjustment" \(user_id, amount, reason\) VALUES \(\$1, \$2, \$3\) RETURNING id, created_at`).
+			WithArgs(1, 200, "hackathon prize").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(3, created))
+		mock.ExpectCommit()
+
+		adjustment, err := repo.AdjustBalance(ctx, models.BalanceAdjustment{Username: "alice", Amount: 200, Reason: "hackathon prize"})
+		assert.NoError(t, err)
+		assert.Equal(t, models.BalanceAdjustment{ID: 3, UserID: 1, Username: "alice", Amount: 200, Reason: "hackathon prize", Balance: 1200, CreatedAt: created}, adjustment)
+	})
+
+	t.Run("AdjustBalance below zero", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE "user" SET coins = coins \+ \$1`).
+			WithArgs(-5000, "alice").
+			WillReturnError(&pq.Error{Code: "23514"})
+		mock.ExpectRollback()
+
+		_, err := repo.AdjustBalance(ctx, models.BalanceAdjustment{Username: "alice", Amount: -5000, Reason: "typo"})
+		assert.ErrorIs(t, err, models.ErrNotEnough)
+	})
+
+	t.Run("AdjustBalance unknown user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(`UPDATE "user" SET coins = coins \+ \$1`).
+			WithArgs(10, "ghost").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "coins"}))
+		mock.ExpectRollback()
+
+		_, err := repo.AdjustBalance(ctx, models.BalanceAdjustment{Username: "ghost", Amount: 10, Reason: "bonus"})
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAdminRepository_SyncCatalog(t *testing.T) {
+	products := sqlmock.NewRows([]string{"id", "name", "price", "retired"}).
+		AddRow(1, "pen", 10, false).
+		AddRow(2, "cup", 20, true)
+	oldPrice := 20
+	plan := func(current []models.Product) ([]models.CatalogChange, int) {
+		assert.Equal(t, []models.Product{{ID: 1, Name: "pen", Price: 10}, {ID: 2, Name: "cup", Price: 20, Retired: true}}, current)
+		return []models.CatalogChange{
+			{Action: models.CatalogInsert, Name: "mug", Price: 30},
+			{Action: models.CatalogRestore, ID: 2, Name: "cup", Price: 25, OldPrice: &oldPrice},
+			{Action: models.CatalogRetire, ID: 1, Name: "pen", Price: 10},
+		}, 0
+	}
+
+	t.Run("Apply", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := NewAdminRepositoryImpl(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`LOCK TABLE "product" IN SHARE ROW EXCLUSIVE MODE`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`SELECT id, name, price, retired_at IS NOT NULL FROM "product" ORDER BY id`).WillReturnRows(products)
+		mock.ExpectQuery(`INSERT INTO "product" \(name, price\) VALUES \(\$1, \$2\) RETURNING id`).
+			WithArgs("mug", 30).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+		mock.ExpectExec(`UPDATE "product" SET price = \$1, retired_at = NULL, updated_at = NOW\(\) WHERE id = \$2`).
+			WithArgs(25, uint(2)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`UPDATE "product" SET retired_at = NOW\(\), updated_at = NOW\(\) WHERE id = \$1`).
+			WithArgs(uint(1)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		changes, _, err := repo.SyncCatalog(context.Background(), plan, false)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), changes[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := NewAdminRepositoryImpl(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`SELECT id, name, price, retired_at IS NOT NULL FROM "product"`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price", "retired"}).AddRow(1, "pen", 10, false).AddRow(2, "cup", 20, true))
+		mock.ExpectRollback()
+
+		changes, _, err := repo.SyncCatalog(context.Background(), plan, true)
+		assert.NoError(t, err)
+		assert.Len(t, changes, 3)
+		assert.Zero(t, changes[0].ID)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestAdminRepository_CreateUsers(t *testing.T) {
+	coins := 1500
+	users := []models.NewUser{
+		{Username: "alice", Coins: &coins, Roles: []string{models.RoleHR}},
+		{Username: "bob", Roles: []string{}},
+	}
+
+	t.Run("Row by row", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := NewAdminRepositoryImpl(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "user" \(username, password_hash, roles, coins\) VALUES \(\$1, \$2, \$3, \$4\) RETURNING id`).
+			WithArgs("alice", "", `{"hr"}`, 1500).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectCommit()
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "user" \(username, password_hash, roles\) VALUES \(\$1, \$2, \$3\) RETURNING id`).
+			WithArgs("bob", "", "{}").
+			WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectRollback()
+
+		results, err := repo.CreateUsers(context.Background(), users, false, false)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(7), results[0].ID)
+		assert.NoError(t, results[0].Err)
+		assert.ErrorIs(t, results[1].Err, models.ErrAlreadyExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("All or nothing", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := NewAdminRepositoryImpl(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`SAVEPOINT provision_user`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "user"`).WithArgs("alice", "", `{"hr"}`, 1500).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(7))
+		mock.ExpectExec(`SAVEPOINT provision_user`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(`INSERT INTO "user"`).WithArgs("bob", "", "{}").
+			WillReturnError(&pq.Error{Code: "23505"})
+		mock.ExpectExec(`ROLLBACK TO SAVEPOINT provision_user`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		results, err := repo.CreateUsers(context.Background(), users, true, false)
+		assert.NoError(t, err)
+		assert.NoError(t, results[0].Err)
+		assert.ErrorIs(t, results[1].Err, models.ErrAlreadyExists)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		repo := NewAdminRepositoryImpl(db)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(`INSERT INTO "user"`).WithArgs("bob", "", "{}").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(8))
+		mock.ExpectRollback()
+
+		results, err := repo.CreateUsers(context.Background(), users[1:], false, true)
+		assert.NoError(t, err)
+		assert.NoError(t, results[0].Err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
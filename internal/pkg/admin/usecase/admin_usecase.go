@@ -0,0 +1,237 @@
+package usecase
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/admin"
+	"Merch_store-Avito_test_task/internal/pkg/auth"
+	"Merch_store-Avito_test_task/internal/pkg/auth/password"
+	authUsecase "Merch_store-Avito_test_task/internal/pkg/auth/usecase"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/service"
+	"context"
+	"fmt"
+	"strings"
+)
+
+// AdminUsecaseImpl implements operator tasks on top of the user, service and admin repositories.
+type AdminUsecaseImpl struct {
+	repo    admin.AdminRepository
+	users   auth.AuthRepository
+	service service.ServiceRepository
+	hasher  *password.Hasher
+	policy  *password.Policy
+}
+
+func NewAdminUsecase(repo admin.AdminRepository, users auth.AuthRepository, service service.ServiceRepository, passwords config.Password) *AdminUsecaseImpl {
+	return &AdminUsecaseImpl{
+		repo:    repo,
+		users:   users,
+		service: service,
+		hasher:  password.NewHasher(passwords),
+		policy:  password.NewPolicy(passwords),
+	}
+}
+
+func (uc *AdminUsecaseImpl) ListUsers(ctx context.Context) ([]models.UserSummary, error) {
+	return uc.repo.ListUsers(ctx)
+}
+
+// CreateUser registers a user under the same username and password rules as the login endpoint.
+func (uc *AdminUsecaseImpl) CreateUser(ctx context.Context, username, password string) (models.User, error) {
+	if err := authUsecase.ValidateUsername(username); err != nil {
+		return models.User{}, err
+	}
+	hash, err := uc.hash(password)
+	if err != nil {
+		return models.User{}, err
+	}
+	user := models.User{Username: username, PasswordHash: hash}
+	if user.ID, err = uc.users.CreateUser(ctx, user); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+// ResetPassword sets a new password without knowing the current one. Like a password change it
+// revokes every token issued to the user.
+func (uc *AdminUsecaseImpl) ResetPassword(ctx context.Context, username, password string) (models.User, error) {
+	user, err := uc.users.GetUser(ctx, username)
+	if err != nil {
+		return models.User{}, err
+	}
+	if user.PasswordHash, err = uc.hash(password); err != nil {
+		return models.User{}, err
+	}
+	if user.TokenVersion, err = uc.users.ChangePassword(ctx, user.ID, user.PasswordHash); err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+func (uc *AdminUsecaseImpl) AdjustBalance(ctx context.Context, username string, amount int, reason string) (models.BalanceAdjustment, error) {
+	reason = strings.TrimSpace(reason)
+	if amount == 0 {
+		return models.BalanceAdjustment{}, fmt.Errorf("%w: amount must not be zero", models.ErrInvalidInput)
+	}
+	if reason == "" {
+		return models.BalanceAdjustment{}, fmt.Errorf("%w: a reason is required", models.ErrInvalidInput)
+	}
+	adjustment, err := uc.repo.AdjustBalance(ctx, models.BalanceAdjustment{Username: username, Amount: amount, Reason: reason})
+	if err != nil {
+		return models.BalanceAdjustment{}, err
+	}
+	if cache, ok := uc.service.(service.CacheInvalidator); ok {
+		cache.InvalidateUserInfo(adjustment.UserID)
+	}
+	return adjustment, nil
+}
+
+// UserInfo returns the same summary GET /api/info shows to the user.
+func (uc *AdminUsecaseImpl) UserInfo(ctx context.Context, username string) (models.UserData, error) {
+	user, err := uc.users.GetUser(ctx, username)
+	if err != nil {
+		return models.UserData{}, err
+	}
+	return uc.service.GetUserInfo(ctx, user.ID)
+}
+
+func (uc *AdminUsecaseImpl) ExportCatalog(ctx context.Context) ([]models.Product, error) {
+	return uc.service.ListProducts(ctx)
+}
+
+// SyncCatalog makes the catalog match products: new products are added, prices updated and retired
+// products listed again restored. With retire, products missing from the input are retired. Running
+// it again with the same input changes nothing.
+func (uc *AdminUsecaseImpl) SyncCatalog(ctx context.Context, products []models.Product, retire, dryRun bool) (models.CatalogDiff, error) {
+	if err := admin.ValidateCatalog(products); err != nil {
+		return models.CatalogDiff{}, err
+	}
+	if len(products) == 0 && retire {
+		return models.CatalogDiff{}, fmt.Errorf("%w: refusing to retire the whole catalog", models.ErrInvalidInput)
+	}
+	plan := func(current []models.Product) ([]models.CatalogChange, int) {
+		return admin.DiffCatalog(current, products, retire)
+	}
+	changes, unchanged, err := uc.repo.SyncCatalog(ctx, plan, dryRun)
+	if err != nil {
+		return models.CatalogDiff{}, err
+	}
+	if cache, ok := uc.service.(service.CacheInvalidator); ok && !dryRun && len(changes) > 0 {
+		cache.InvalidateProducts()
+	}
+	return models.CatalogDiff{DryRun: dryRun, Retire: retire, Unchanged: unchanged, Changes: changes}, nil
+}
+
+// ProvisionUsers validates rows and creates the valid ones, reporting the outcome of every row.
+// Rows are independent unless atomic is set, in which case one failing row fails the whole run.
+// A dry run checks the rows against the database without keeping any change. Users without a
+// password in their row get a generated one, which only the report contains.
+func (uc *AdminUsecaseImpl) ProvisionUsers(ctx context.Context, rows []admin.UserRow, atomic, dryRun bool) (models.ProvisionReport, error) {
+	report := models.ProvisionReport{DryRun: dryRun, Atomic: atomic, Rows: make([]models.ProvisionRow, len(rows))}
+	users := make([]models.NewUser, 0, len(rows))
+	generated := make([]string, 0, len(rows))
+	indexes := make([]int, 0, len(rows))
+	seen := make(map[string]int, len(rows))
+	for i, row := range rows {
+		report.Rows[i] = models.ProvisionRow{Line: row.Line, Username: row.Username}
+		user, password, err := uc.newUser(row, seen, dryRun)
+		if err != nil {
+			report.Rows[i].Status, report.Rows[i].Error = models.ProvisionFailed, err.Error()
+			report.Failed++
+			continue
+		}
+		users = append(users, user)
+		generated = append(generated, password)
+		indexes = append(indexes, i)
+	}
+
+	if len(users) > 0 {
+		// An atomic run with invalid rows cannot succeed; the rest is only checked.
+		results, err := uc.repo.CreateUsers(ctx, users, atomic, dryRun || (atomic && report.Failed > 0))
+		if err != nil {
+			return models.ProvisionReport{}, err
+		}
+		for j, result := range results {
+			row := &report.Rows[indexes[j]]
+			switch {
+			case result.Err != nil:
+				row.Status, row.Error = models.ProvisionFailed, result.Err.Error()
+				report.Failed++
+			case dryRun:
+				row.Status = models.ProvisionValid
+			default:
+				row.Status, row.UserID, row.Password = models.ProvisionCreated, result.ID, generated[j]
+				report.Created++
+			}
+		}
+	}
+
+	if atomic && report.Failed > 0 {
+		for i := range report.Rows {
+			if row := &report.Rows[i]; row.Status != models.ProvisionFailed {
+				row.Status, row.UserID, row.Password = models.ProvisionSkipped, 0, ""
+			}
+		}
+		report.Created = 0
+	}
+	return report, nil
+}
+
+// newUser validates a provisioning row and returns the password generated for it, if any. seen maps
+// the usernames of earlier rows to their lines.
+func (uc *AdminUsecaseImpl) newUser(row admin.UserRow, seen map[string]int, dryRun bool) (models.NewUser, string, error) {
+	if row.Err != nil {
+		return models.NewUser{}, "", row.Err
+	}
+	if err := authUsecase.ValidateUsername(row.Username); err != nil {
+		return models.NewUser{}, "", err
+	}
+	if line, ok := seen[row.Username]; ok {
+		return models.NewUser{}, "", fmt.Errorf("%w: username is already used on line %d", models.ErrInvalidInput, line)
+	}
+	seen[row.Username] = row.Line
+	if row.Coins != nil && *row.Coins < 0 {
+		return models.NewUser{}, "", fmt.Errorf("%w: coins must not be negative", models.ErrInvalidInput)
+	}
+	for _, role := range row.Roles {
+		if !models.ValidRole(role) {
+			return models.NewUser{}, "", fmt.Errorf("%w: unknown role %q", models.ErrInvalidInput, role)
+		}
+	}
+
+	user := models.NewUser{Username: row.Username, Coins: row.Coins, Roles: row.Roles}
+	plain, generated := row.Password, ""
+	if plain != "" {
+		if err := uc.policy.Validate(plain); err != nil {
+			return models.NewUser{}, "", err
+		}
+	}
+	if !dryRun {
+		if plain == "" {
+			var err error
+			if plain, err = password.Generate(); err != nil {
+				return models.NewUser{}, "", err
+			}
+			generated = plain
+		}
+		var err error
+		if user.PasswordHash, err = uc.hasher.Hash(plain); err != nil {
+			return models.NewUser{}, "", fmt.Errorf("error hashing password: %w", err)
+		}
+	}
+	if user.Roles == nil {
+		user.Roles = []string{}
+	}
+	return user, generated, nil
+}
+
+func (uc *AdminUsecaseImpl) hash(plain string) (string, error) {
+	if err := uc.policy.Validate(plain); err != nil {
+		return "", err
+	}
+	hash, err := uc.hasher.Hash(plain)
+	if err != nil {
+		return "", fmt.Errorf("error hashing password: %w", err)
+	}
+	return hash, nil
+}
@@ -0,0 +1,210 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/admin"
+	mocks "Merch_store-Avito_test_task/internal/pkg/admin/mocks"
+	authMocks "Merch_store-Avito_test_task/internal/pkg/auth/mocks"
+	"Merch_store-Avito_test_task/internal/pkg/auth/password"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	serviceMocks "Merch_store-Avito_test_task/internal/pkg/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testPasswords = config.Password{Algorithm: "bcrypt", BcryptCost: 4, MinLength: 8, MaxLength: 72}
+
+func newTestUsecase(t *testing.T) (*AdminUsecaseImpl, *mocks.MockAdminRepository, *authMocks.MockAuthRepository, *serviceMocks.MockServiceRepository) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	repo := mocks.NewMockAdminRepository(ctrl)
+	users := authMocks.NewMockAuthRepository(ctrl)
+	service := serviceMocks.NewMockServiceRepository(ctrl)
+	return NewAdminUsecase(repo, users, service, testPasswords), repo, users, service
+}
+
+func TestCreateUser(t *testing.T) {
+	uc, _, users, _ := newTestUsecase(t)
+	ctx := context.Background()
+
+	users.EXPECT().CreateUser(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, user models.User) (uint, error) {
+		ok, err := password.NewHasher(testPasswords).Verify(user.PasswordHash, "s3cret-pass")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		return 5, nil
+	})
+	user, err := uc.CreateUser(ctx, "alice", "s3cret-pass")
+	assert.NoError(t, err)
+	assert.Equal(t, uint(5), user.ID)
+
+	_, err = uc.CreateUser(ctx, "bad name!", "s3cret-pass")
+	assert.ErrorIs(t, err, models.ErrBadUsername)
+	_, err = uc.CreateUser(ctx, "bob", "short")
+	assert.ErrorIs(t, err, models.ErrWeakPassword)
+}
+
+func TestResetPassword(t *testing.T) {
+	uc, _, users, _ := newTestUsecase(t)
+	ctx := context.Background()
+
+	users.EXPECT().GetUser(ctx, "alice").Return(models.User{ID: 5, Username: "alice", TokenVersion: 2}, nil)
+	users.EXPECT().ChangePassword(ctx, uint(5), gomock.Any()).Return(3, nil)
+	user, err := uc.ResetPassword(ctx, "alice", "n3w-password")
+	assert.NoError(t, err)
+	assert.Equal(t, 3, user.TokenVersion)
+
+	users.EXPECT().GetUser(ctx, "ghost").Return(models.User{}, models.ErrNotFound)
+	_, err = uc.ResetPassword(ctx, "ghost", "n3w-password")
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestAdjustBalance(t *testing.T) {
+	uc, repo, _, _ := newTestUsecase(t)
+	ctx := context.Background()
+
+	repo.EXPECT().AdjustBalance(ctx, models.BalanceAdjustment{Username: "alice", Amount: -50, Reason: "refund reversal"}).
+		Return(models.BalanceAdjustment{ID: 1, UserID: 5, Username: "alice", Amount: -50, Reason: "refund reversal", Balance: 950, CreatedAt: time.Now()}, nil)
+	adjustment, err := uc.AdjustBalance(ctx, "alice", -50, "  refund reversal ")
+	assert.NoError(t, err)
+	assert.Equal(t, 950, adjustment.Balance)
+
+	_, err = uc.AdjustBalance(ctx, "alice", 0, "nothing")
+	assert.ErrorIs(t, err, models.ErrInvalidInput)
+	_, err = uc.AdjustBalance(ctx, "alice", 10, " ")
+	assert.ErrorIs(t, err, models.ErrInvalidInput)
+}
+
+func TestUserInfo(t *testing.T) {
+	uc, _, users, service := newTestUsecase(t)
+	ctx := context.Background()
+
+	users.EXPECT().GetUser(ctx, "alice").Return(models.User{ID: 5, Username: "alice"}, nil)
+	service.EXPECT().GetUserInfo(ctx, uint(5)).Return(models.UserData{Coins: 700}, nil)
+
+	info, err := uc.UserInfo(ctx, "alice")
+	assert.NoError(t, err)
+	assert.Equal(t, 700, info.Coins)
+}
+
+func TestSyncCatalog(t *testing.T) {
+	uc, repo, _, _ := newTestUsecase(t)
+	ctx := context.Background()
+	products := []models.Product{{Name: "pen", Price: 10}, {Name: "mug", Price: 30}}
+
+	repo.EXPECT().SyncCatalog(ctx, gomock.Any(), true).DoAndReturn(func(_ context.Context, plan admin.CatalogPlan, _ bool) ([]models.CatalogChange, int, error) {
+		changes, unchanged := plan([]models.Product{{ID: 1, Name: "pen", Price: 10}, {ID: 2, Name: "hoody", Price: 300}})
+		return changes, unchanged, nil
+	})
+	diff, err := uc.SyncCatalog(ctx, products, true, true)
+	assert.NoError(t, err)
+	assert.Equal(t, models.CatalogDiff{DryRun: true, Retire: true, Unchanged: 1, Changes: []models.CatalogChange{
+		{Action: models.CatalogInsert, Name: "mug", Price: 30},
+		{Action: models.CatalogRetire, ID: 2, Name: "hoody", Price: 300},
+	}}, diff)
+
+	_, err = uc.SyncCatalog(ctx, []models.Product{{Name: "pen", Price: -1}}, false, false)
+	assert.ErrorIs(t, err, models.ErrInvalidInput)
+	_, err = uc.SyncCatalog(ctx, nil, true, false)
+	assert.ErrorIs(t, err, models.ErrInvalidInput)
+}
+
+func TestProvisionUsers(t *testing.T) {
+	ctx := context.Background()
+	coins, negative := 1500, -1
+	rows := []admin.UserRow{
+		{Line: 2, Username: "alice", Coins: &coins, Roles: []string{models.RoleHR}},
+		{Line: 3, Username: "bob"},
+		{Line: 4, Username: "alice"},
+		{Line: 5, Username: "carol", Coins: &negative},
+		{Line: 6, Username: "dave", Roles: []string{"owner"}},
+		{Line: 7, Username: "erin", Err: errors.New("expected 2 fields, got 1")},
+	}
+	// Rows without a password get a generated one, so the hashes differ on every run.
+	valid := func(t *testing.T, users []models.NewUser) {
+		assert.Len(t, users, 2)
+		for i, want := range []models.NewUser{
+			{Username: "alice", Coins: &coins, Roles: []string{models.RoleHR}},
+			{Username: "bob", Roles: []string{}},
+		} {
+			assert.NotEmpty(t, users[i].PasswordHash)
+			users[i].PasswordHash = ""
+			assert.Equal(t, want, users[i])
+		}
+	}
+
+	t.Run("Row by row", func(t *testing.T) {
+		uc, repo, _, _ := newTestUsecase(t)
+		var aliceHash string
+		repo.EXPECT().CreateUsers(ctx, gomock.Any(), false, false).
+			DoAndReturn(func(_ context.Context, users []models.NewUser, _, _ bool) ([]admin.RowResult, error) {
+				aliceHash = users[0].PasswordHash
+				valid(t, users)
+				return []admin.RowResult{{ID: 7}, {Err: models.ErrAlreadyExists}}, nil
+			})
+
+		report, err := uc.ProvisionUsers(ctx, rows, false, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Equal(t, 5, report.Failed)
+		generated := report.Rows[0].Password
+		assert.Equal(t, models.ProvisionRow{Line: 2, Username: "alice", Status: models.ProvisionCreated, UserID: 7, Password: generated}, report.Rows[0])
+		assert.NoError(t, password.NewPolicy(testPasswords).Validate(generated))
+		ok, err := password.NewHasher(testPasswords).Verify(aliceHash, generated)
+		assert.NoError(t, err)
+		assert.True(t, ok)
+		assert.Empty(t, report.Rows[1].Password, "failed rows show no password")
+		assert.Equal(t, models.ProvisionFailed, report.Rows[1].Status)
+		assert.Contains(t, report.Rows[2].Error, "already used on line 2")
+		assert.Contains(t, report.Rows[3].Error, "must not be negative")
+		assert.Contains(t, report.Rows[4].Error, `unknown role "owner"`)
+		assert.Equal(t, "expected 2 fields, got 1", report.Rows[5].Error)
+	})
+
+	t.Run("All or nothing with invalid rows only checks the rest", func(t *testing.T) {
+		uc, repo, _, _ := newTestUsecase(t)
+		repo.EXPECT().CreateUsers(ctx, gomock.Any(), true, true).
+			DoAndReturn(func(_ context.Context, users []models.NewUser, _, _ bool) ([]admin.RowResult, error) {
+				valid(t, users)
+				return []admin.RowResult{{ID: 7}, {ID: 8}}, nil
+			})
+
+		report, err := uc.ProvisionUsers(ctx, rows, true, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, report.Created)
+		assert.Equal(t, 4, report.Failed)
+		assert.Equal(t, models.ProvisionRow{Line: 2, Username: "alice", Status: models.ProvisionSkipped}, report.Rows[0])
+	})
+
+	t.Run("Dry run", func(t *testing.T) {
+		uc, repo, _, _ := newTestUsecase(t)
+		repo.EXPECT().CreateUsers(ctx, []models.NewUser{{Username: "frank", Roles: []string{}}}, false, true).
+			Return([]admin.RowResult{{ID: 9}}, nil)
+
+		report, err := uc.ProvisionUsers(ctx, []admin.UserRow{{Line: 2, Username: "frank", Password: "Str0ng-pass"}}, false, true)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ProvisionRow{Line: 2, Username: "frank", Status: models.ProvisionValid}, report.Rows[0])
+	})
+
+	t.Run("Passwords are hashed", func(t *testing.T) {
+		uc, repo, _, _ := newTestUsecase(t)
+		repo.EXPECT().CreateUsers(ctx, gomock.Any(), false, false).
+			DoAndReturn(func(_ context.Context, users []models.NewUser, _, _ bool) ([]admin.RowResult, error) {
+				ok, err := password.NewHasher(testPasswords).Verify(users[0].PasswordHash, "Str0ng-pass")
+				assert.NoError(t, err)
+				assert.True(t, ok)
+				return []admin.RowResult{{ID: 9}}, nil
+			})
+
+		report, err := uc.ProvisionUsers(ctx, []admin.UserRow{{Line: 2, Username: "frank", Password: "Str0ng-pass"}, {Line: 3, Username: "gina", Password: "weak"}}, false, false)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, report.Created)
+		assert.Empty(t, report.Rows[0].Password, "chosen passwords are not echoed")
+		assert.Equal(t, models.ProvisionFailed, report.Rows[1].Status)
+	})
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/apikey"
+	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
+	"Merch_store-Avito_test_task/internal/pkg/middleware"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const maxNameLength = 64
+
+var (
+	errUnauthorized = httpresponses.NewError(http.StatusUnauthorized, httpresponses.CodeUnauthorized, "user is not authorized")
+	errInvalidBody  = httpresponses.NewError(http.StatusBadRequest, httpresponses.CodeInvalidRequest, "invalid request body")
+	errInvalidKeyID = httpresponses.NewError(http.StatusBadRequest, httpresponses.CodeInvalidRequest, "invalid api key id")
+	errScopeNotHeld = httpresponses.NewError(http.StatusForbidden, httpresponses.CodeForbidden, "cannot grant a scope the caller does not hold")
+)
+
+type APIKeyHandler struct {
+	uc     apikey.APIKeyUsecase
+	logger *slog.Logger
+}
+
+func NewAPIKeyHandler(uc apikey.APIKeyUsecase, logger *slog.Logger) *APIKeyHandler {
+	return &APIKeyHandler{uc: uc, logger: logger}
+}
+
+// IssueKey creates a key. The plaintext key is part of this response only. Callers can only grant
+// scopes they hold themselves.
+func (h *APIKeyHandler) IssueKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	issuerID, ok := middleware.UserID(ctx)
+	if !ok {
+		h.logger.ErrorContext(ctx, "failed to retrieve user id from context")
+		httpresponses.SendError(w, r, errUnauthorized, h.logger)
+		return
+	}
+	var data struct {
+		Name   string   `json:"name"`
+		User   string   `json:"user"`
+		Scopes []string `json:"scopes"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&data)
+	data.Name = strings.TrimSpace(data.Name)
+	if err != nil || data.Name == "" || len(data.Name) > maxNameLength || data.User == "" {
+		h.logger.WarnContext(ctx, "invalid api key request", slog.Any("error", err))
+		httpresponses.SendError(w, r, errInvalidBody, h.logger)
+		return
+	}
+
+	for _, scope := range data.Scopes {
+		if models.ValidScope(scope) && !middleware.HasScope(ctx, scope) {
+			h.logger.WarnContext(ctx, "api key scope not held by issuer", slog.String("scope", scope))
+			httpresponses.SendError(w, r, errScopeNotHeld.WithDetails(scope), h.logger)
+			return
+		}
+	}
+
+	key, secret, err := h.uc.Issue(ctx, issuerID, data.Name, data.User, data.Scopes)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to issue api key", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	h.logger.InfoContext(ctx, "api key issued", slog.Int("keyID", int(key.ID)), slog.String("user", key.Username),
+		slog.Any("scopes", key.Scopes))
+	response := struct {
+		models.APIKey
+		Key string `json:"key"`
+	}{key, secret}
+	httpresponses.SendJSONResponse(ctx, w, response, http.StatusCreated, h.logger)
+}
+
+func (h *APIKeyHandler) ListKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	keys, err := h.uc.List(ctx)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to list api keys", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	httpresponses.SendJSONResponse(ctx, w, struct {
+		Keys []models.APIKey `json:"keys"`
+	}{keys}, http.StatusOK, h.logger)
+}
+
+func (h *APIKeyHandler) RevokeKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := strconv.ParseUint(mux.Vars(r)["id"], 10, 32)
+	if err != nil {
+		h.logger.WarnContext(ctx, "failed to parse api key id", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, errInvalidKeyID, h.logger)
+		return
+	}
+	if err = h.uc.Revoke(ctx, uint(id)); err != nil {
+		h.logger.ErrorContext(ctx, "failed to revoke api key", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	h.logger.InfoContext(ctx, "api key revoked", slog.Int("keyID", int(id)))
+	httpresponses.SendJSONResponse(ctx, w, struct{}{}, http.StatusOK, h.logger)
+}
@@ -0,0 +1,168 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+	mocks "Merch_store-Avito_test_task/internal/pkg/apikey/mocks"
+	"Merch_store-Avito_test_task/internal/pkg/middleware"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssueKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsecase := mocks.NewMockAPIKeyUsecase(ctrl)
+	handler := NewAPIKeyHandler(mockUsecase, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name: "Issued",
+			body: `{"name":"hr-sync","user":"hr-bot","scopes":["grant:write"]}`,
+			setupMock: func() {
+				mockUsecase.EXPECT().Issue(gomock.Any(), uint(1), "hr-sync", "hr-bot", []string{models.ScopeGrantWrite}).
+					Return(models.APIKey{ID: 1, Name: "hr-sync", Prefix: "msk_abcdefgh", Username: "hr-bot"}, "msk_abcdefgh-secret", nil)
+			},
+			expectedStatus: http.StatusCreated,
+		},
+		{
+			name:           "Missing name",
+			body:           `{"name":"  ","user":"hr-bot","scopes":["grant:write"]}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+		},
+		{
+			name: "Unknown scope",
+			body: `{"name":"hr-sync","user":"hr-bot","scopes":["root"]}`,
+			setupMock: func() {
+				mockUsecase.EXPECT().Issue(gomock.Any(), uint(1), "hr-sync", "hr-bot", []string{"root"}).
+					Return(models.APIKey{}, "", models.ErrBadScope)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "invalid_request",
+		},
+		{
+			name: "Unknown owner",
+			body: `{"name":"hr-sync","user":"ghost","scopes":["grant:write"]}`,
+			setupMock: func() {
+				mockUsecase.EXPECT().Issue(gomock.Any(), uint(1), "hr-sync", "ghost", gomock.Any()).Return(models.APIKey{}, "", models.ErrNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "not_found",
+		},
+		{
+			name: "Another person's account",
+			body: `{"name":"hr-sync","user":"alice","scopes":["grant:write"]}`,
+			setupMock: func() {
+				mockUsecase.EXPECT().Issue(gomock.Any(), uint(1), "hr-sync", "alice", gomock.Any()).Return(models.APIKey{}, "", models.ErrForbidden)
+			},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "forbidden",
+		},
+		{
+			name:           "Scope the caller does not hold",
+			body:           `{"name":"hr-sync","user":"hr-bot","scopes":["grant:write","keys:admin"]}`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusForbidden,
+			expectedCode:   "forbidden",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/keys", strings.NewReader(tt.body))
+			ctx := context.WithValue(req.Context(), middleware.IdKey, uint(1))
+			req = req.WithContext(context.WithValue(ctx, middleware.ScopesKey, []string{models.ScopeGrantWrite}))
+			rr := httptest.NewRecorder()
+
+			handler.IssueKey(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			var body map[string]any
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+			if tt.expectedCode != "" {
+				assert.Equal(t, tt.expectedCode, body["code"])
+				return
+			}
+			assert.Equal(t, "msk_abcdefgh-secret", body["key"])
+			assert.Equal(t, "msk_abcdefgh", body["prefix"])
+		})
+	}
+}
+
+func TestListKeys(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsecase := mocks.NewMockAPIKeyUsecase(ctrl)
+	handler := NewAPIKeyHandler(mockUsecase, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	mockUsecase.EXPECT().List(gomock.Any()).Return([]models.APIKey{{ID: 1, Name: "hr-sync", Scopes: []string{models.ScopeUsersRead}}}, nil)
+
+	rr := httptest.NewRecorder()
+	handler.ListKeys(rr, httptest.NewRequest(http.MethodGet, "/api/admin/keys", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"scopes":["users:read"]`)
+	assert.NotContains(t, rr.Body.String(), `"key"`)
+}
+
+func TestRevokeKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsecase := mocks.NewMockAPIKeyUsecase(ctrl)
+	handler := NewAPIKeyHandler(mockUsecase, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+
+	tests := []struct {
+		name           string
+		id             string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name:           "Revoked",
+			id:             "3",
+			setupMock:      func() { mockUsecase.EXPECT().Revoke(gomock.Any(), uint(3)).Return(nil) },
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Already revoked",
+			id:             "4",
+			setupMock:      func() { mockUsecase.EXPECT().Revoke(gomock.Any(), uint(4)).Return(models.ErrNotFound) },
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid id",
+			id:             "abc",
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodDelete, "/api/admin/keys/"+tt.id, nil), map[string]string{"id": tt.id})
+			rr := httptest.NewRecorder()
+
+			handler.RevokeKey(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
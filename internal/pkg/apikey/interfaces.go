@@ -0,0 +1,27 @@
+package apikey
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+)
+
+//go:generate mockgen -source=interfaces.go -destination=mocks/mock.go
+type APIKeyUsecase interface {
+	// Issue creates a key for the user named owner and returns it with the plaintext key, which is not stored.
+	// The owner has to be the issuer or a service account, otherwise it yields models.ErrForbidden.
+	Issue(ctx context.Context, issuerID uint, name, owner string, scopes []string) (models.APIKey, string, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id uint) error
+	// Authenticate resolves a plaintext key; unknown and revoked keys yield models.ErrNotFound.
+	Authenticate(ctx context.Context, key string) (models.APIKey, error)
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error)
+	// GetOwner returns the ID and roles of the user named username.
+	GetOwner(ctx context.Context, username string) (uint, []string, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id uint) error
+	GetByHash(ctx context.Context, hash string) (models.APIKey, error)
+	TouchLastUsed(ctx context.Context, id uint) error
+}
@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+)
+
+type APIKeyRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAPIKeyRepositoryImpl(db *sql.DB) *APIKeyRepositoryImpl {
+	return &APIKeyRepositoryImpl{db: db}
+}
+
+const selectAPIKey = `SELECT k.id, k.name, k.prefix, k.user_id, u.username, k.scopes, k.created_at, k.last_used_at, k.revoked_at
+	FROM "api_key" k JOIN "user" u ON u.id = k.user_id`
+
+// Create stores a key owned by key.Username; a missing owner yields models.ErrNotFound.
+func (repo *APIKeyRepositoryImpl) Create(ctx context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+	query := `INSERT INTO "api_key" (name, prefix, key_hash, scopes, user_id)
+		SELECT $1, $2, $3, $4, id FROM "user" WHERE username = $5
+		RETURNING id, user_id, created_at`
+	err := repo.db.QueryRowContext(ctx, query, key.Name, key.Prefix, hash, pq.Array(key.Scopes), key.Username).
+		Scan(&key.ID, &key.UserID, &key.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, fmt.Errorf("key owner %q: %w", key.Username, models.ErrNotFound)
+		}
+		return models.APIKey{}, fmt.Errorf("creating api key failed: %w", err)
+	}
+	return key, nil
+}
+
+func (repo *APIKeyRepositoryImpl) GetOwner(ctx context.Context, username string) (uint, []string, error) {
+	var id uint
+	var roles []string
+	query := `SELECT id, roles FROM "user" WHERE username = $1`
+	err := repo.db.QueryRowContext(ctx, query, username).Scan(&id, pq.Array(&roles))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil, fmt.Errorf("key owner %q: %w", username, models.ErrNotFound)
+		}
+		return 0, nil, fmt.Errorf("getting key owner failed: %w", err)
+	}
+	return id, roles, nil
+}
+
+func (repo *APIKeyRepositoryImpl) List(ctx context.Context) ([]models.APIKey, error) {
+	rows, err := repo.db.QueryContext(ctx, selectAPIKey+` ORDER BY k.id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing api keys failed: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing api keys failed: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke disables a key for good; revoking an unknown or already revoked key yields models.ErrNotFound.
+func (repo *APIKeyRepositoryImpl) Revoke(ctx context.Context, id uint) error {
+	query := `UPDATE "api_key" SET revoked_at = NOW() WHERE id = $1 AND revoked_at IS NULL`
+	res, err := repo.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("revoking api key failed: %w", err)
+	}
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting rows affected failed: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("api key %d: %w", id, models.ErrNotFound)
+	}
+	return nil
+}
+
+// GetByHash returns the active key with the given hash.
+func (repo *APIKeyRepositoryImpl) GetByHash(ctx context.Context, hash string) (models.APIKey, error) {
+	row := repo.db.QueryRowContext(ctx, selectAPIKey+` WHERE k.key_hash = $1 AND k.revoked_at IS NULL`, hash)
+	key, err := scanAPIKey(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.APIKey{}, models.ErrNotFound
+		}
+		return models.APIKey{}, err
+	}
+	return key, nil
+}
+
+// TouchLastUsed records that the key was just used. The timestamp has minute resolution so busy keys
+// don't turn every request into a row update.
+func (repo *APIKeyRepositoryImpl) TouchLastUsed(ctx context.Context, id uint) error {
+	query := `UPDATE "api_key" SET last_used_at = NOW()
+		WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL '1 minute')`
+	if _, err := repo.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("updating api key usage failed: %w", err)
+	}
+	return nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanAPIKey(row scanner) (models.APIKey, error) {
+	var key models.APIKey
+	var lastUsedAt, revokedAt sql.NullTime
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, &key.UserID, &key.Username, pq.Array(&key.Scopes),
+		&key.CreatedAt, &lastUsedAt, &revokedAt)
+	if err != nil {
+		return models.APIKey{}, err
+	}
+	if lastUsedAt.Valid {
+		key.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+	return key, nil
+}
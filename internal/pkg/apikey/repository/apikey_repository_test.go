@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var keyColumns = []string{"id", "name", "prefix", "user_id", "username", "scopes", "created_at", "last_used_at", "revoked_at"}
+
+func TestAPIKeyRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAPIKeyRepositoryImpl(db)
+	ctx := context.Background()
+	created := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Create resolves the owner", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO "api_key" \(name, prefix, key_hash, scopes, user_id\)\s+SELECT \$1, \$2, \$3, \$4, id FROM "user" WHERE username = \$5`).
+			WithArgs("billing", "msk_abcdefgh", "hash", `{"keys:admin","users:read"}`, "billing-bot").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "created_at"}).AddRow(5, 3, created))
+
+		key, err := repo.Create(ctx, models.APIKey{
+			Name:     "billing",
+			Prefix:   "msk_abcdefgh",
+			Username: "billing-bot",
+			Scopes:   []string{models.ScopeKeysAdmin, models.ScopeUsersRead},
+		}, "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(5), key.ID)
+		assert.Equal(t, uint(3), key.UserID)
+		assert.Equal(t, created, key.CreatedAt)
+	})
+
+	t.Run("Create with unknown owner", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO "api_key"`).WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.Create(ctx, models.APIKey{Name: "x", Username: "ghost", Scopes: []string{models.ScopeUsersRead}}, "hash")
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	t.Run("GetOwner", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, roles FROM "user" WHERE username = \$1`).
+			WithArgs("billing-bot").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "roles"}).AddRow(3, "{service}"))
+
+		id, roles, err := repo.GetOwner(ctx, "billing-bot")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), id)
+		assert.Equal(t, []string{models.RoleService}, roles)
+	})
+
+	t.Run("GetOwner unknown user", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, roles FROM "user"`).WithArgs("ghost").WillReturnError(sql.ErrNoRows)
+
+		_, _, err := repo.GetOwner(ctx, "ghost")
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	t.Run("GetByHash skips revoked keys", func(t *testing.T) {
+		mock.ExpectQuery(`FROM "api_key" k JOIN "user" u ON u.id = k.user_id WHERE k.key_hash = \$1 AND k.revoked_at IS NULL`).
+			WithArgs("hash").
+			WillReturnRows(sqlmock.NewRows(keyColumns).AddRow(5, "billing", "msk_abcdefgh", 3, "billing-bot", "{users:read}", created, created, nil))
+
+		key, err := repo.GetByHash(ctx, "hash")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{models.ScopeUsersRead}, key.Scopes)
+		assert.Equal(t, "billing-bot", key.Username)
+		assert.Equal(t, &created, key.LastUsedAt)
+		assert.Nil(t, key.RevokedAt)
+	})
+
+	t.Run("GetByHash unknown key", func(t *testing.T) {
+		mock.ExpectQuery(`WHERE k.key_hash = \$1`).WithArgs("nope").WillReturnRows(sqlmock.NewRows(keyColumns))
+
+		_, err := repo.GetByHash(ctx, "nope")
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		mock.ExpectQuery(`FROM "api_key" k JOIN "user" u ON u.id = k.user_id ORDER BY k.id`).
+			WillReturnRows(sqlmock.NewRows(keyColumns).
+				AddRow(5, "billing", "msk_abcdefgh", 3, "billing-bot", "{users:read}", created, nil, created))
+
+		keys, err := repo.List(ctx)
+		assert.NoError(t, err)
+		assert.Len(t, keys, 1)
+		assert.Equal(t, &created, keys[0].RevokedAt)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "api_key" SET revoked_at = NOW\(\) WHERE id = \$1 AND revoked_at IS NULL`).
+			WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 1))
+		assert.NoError(t, repo.Revoke(ctx, 5))
+	})
+
+	t.Run("Revoke unknown or revoked key", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "api_key" SET revoked_at`).WithArgs(6).WillReturnResult(sqlmock.NewResult(0, 0))
+		assert.ErrorIs(t, repo.Revoke(ctx, 6), models.ErrNotFound)
+	})
+
+	t.Run("TouchLastUsed", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "api_key" SET last_used_at = NOW\(\)`).WithArgs(5).WillReturnResult(sqlmock.NewResult(0, 0))
+		assert.NoError(t, repo.TouchLastUsed(ctx, 5))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
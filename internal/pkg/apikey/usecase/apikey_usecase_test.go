@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+	mocks "Merch_store-Avito_test_task/internal/pkg/apikey/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIssue(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockAPIKeyRepository(ctrl)
+	uc := NewAPIKeyUsecase(repo)
+	ctx := context.Background()
+
+	t.Run("Stores only the hash", func(t *testing.T) {
+		var storedHash string
+		repo.EXPECT().GetOwner(ctx, "hr-bot").Return(uint(2), []string{models.RoleService}, nil)
+		repo.EXPECT().Create(ctx, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, key models.APIKey, hash string) (models.APIKey, error) {
+				storedHash = hash
+				assert.Equal(t, []string{models.ScopeGrantWrite, models.ScopeUsersRead}, key.Scopes)
+				key.ID = 1
+				return key, nil
+			})
+
+		key, secret, err := uc.Issue(ctx, 1, "hr-sync", "hr-bot", []string{models.ScopeUsersRead, models.ScopeGrantWrite, models.ScopeUsersRead})
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(secret, keyMarker))
+		assert.Equal(t, secret[:prefixLength], key.Prefix)
+		assert.Equal(t, hashKey(secret), storedHash)
+		assert.NotContains(t, storedHash, secret)
+	})
+
+	t.Run("Unknown scope", func(t *testing.T) {
+		_, _, err := uc.Issue(ctx, 1, "hr-sync", "hr-bot", []string{"root"})
+		assert.ErrorIs(t, err, models.ErrBadScope)
+	})
+
+	t.Run("No scopes", func(t *testing.T) {
+		_, _, err := uc.Issue(ctx, 1, "hr-sync", "hr-bot", nil)
+		assert.ErrorIs(t, err, models.ErrBadScope)
+	})
+
+	t.Run("Own key", func(t *testing.T) {
+		repo.EXPECT().GetOwner(ctx, "root").Return(uint(1), []string{models.RoleAdmin}, nil)
+		repo.EXPECT().Create(ctx, gomock.Any(), gomock.Any()).Return(models.APIKey{ID: 2}, nil)
+
+		_, _, err := uc.Issue(ctx, 1, "deploy", "root", []string{models.ScopeMetricsRead})
+		assert.NoError(t, err)
+	})
+
+	t.Run("Another person's account", func(t *testing.T) {
+		repo.EXPECT().GetOwner(ctx, "alice").Return(uint(3), []string{models.RoleHR}, nil)
+
+		_, secret, err := uc.Issue(ctx, 1, "spend-alice", "alice", []string{models.ScopeGrantWrite})
+		assert.ErrorIs(t, err, models.ErrForbidden)
+		assert.Empty(t, secret)
+	})
+
+	t.Run("Unknown owner", func(t *testing.T) {
+		repo.EXPECT().GetOwner(ctx, "ghost").Return(uint(0), nil, models.ErrNotFound)
+
+		_, secret, err := uc.Issue(ctx, 1, "hr-sync", "ghost", []string{models.ScopeUsersRead})
+		assert.ErrorIs(t, err, models.ErrNotFound)
+		assert.Empty(t, secret)
+	})
+}
+
+func TestAuthenticate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockAPIKeyRepository(ctrl)
+	uc := NewAPIKeyUsecase(repo)
+	ctx := context.Background()
+	secret := keyMarker + "0123456789abcdef"
+
+	t.Run("Active key", func(t *testing.T) {
+		repo.EXPECT().GetByHash(ctx, hashKey(secret)).Return(models.APIKey{ID: 4, UserID: 2}, nil)
+		repo.EXPECT().TouchLastUsed(ctx, uint(4)).Return(nil)
+
+		key, err := uc.Authenticate(ctx, secret)
+		assert.NoError(t, err)
+		assert.Equal(t, uint(2), key.UserID)
+	})
+
+	t.Run("Malformed key is not looked up", func(t *testing.T) {
+		_, err := uc.Authenticate(ctx, "Bearer something")
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	t.Run("Unknown key", func(t *testing.T) {
+		repo.EXPECT().GetByHash(ctx, gomock.Any()).Return(models.APIKey{}, models.ErrNotFound)
+
+		_, err := uc.Authenticate(ctx, secret)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	t.Run("Usage update fails", func(t *testing.T) {
+		dbErr := errors.New("connection reset")
+		repo.EXPECT().GetByHash(ctx, gomock.Any()).Return(models.APIKey{ID: 4}, nil)
+		repo.EXPECT().TouchLastUsed(ctx, uint(4)).Return(dbErr)
+
+		_, err := uc.Authenticate(ctx, secret)
+		assert.ErrorIs(t, err, dbErr)
+	})
+}
@@ -1,23 +1,37 @@
 package http
 
 import (
+	"Merch_store-Avito_test_task/internal/models"
 	"Merch_store-Avito_test_task/internal/pkg/auth"
 	httpresponse "Merch_store-Avito_test_task/internal/pkg/httpresponses"
 	"Merch_store-Avito_test_task/internal/pkg/jwt"
+	"Merch_store-Avito_test_task/internal/pkg/middleware"
 	"encoding/json"
-	"html/template"
+	"errors"
 	"log/slog"
+	"math"
 	"net/http"
+	"strconv"
+	"time"
+)
+
+var (
+	errInvalidRequest    = httpresponse.NewError(http.StatusBadRequest, httpresponse.CodeInvalidRequest, "invalid request body")
+	errUnauthorized      = httpresponse.NewError(http.StatusUnauthorized, httpresponse.CodeUnauthorized, "user is not authorized")
+	errTooManyAttempts   = httpresponse.NewError(http.StatusTooManyRequests, httpresponse.CodeTooManyRequests, "too many login attempts")
+	errWrongCredentials  = httpresponse.NewError(http.StatusUnauthorized, httpresponse.CodeInvalidCredentials, "invalid username or password")
+	errWrongCurrPassword = httpresponse.NewError(http.StatusUnauthorized, httpresponse.CodeInvalidCredentials, "invalid current password")
 )
 
 type AuthHandler struct {
 	uc     auth.AuthUsecase
 	logger *slog.Logger
 	jwt    jwt.JWTInterface
+	guard  auth.LoginGuard
 }
 
-func NewAuthHandler(uc auth.AuthUsecase, logger *slog.Logger, jwt jwt.JWTInterface) *AuthHandler {
-	return &AuthHandler{uc: uc, logger: logger, jwt: jwt}
+func NewAuthHandler(uc auth.AuthUsecase, logger *slog.Logger, jwt jwt.JWTInterface, guard auth.LoginGuard) *AuthHandler {
+	return &AuthHandler{uc: uc, logger: logger, jwt: jwt, guard: guard}
 }
 
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
@@ -39,36 +53,44 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 				return "empty username or password"
 			}()),
 		)
-		response := httpresponse.Response{
-			Message: "Invalid request",
-		}
-		httpresponse.SendJSONResponse(logCtx, w, response, http.StatusBadRequest, h.logger)
+		httpresponse.SendError(w, r, errInvalidRequest, h.logger)
 		return
 	}
 
-	credentials.Username = template.HTMLEscapeString(credentials.Username)
-	credentials.Password = template.HTMLEscapeString(credentials.Password)
+	ip := middleware.ClientIP(r)
+	retryAfter, err := h.guard.Allow(logCtx, credentials.Username, ip)
+	if err != nil {
+		h.logger.ErrorContext(logCtx, "Login guard check failed", slog.String("error", err.Error()))
+		httpresponse.SendError(w, r, err, h.logger)
+		return
+	}
+	if retryAfter > 0 {
+		h.sendTooManyAttempts(w, r, retryAfter)
+		return
+	}
 
 	user, err := h.uc.Login(r.Context(), credentials.Username, credentials.Password)
 	if err != nil {
-		h.logger.ErrorContext(logCtx, "Login failed: invalid username or password")
-
-		response := httpresponse.Response{
-			Message: "Invalid username or password",
+		h.logger.ErrorContext(logCtx, "Login failed", slog.String("error", err.Error()))
+		if errors.Is(err, models.ErrMismatch) {
+			if _, guardErr := h.guard.RegisterFailure(logCtx, credentials.Username, ip); guardErr != nil {
+				h.logger.ErrorContext(logCtx, "Failed to register login failure", slog.String("error", guardErr.Error()))
+			}
+			err = errWrongCredentials
 		}
-		httpresponse.SendJSONResponse(logCtx, w, response, http.StatusUnauthorized, h.logger)
+		httpresponse.SendError(w, r, err, h.logger)
 		return
 	}
 
 	h.logger.DebugContext(logCtx, "User logged in successfully")
+	if err = h.guard.RegisterSuccess(logCtx, credentials.Username, ip); err != nil {
+		h.logger.ErrorContext(logCtx, "Failed to reset login failures", slog.String("error", err.Error()))
+	}
 
-	token, err := h.jwt.GenerateToken(user.ID, user.Username)
+	token, err := h.jwt.GenerateToken(user.ID, user.Username, user.TokenVersion)
 	if err != nil {
 		h.logger.ErrorContext(logCtx, "Token generation failed", slog.String("error", err.Error()))
-		response := httpresponse.Response{
-			Message: "Token generation failed",
-		}
-		httpresponse.SendJSONResponse(logCtx, w, response, http.StatusInternalServerError, h.logger)
+		httpresponse.SendError(w, r, err, h.logger)
 		return
 	}
 	h.logger.DebugContext(logCtx, "Token generated", slog.Int("ID", int(user.ID)), slog.String("username", user.Username))
@@ -77,3 +99,70 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 
 	httpresponse.SendJSONResponse(logCtx, w, map[string]string{"token": token}, http.StatusOK, h.logger)
 }
+
+// ChangePassword replaces the password of the authenticated user and revokes every token issued
+// before. The response carries a fresh token for the caller.
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	logCtx := r.Context()
+	h.logger.DebugContext(logCtx, "Handling request for password change")
+
+	userID, ok := logCtx.Value(middleware.IdKey).(uint)
+	username, _ := logCtx.Value(middleware.UsernameKey).(string)
+	if !ok {
+		h.logger.ErrorContext(logCtx, "User ID not found in context")
+		httpresponse.SendError(w, r, errUnauthorized, h.logger)
+		return
+	}
+
+	var request struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || request.CurrentPassword == "" || request.NewPassword == "" {
+		h.logger.WarnContext(logCtx, "Failed to decode password change request")
+		httpresponse.SendError(w, r, errInvalidRequest, h.logger)
+		return
+	}
+
+	ip := middleware.ClientIP(r)
+	retryAfter, err := h.guard.Allow(logCtx, username, ip)
+	if err != nil {
+		h.logger.ErrorContext(logCtx, "Login guard check failed", slog.String("error", err.Error()))
+		httpresponse.SendError(w, r, err, h.logger)
+		return
+	}
+	if retryAfter > 0 {
+		h.sendTooManyAttempts(w, r, retryAfter)
+		return
+	}
+
+	user, err := h.uc.ChangePassword(logCtx, userID, request.CurrentPassword, request.NewPassword)
+	if err != nil {
+		h.logger.ErrorContext(logCtx, "Password change failed", slog.String("error", err.Error()))
+		if errors.Is(err, models.ErrMismatch) {
+			if _, guardErr := h.guard.RegisterFailure(logCtx, username, ip); guardErr != nil {
+				h.logger.ErrorContext(logCtx, "Failed to register login failure", slog.String("error", guardErr.Error()))
+			}
+			err = errWrongCurrPassword
+		}
+		httpresponse.SendError(w, r, err, h.logger)
+		return
+	}
+
+	token, err := h.jwt.GenerateToken(user.ID, user.Username, user.TokenVersion)
+	if err != nil {
+		h.logger.ErrorContext(logCtx, "Token generation failed", slog.String("error", err.Error()))
+		httpresponse.SendError(w, r, err, h.logger)
+		return
+	}
+	w.Header().Set("Access-Token", token)
+	h.logger.DebugContext(logCtx, "Password changed", slog.Int("ID", int(user.ID)))
+
+	httpresponse.SendJSONResponse(logCtx, w, map[string]string{"token": token}, http.StatusOK, h.logger)
+}
+
+func (h *AuthHandler) sendTooManyAttempts(w http.ResponseWriter, r *http.Request, retryAfter time.Duration) {
+	h.logger.WarnContext(r.Context(), "Login locked out", slog.Duration("retryAfter", retryAfter))
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	httpresponse.SendError(w, r, errTooManyAttempts, h.logger)
+}
@@ -2,16 +2,20 @@ package http
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"Merch_store-Avito_test_task/internal/models"
 	mocks "Merch_store-Avito_test_task/internal/pkg/auth/mocks"
 	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
 	jwtmock "Merch_store-Avito_test_task/internal/pkg/jwt/mocks"
+	"Merch_store-Avito_test_task/internal/pkg/middleware"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"log/slog"
@@ -23,16 +27,19 @@ func TestAuthHandler_Login(t *testing.T) {
 
 	mockUsecase := mocks.NewMockAuthUsecase(ctrl)
 	mockJWT := jwtmock.NewMockJWTInterface(ctrl)
+	mockGuard := mocks.NewMockLoginGuard(ctrl)
 	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
 
-	handler := NewAuthHandler(mockUsecase, logger, mockJWT)
+	handler := NewAuthHandler(mockUsecase, logger, mockJWT, mockGuard)
+	mockGuard.EXPECT().Allow(gomock.Any(), gomock.Any(), gomock.Any()).Return(time.Duration(0), nil).AnyTimes()
+	mockGuard.EXPECT().RegisterSuccess(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
 
 	tests := []struct {
 		name           string
 		requestBody    map[string]string
 		mockSetup      func()
 		expectedStatus int
-		expectedBody   httpresponses.Response
+		expectedBody   httpresponses.Error
 	}{
 		{
 			name: "Successful login",
@@ -42,10 +49,9 @@ func TestAuthHandler_Login(t *testing.T) {
 			},
 			mockSetup: func() {
 				mockUsecase.EXPECT().Login(gomock.Any(), "testuser", "password").Return(models.User{ID: 1, Username: "testuser"}, nil)
-				mockJWT.EXPECT().GenerateToken(uint(1), "testuser").Return("valid_token", nil)
+				mockJWT.EXPECT().GenerateToken(uint(1), "testuser", 0).Return("valid_token", nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   httpresponses.Response{Message: ""},
 		},
 		{
 			name: "Invalid username or password",
@@ -54,10 +60,11 @@ func TestAuthHandler_Login(t *testing.T) {
 				"password": "wrongpass",
 			},
 			mockSetup: func() {
-				mockUsecase.EXPECT().Login(gomock.Any(), "wronguser", "wrongpass").Return(models.User{}, errors.New("invalid credentials"))
+				mockUsecase.EXPECT().Login(gomock.Any(), "wronguser", "wrongpass").Return(models.User{}, models.ErrMismatch)
+				mockGuard.EXPECT().RegisterFailure(gomock.Any(), "wronguser", "192.0.2.1").Return(time.Duration(0), nil)
 			},
 			expectedStatus: http.StatusUnauthorized,
-			expectedBody:   httpresponses.Response{Message: "Invalid username or password"},
+			expectedBody:   httpresponses.Error{Code: httpresponses.CodeInvalidCredentials, Message: "invalid username or password"},
 		},
 		{
 			name: "Token generation failure",
@@ -67,17 +74,41 @@ func TestAuthHandler_Login(t *testing.T) {
 			},
 			mockSetup: func() {
 				mockUsecase.EXPECT().Login(gomock.Any(), "testuser", "password").Return(models.User{ID: 1, Username: "testuser"}, nil)
-				mockJWT.EXPECT().GenerateToken(uint(1), "testuser").Return("", errors.New("token error"))
+				mockJWT.EXPECT().GenerateToken(uint(1), "testuser", 0).Return("", errors.New("token error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   httpresponses.Response{Message: "Token generation failed"},
+			expectedBody:   httpresponses.Error{Code: httpresponses.CodeInternal, Message: "internal server error"},
+		},
+		{
+			name: "Credentials are passed without escaping",
+			requestBody: map[string]string{
+				"username": "testuser",
+				"password": "p&ss<word>",
+			},
+			mockSetup: func() {
+				mockUsecase.EXPECT().Login(gomock.Any(), "testuser", "p&ss<word>").Return(models.User{ID: 1, Username: "testuser"}, nil)
+				mockJWT.EXPECT().GenerateToken(uint(1), "testuser", 0).Return("valid_token", nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name: "Invalid username",
+			requestBody: map[string]string{
+				"username": "<b>",
+				"password": "password",
+			},
+			mockSetup: func() {
+				mockUsecase.EXPECT().Login(gomock.Any(), "<b>", "password").Return(models.User{}, models.ErrBadUsername)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   httpresponses.Error{Code: httpresponses.CodeInvalidUsername, Message: "invalid username"},
 		},
 		{
 			name:           "Invalid JSON request",
 			requestBody:    map[string]string{},
 			mockSetup:      func() {},
 			expectedStatus: http.StatusBadRequest,
-			expectedBody:   httpresponses.Response{Message: "Invalid request"},
+			expectedBody:   httpresponses.Error{Code: httpresponses.CodeInvalidRequest, Message: "invalid request body"},
 		},
 	}
 
@@ -88,6 +119,7 @@ func TestAuthHandler_Login(t *testing.T) {
 			reqBody, _ := json.Marshal(tt.requestBody)
 			req, err := http.NewRequest(http.MethodPost, "/auth", bytes.NewReader(reqBody))
 			assert.NoError(t, err)
+			req.RemoteAddr = "192.0.2.1:1234"
 
 			rr := httptest.NewRecorder()
 
@@ -102,8 +134,114 @@ func TestAuthHandler_Login(t *testing.T) {
 			if tt.expectedStatus == http.StatusOK {
 				assert.Contains(t, response, "token")
 			} else {
+				assert.Equal(t, tt.expectedBody.Code, response["code"])
 				assert.Equal(t, tt.expectedBody.Message, response["message"])
 			}
 		})
 	}
 }
+
+func TestAuthHandler_LoginLockedOut(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsecase := mocks.NewMockAuthUsecase(ctrl)
+	mockJWT := jwtmock.NewMockJWTInterface(ctrl)
+	mockGuard := mocks.NewMockLoginGuard(ctrl)
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	handler := NewAuthHandler(mockUsecase, logger, mockJWT, mockGuard)
+
+	mockGuard.EXPECT().Allow(gomock.Any(), "victim", "192.0.2.1").Return(1500*time.Millisecond, nil)
+
+	reqBody, _ := json.Marshal(map[string]string{"username": "victim", "password": "guess"})
+	req := httptest.NewRequest(http.MethodPost, "/auth", bytes.NewReader(reqBody))
+	req.RemoteAddr = "192.0.2.1:1234"
+	rr := httptest.NewRecorder()
+
+	handler.Login(rr, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+}
+
+func TestAuthHandler_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockUsecase := mocks.NewMockAuthUsecase(ctrl)
+	mockJWT := jwtmock.NewMockJWTInterface(ctrl)
+	mockGuard := mocks.NewMockLoginGuard(ctrl)
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	handler := NewAuthHandler(mockUsecase, logger, mockJWT, mockGuard)
+	mockGuard.EXPECT().Allow(gomock.Any(), "testuser", "192.0.2.1").Return(time.Duration(0), nil).AnyTimes()
+
+	tests := []struct {
+		name            string
+		requestBody     map[string]string
+		mockSetup       func()
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{
+			name:        "Successful change",
+			requestBody: map[string]string{"currentPassword": "password", "newPassword": "n3w-Passw0rd"},
+			mockSetup: func() {
+				mockUsecase.EXPECT().ChangePassword(gomock.Any(), uint(1), "password", "n3w-Passw0rd").
+					Return(models.User{ID: 1, Username: "testuser", TokenVersion: 1}, nil)
+				mockJWT.EXPECT().GenerateToken(uint(1), "testuser", 1).Return("new_token", nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:        "Wrong current password",
+			requestBody: map[string]string{"currentPassword": "wrong", "newPassword": "n3w-Passw0rd"},
+			mockSetup: func() {
+				mockUsecase.EXPECT().ChangePassword(gomock.Any(), uint(1), "wrong", "n3w-Passw0rd").Return(models.User{}, models.ErrMismatch)
+				mockGuard.EXPECT().RegisterFailure(gomock.Any(), "testuser", "192.0.2.1").Return(time.Duration(0), nil)
+			},
+			expectedStatus:  http.StatusUnauthorized,
+			expectedMessage: "invalid current password",
+		},
+		{
+			name:        "Weak new password",
+			requestBody: map[string]string{"currentPassword": "password", "newPassword": "short"},
+			mockSetup: func() {
+				mockUsecase.EXPECT().ChangePassword(gomock.Any(), uint(1), "password", "short").
+					Return(models.User{}, fmt.Errorf("%w: password must contain at least 8 characters", models.ErrWeakPassword))
+			},
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "password does not satisfy the password policy",
+		},
+		{
+			name:            "Missing fields",
+			requestBody:     map[string]string{"currentPassword": "password"},
+			mockSetup:       func() {},
+			expectedStatus:  http.StatusBadRequest,
+			expectedMessage: "invalid request body",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			reqBody, _ := json.Marshal(tt.requestBody)
+			req := httptest.NewRequest(http.MethodPost, "/auth/password", bytes.NewReader(reqBody))
+			req.RemoteAddr = "192.0.2.1:1234"
+			ctx := context.WithValue(req.Context(), middleware.IdKey, uint(1))
+			ctx = context.WithValue(ctx, middleware.UsernameKey, "testuser")
+			rr := httptest.NewRecorder()
+
+			handler.ChangePassword(rr, req.WithContext(ctx))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			var response map[string]string
+			assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, "new_token", response["token"])
+			} else {
+				assert.Equal(t, tt.expectedMessage, response["message"])
+			}
+		})
+	}
+}
@@ -3,14 +3,40 @@ package auth
 import (
 	"Merch_store-Avito_test_task/internal/models"
 	"context"
+	"time"
 )
 
 //go:generate mockgen -source=interfaces.go -destination=mocks/mock.go
 type AuthUsecase interface {
 	Login(ctx context.Context, username, password string) (models.User, error)
+	ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) (models.User, error)
+	TokenVersion(ctx context.Context, userID uint) (int, error)
+	Roles(ctx context.Context, userID uint) ([]string, error)
 }
 
 type AuthRepository interface {
 	CreateUser(ctx context.Context, user models.User) (uint, error)
 	GetUser(ctx context.Context, username string) (models.User, error)
+	GetUserByID(ctx context.Context, userID uint) (models.User, error)
+	RenameUser(ctx context.Context, userID uint, username string) error
+	UpdatePasswordHash(ctx context.Context, userID uint, oldHash, newHash string) error
+	ChangePassword(ctx context.Context, userID uint, hash string) (int, error)
+	GetTokenVersion(ctx context.Context, userID uint) (int, error)
+	GetRoles(ctx context.Context, userID uint) ([]string, error)
+}
+
+// LoginGuard throttles password guessing per username and per client IP.
+// A positive duration means the caller is locked out for that long.
+type LoginGuard interface {
+	Allow(ctx context.Context, username, ip string) (time.Duration, error)
+	RegisterFailure(ctx context.Context, username, ip string) (time.Duration, error)
+	RegisterSuccess(ctx context.Context, username, ip string) error
+}
+
+// AttemptStore keeps failed login counters. Increment starts a new series when the previous
+// failure happened before since.
+type AttemptStore interface {
+	Get(ctx context.Context, key string) (models.LoginAttempts, error)
+	Increment(ctx context.Context, key string, now, since time.Time) (models.LoginAttempts, error)
+	Reset(ctx context.Context, key string) error
 }
@@ -8,6 +8,7 @@ import (
 	models "Merch_store-Avito_test_task/internal/models"
 	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 )
@@ -35,6 +36,21 @@ func (m *MockAuthUsecase) EXPECT() *MockAuthUsecaseMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockAuthUsecase) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, currentPassword, newPassword)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthUsecaseMockRecorder) ChangePassword(ctx, userID, currentPassword, newPassword interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthUsecase)(nil).ChangePassword), ctx, userID, currentPassword, newPassword)
+}
+
 // Login mocks base method.
 func (m *MockAuthUsecase) Login(ctx context.Context, username, password string) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -50,6 +66,36 @@ func (mr *MockAuthUsecaseMockRecorder) Login(ctx, username, password interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Login", reflect.TypeOf((*MockAuthUsecase)(nil).Login), ctx, username, password)
 }
 
+// Roles mocks base method.
+func (m *MockAuthUsecase) Roles(ctx context.Context, userID uint) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Roles", ctx, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Roles indicates an expected call of Roles.
+func (mr *MockAuthUsecaseMockRecorder) Roles(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Roles", reflect.TypeOf((*MockAuthUsecase)(nil).Roles), ctx, userID)
+}
+
+// TokenVersion mocks base method.
+func (m *MockAuthUsecase) TokenVersion(ctx context.Context, userID uint) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TokenVersion", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TokenVersion indicates an expected call of TokenVersion.
+func (mr *MockAuthUsecaseMockRecorder) TokenVersion(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TokenVersion", reflect.TypeOf((*MockAuthUsecase)(nil).TokenVersion), ctx, userID)
+}
+
 // MockAuthRepository is a mock of AuthRepository interface.
 type MockAuthRepository struct {
 	ctrl     *gomock.Controller
@@ -73,6 +119,21 @@ func (m *MockAuthRepository) EXPECT() *MockAuthRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ChangePassword mocks base method.
+func (m *MockAuthRepository) ChangePassword(ctx context.Context, userID uint, hash string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangePassword", ctx, userID, hash)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangePassword indicates an expected call of ChangePassword.
+func (mr *MockAuthRepositoryMockRecorder) ChangePassword(ctx, userID, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangePassword", reflect.TypeOf((*MockAuthRepository)(nil).ChangePassword), ctx, userID, hash)
+}
+
 // CreateUser mocks base method.
 func (m *MockAuthRepository) CreateUser(ctx context.Context, user models.User) (uint, error) {
 	m.ctrl.T.Helper()
@@ -88,6 +149,36 @@ func (mr *MockAuthRepositoryMockRecorder) CreateUser(ctx, user interface{}) *gom
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateUser", reflect.TypeOf((*MockAuthRepository)(nil).CreateUser), ctx, user)
 }
 
+// GetRoles mocks base method.
+func (m *MockAuthRepository) GetRoles(ctx context.Context, userID uint) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRoles", ctx, userID)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRoles indicates an expected call of GetRoles.
+func (mr *MockAuthRepositoryMockRecorder) GetRoles(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRoles", reflect.TypeOf((*MockAuthRepository)(nil).GetRoles), ctx, userID)
+}
+
+// GetTokenVersion mocks base method.
+func (m *MockAuthRepository) GetTokenVersion(ctx context.Context, userID uint) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTokenVersion", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTokenVersion indicates an expected call of GetTokenVersion.
+func (mr *MockAuthRepositoryMockRecorder) GetTokenVersion(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTokenVersion", reflect.TypeOf((*MockAuthRepository)(nil).GetTokenVersion), ctx, userID)
+}
+
 // GetUser mocks base method.
 func (m *MockAuthRepository) GetUser(ctx context.Context, username string) (models.User, error) {
 	m.ctrl.T.Helper()
@@ -102,3 +193,180 @@ func (mr *MockAuthRepositoryMockRecorder) GetUser(ctx, username interface{}) *go
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUser", reflect.TypeOf((*MockAuthRepository)(nil).GetUser), ctx, username)
 }
+
+// GetUserByID mocks base method.
+func (m *MockAuthRepository) GetUserByID(ctx context.Context, userID uint) (models.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserByID", ctx, userID)
+	ret0, _ := ret[0].(models.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserByID indicates an expected call of GetUserByID.
+func (mr *MockAuthRepositoryMockRecorder) GetUserByID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserByID", reflect.TypeOf((*MockAuthRepository)(nil).GetUserByID), ctx, userID)
+}
+
+// RenameUser mocks base method.
+func (m *MockAuthRepository) RenameUser(ctx context.Context, userID uint, username string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RenameUser", ctx, userID, username)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RenameUser indicates an expected call of RenameUser.
+func (mr *MockAuthRepositoryMockRecorder) RenameUser(ctx, userID, username interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RenameUser", reflect.TypeOf((*MockAuthRepository)(nil).RenameUser), ctx, userID, username)
+}
+
+// UpdatePasswordHash mocks base method.
+func (m *MockAuthRepository) UpdatePasswordHash(ctx context.Context, userID uint, oldHash, newHash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePasswordHash", ctx, userID, oldHash, newHash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePasswordHash indicates an expected call of UpdatePasswordHash.
+func (mr *MockAuthRepositoryMockRecorder) UpdatePasswordHash(ctx, userID, oldHash, newHash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePasswordHash", reflect.TypeOf((*MockAuthRepository)(nil).UpdatePasswordHash), ctx, userID, oldHash, newHash)
+}
+
+// MockLoginGuard is a mock of LoginGuard interface.
+type MockLoginGuard struct {
+	ctrl     *gomock.Controller
+	recorder *MockLoginGuardMockRecorder
+}
+
+// MockLoginGuardMockRecorder is the mock recorder for MockLoginGuard.
+type MockLoginGuardMockRecorder struct {
+	mock *MockLoginGuard
+}
+
+// NewMockLoginGuard creates a new mock instance.
+func NewMockLoginGuard(ctrl *gomock.Controller) *MockLoginGuard {
+	mock := &MockLoginGuard{ctrl: ctrl}
+	mock.recorder = &MockLoginGuardMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockLoginGuard) EXPECT() *MockLoginGuardMockRecorder {
+	return m.recorder
+}
+
+// Allow mocks base method.
+func (m *MockLoginGuard) Allow(ctx context.Context, username, ip string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Allow", ctx, username, ip)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Allow indicates an expected call of Allow.
+func (mr *MockLoginGuardMockRecorder) Allow(ctx, username, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Allow", reflect.TypeOf((*MockLoginGuard)(nil).Allow), ctx, username, ip)
+}
+
+// RegisterFailure mocks base method.
+func (m *MockLoginGuard) RegisterFailure(ctx context.Context, username, ip string) (time.Duration, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterFailure", ctx, username, ip)
+	ret0, _ := ret[0].(time.Duration)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RegisterFailure indicates an expected call of RegisterFailure.
+func (mr *MockLoginGuardMockRecorder) RegisterFailure(ctx, username, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterFailure", reflect.TypeOf((*MockLoginGuard)(nil).RegisterFailure), ctx, username, ip)
+}
+
+// RegisterSuccess mocks base method.
+func (m *MockLoginGuard) RegisterSuccess(ctx context.Context, username, ip string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterSuccess", ctx, username, ip)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterSuccess indicates an expected call of RegisterSuccess.
+func (mr *MockLoginGuardMockRecorder) RegisterSuccess(ctx, username, ip interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterSuccess", reflect.TypeOf((*MockLoginGuard)(nil).RegisterSuccess), ctx, username, ip)
+}
+
+// MockAttemptStore is a mock of AttemptStore interface.
+type MockAttemptStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockAttemptStoreMockRecorder
+}
+
+// MockAttemptStoreMockRecorder is the mock recorder for MockAttemptStore.
+type MockAttemptStoreMockRecorder struct {
+	mock *MockAttemptStore
+}
+
+// NewMockAttemptStore creates a new mock instance.
+func NewMockAttemptStore(ctrl *gomock.Controller) *MockAttemptStore {
+	mock := &MockAttemptStore{ctrl: ctrl}
+	mock.recorder = &MockAttemptStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockAttemptStore) EXPECT() *MockAttemptStoreMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method.
+func (m *MockAttemptStore) Get(ctx context.Context, key string) (models.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", ctx, key)
+	ret0, _ := ret[0].(models.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get.
+func (mr *MockAttemptStoreMockRecorder) Get(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockAttemptStore)(nil).Get), ctx, key)
+}
+
+// Increment mocks base method.
+func (m *MockAttemptStore) Increment(ctx context.Context, key string, now, since time.Time) (models.LoginAttempts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Increment", ctx, key, now, since)
+	ret0, _ := ret[0].(models.LoginAttempts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Increment indicates an expected call of Increment.
+func (mr *MockAttemptStoreMockRecorder) Increment(ctx, key, now, since interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Increment", reflect.TypeOf((*MockAttemptStore)(nil).Increment), ctx, key, now, since)
+}
+
+// Reset mocks base method.
+func (m *MockAttemptStore) Reset(ctx context.Context, key string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reset", ctx, key)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reset indicates an expected call of Reset.
+func (mr *MockAttemptStoreMockRecorder) Reset(ctx, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reset", reflect.TypeOf((*MockAttemptStore)(nil).Reset), ctx, key)
+}
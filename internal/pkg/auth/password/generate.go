@@ -0,0 +1,17 @@
+package password
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+)
+
+// Generate returns a random password for an operator to hand over. It contains every character class
+// a Policy can require and is longer than its default minimum.
+func Generate() (string, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate password: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf) + "!Aa1", nil
+}
@@ -0,0 +1,133 @@
+package password
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+type argon2Params struct {
+	memory  uint32
+	time    uint32
+	threads uint8
+}
+
+// Hasher hashes new passwords with the configured algorithm and verifies hashes of any supported one.
+type Hasher struct {
+	algorithm  string
+	bcryptCost int
+	argon2     argon2Params
+}
+
+// NewHasher creates a Hasher; zero values in cfg fall back to bcrypt with bcrypt.DefaultCost.
+func NewHasher(cfg config.Password) *Hasher {
+	h := &Hasher{
+		algorithm:  cfg.Algorithm,
+		bcryptCost: cfg.BcryptCost,
+		argon2:     argon2Params{memory: cfg.Argon2Memory, time: cfg.Argon2Time, threads: cfg.Argon2Threads},
+	}
+	if h.algorithm == "" {
+		h.algorithm = AlgorithmBcrypt
+	}
+	if h.bcryptCost == 0 {
+		h.bcryptCost = bcrypt.DefaultCost
+	}
+	if h.argon2 == (argon2Params{}) {
+		h.argon2 = argon2Params{memory: 64 * 1024, time: 3, threads: 2}
+	}
+	return h
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	if h.algorithm == AlgorithmArgon2id {
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("generating salt: %w", err)
+		}
+		key := argon2.IDKey([]byte(password), salt, h.argon2.time, h.argon2.memory, h.argon2.threads, argon2KeyLength)
+		return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.argon2.memory, h.argon2.time, h.argon2.threads,
+			base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.bcryptCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify reports whether password matches the encoded hash.
+func (h *Hasher) Verify(encoded, password string) (bool, error) {
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		params, salt, key, err := decodeArgon2(encoded)
+		if err != nil {
+			return false, err
+		}
+		actual := argon2.IDKey([]byte(password), salt, params.time, params.memory, params.threads, uint32(len(key)))
+		return subtle.ConstantTimeCompare(actual, key) == 1, nil
+	}
+	err := bcrypt.CompareHashAndPassword([]byte(encoded), []byte(password))
+	switch {
+	case err == nil:
+		return true, nil
+	case errors.Is(err, bcrypt.ErrMismatchedHashAndPassword):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// NeedsRehash reports whether encoded was produced with another algorithm or other parameters.
+func (h *Hasher) NeedsRehash(encoded string) bool {
+	if strings.HasPrefix(encoded, "$argon2id$") {
+		if h.algorithm != AlgorithmArgon2id {
+			return true
+		}
+		params, _, _, err := decodeArgon2(encoded)
+		return err != nil || params != h.argon2
+	}
+	if h.algorithm != AlgorithmBcrypt {
+		return true
+	}
+	cost, err := bcrypt.Cost([]byte(encoded))
+	return err != nil || cost != h.bcryptCost
+}
+
+func decodeArgon2(encoded string) (argon2Params, []byte, []byte, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != AlgorithmArgon2id {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+	var params argon2Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.time, &params.threads); err != nil {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return argon2Params{}, nil, nil, ErrUnknownHash
+	}
+	return params, salt, key, nil
+}
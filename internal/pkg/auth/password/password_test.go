@@ -0,0 +1,64 @@
+package password
+
+import (
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestHasher(t *testing.T) {
+	bcryptHasher := NewHasher(config.Password{Algorithm: AlgorithmBcrypt, BcryptCost: bcrypt.MinCost})
+	argonHasher := NewHasher(config.Password{Algorithm: AlgorithmArgon2id, Argon2Memory: 1024, Argon2Time: 1, Argon2Threads: 1})
+
+	for name, h := range map[string]*Hasher{"bcrypt": bcryptHasher, "argon2id": argonHasher} {
+		t.Run(name, func(t *testing.T) {
+			hash, err := h.Hash("s3cret-password")
+			assert.NoError(t, err)
+
+			ok, err := h.Verify(hash, "s3cret-password")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+
+			ok, err = h.Verify(hash, "wrong-password")
+			assert.NoError(t, err)
+			assert.False(t, ok)
+			assert.False(t, h.NeedsRehash(hash))
+		})
+	}
+
+	t.Run("Rehash on algorithm or parameter change", func(t *testing.T) {
+		bcryptHash, err := bcryptHasher.Hash("s3cret-password")
+		assert.NoError(t, err)
+		argonHash, err := argonHasher.Hash("s3cret-password")
+		assert.NoError(t, err)
+
+		assert.True(t, argonHasher.NeedsRehash(bcryptHash))
+		assert.True(t, bcryptHasher.NeedsRehash(argonHash))
+		assert.True(t, NewHasher(config.Password{BcryptCost: bcrypt.MinCost + 1}).NeedsRehash(bcryptHash))
+		assert.True(t, NewHasher(config.Password{Algorithm: AlgorithmArgon2id, Argon2Memory: 2048, Argon2Time: 1, Argon2Threads: 1}).NeedsRehash(argonHash))
+
+		ok, err := argonHasher.Verify(bcryptHash, "s3cret-password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Malformed argon2id hash", func(t *testing.T) {
+		_, err := argonHasher.Verify("$argon2id$v=19$m=x$salt$key", "s3cret-password")
+		assert.ErrorIs(t, err, ErrUnknownHash)
+	})
+}
+
+func TestPolicy(t *testing.T) {
+	strict := NewPolicy(config.Password{MinLength: 10, MaxLength: 20, RequireUpper: true, RequireLower: true, RequireDigit: true, RequireSymbol: true})
+
+	assert.NoError(t, NewPolicy(config.Password{}).Validate("password"))
+	assert.ErrorIs(t, NewPolicy(config.Password{}).Validate("short"), models.ErrWeakPassword)
+	assert.NoError(t, strict.Validate("Str0ng-enough"))
+	assert.EqualError(t, strict.Validate("weak"),
+		"weak password: password must contain at least 10 characters, an uppercase letter, a digit, a symbol")
+	assert.ErrorIs(t, strict.Validate("Str0ng-but-far-too-long"), models.ErrWeakPassword)
+}
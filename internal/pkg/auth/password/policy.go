@@ -0,0 +1,66 @@
+package password
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Policy decides which new passwords are acceptable. It is not applied to existing passwords on login.
+type Policy struct {
+	cfg config.Password
+}
+
+// NewPolicy creates a Policy; zero lengths fall back to 8..72.
+func NewPolicy(cfg config.Password) *Policy {
+	if cfg.MinLength == 0 {
+		cfg.MinLength = 8
+	}
+	if cfg.MaxLength == 0 {
+		cfg.MaxLength = 72
+	}
+	return &Policy{cfg: cfg}
+}
+
+// Validate returns an error wrapping models.ErrWeakPassword that lists every unmet rule.
+func (p *Policy) Validate(password string) error {
+	var problems []string
+	if utf8.RuneCountInString(password) < p.cfg.MinLength {
+		problems = append(problems, fmt.Sprintf("at least %d characters", p.cfg.MinLength))
+	}
+	if len(password) > p.cfg.MaxLength {
+		problems = append(problems, fmt.Sprintf("at most %d bytes", p.cfg.MaxLength))
+	}
+	var upper, lower, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsDigit(r):
+			digit = true
+		case unicode.IsPunct(r) || unicode.IsSymbol(r):
+			symbol = true
+		}
+	}
+	if p.cfg.RequireUpper && !upper {
+		problems = append(problems, "an uppercase letter")
+	}
+	if p.cfg.RequireLower && !lower {
+		problems = append(problems, "a lowercase letter")
+	}
+	if p.cfg.RequireDigit && !digit {
+		problems = append(problems, "a digit")
+	}
+	if p.cfg.RequireSymbol && !symbol {
+		problems = append(problems, "a symbol")
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%w: password must contain %s", models.ErrWeakPassword, strings.Join(problems, ", "))
+	}
+	return nil
+}
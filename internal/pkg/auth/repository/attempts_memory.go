@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepThreshold bounds the map size when many distinct keys fail once and never come back.
+const sweepThreshold = 10000
+
+// MemoryAttemptStore is a process-local AttemptStore for tests and single-replica setups.
+type MemoryAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string]models.LoginAttempts
+}
+
+func NewMemoryAttemptStore() *MemoryAttemptStore {
+	return &MemoryAttemptStore{attempts: make(map[string]models.LoginAttempts)}
+}
+
+func (s *MemoryAttemptStore) Get(_ context.Context, key string) (models.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[key], nil
+}
+
+func (s *MemoryAttemptStore) Increment(_ context.Context, key string, now, since time.Time) (models.LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.attempts) >= sweepThreshold {
+		for k, a := range s.attempts {
+			if a.LastFailure.Before(since) {
+				delete(s.attempts, k)
+			}
+		}
+	}
+
+	attempts := s.attempts[key]
+	if attempts.LastFailure.Before(since) {
+		attempts.Failures = 0
+	}
+	attempts.Failures++
+	attempts.LastFailure = now
+	s.attempts[key] = attempts
+	return attempts, nil
+}
+
+func (s *MemoryAttemptStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type AttemptsRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewAttemptsRepositoryImpl(db *sql.DB) *AttemptsRepositoryImpl {
+	return &AttemptsRepositoryImpl{db: db}
+}
+
+func (repo *AttemptsRepositoryImpl) Get(ctx context.Context, key string) (models.LoginAttempts, error) {
+	query := `SELECT failures, last_failure FROM "login_attempt" WHERE key = $1`
+	var attempts models.LoginAttempts
+	err := repo.db.QueryRowContext(ctx, query, key).Scan(&attempts.Failures, &attempts.LastFailure)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return models.LoginAttempts{}, nil
+		}
+		return models.LoginAttempts{}, fmt.Errorf("getting login attempts failed: %w", err)
+	}
+	return attempts, nil
+}
+
+// staleBatch is how many counters older than the window a failed login deletes. Every failure adds at
+// most one row per key, so the table stays bounded by the keys that failed within the window.
+const staleBatch = 100
+
+// Increment also deletes up to staleBatch counters of other keys whose last failure is before since;
+// the guard ignores them anyway. Rows locked by concurrent logins are left to the next failure.
+func (repo *AttemptsRepositoryImpl) Increment(ctx context.Context, key string, now, since time.Time) (models.LoginAttempts, error) {
+	query := `WITH stale AS (
+			DELETE FROM "login_attempt" WHERE key IN (
+				SELECT key FROM "login_attempt" WHERE last_failure < $3 AND key <> $1
+				LIMIT $4 FOR UPDATE SKIP LOCKED
+			)
+		)
+		INSERT INTO "login_attempt" (key, failures, last_failure) VALUES ($1, 1, $2)
+		ON CONFLICT (key) DO UPDATE SET
+			failures = CASE WHEN "login_attempt".last_failure < $3 THEN 1 ELSE "login_attempt".failures + 1 END,
+			last_failure = EXCLUDED.last_failure
+		RETURNING failures, last_failure`
+	var attempts models.LoginAttempts
+	err := repo.db.QueryRowContext(ctx, query, key, now, since, staleBatch).Scan(&attempts.Failures, &attempts.LastFailure)
+	if err != nil {
+		return models.LoginAttempts{}, fmt.Errorf("registering login failure failed: %w", err)
+	}
+	return attempts, nil
+}
+
+func (repo *AttemptsRepositoryImpl) Reset(ctx context.Context, key string) error {
+	query := `DELETE FROM "login_attempt" WHERE key = $1`
+	if _, err := repo.db.ExecContext(ctx, query, key); err != nil {
+		return fmt.Errorf("resetting login attempts failed: %w", err)
+	}
+	return nil
+}
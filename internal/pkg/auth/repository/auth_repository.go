@@ -5,6 +5,7 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/lib/pq"
 )
 
@@ -31,10 +32,19 @@ func (repo *AuthRepositoryImpl) CreateUser(ctx context.Context, user models.User
 }
 
 func (repo *AuthRepositoryImpl) GetUser(ctx context.Context, username string) (models.User, error) {
-	query := `SELECT id, username, password_hash FROM "user" WHERE username = $1`
-	row := repo.db.QueryRowContext(ctx, query, username)
+	query := `SELECT id, username, password_hash, token_version FROM "user" WHERE username = $1`
+	return repo.getUser(ctx, query, username)
+}
+
+func (repo *AuthRepositoryImpl) GetUserByID(ctx context.Context, userID uint) (models.User, error) {
+	query := `SELECT id, username, password_hash, token_version FROM "user" WHERE id = $1`
+	return repo.getUser(ctx, query, userID)
+}
+
+func (repo *AuthRepositoryImpl) getUser(ctx context.Context, query string, arg any) (models.User, error) {
+	row := repo.db.QueryRowContext(ctx, query, arg)
 	var user models.User
-	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash)
+	err := row.Scan(&user.ID, &user.Username, &user.PasswordHash, &user.TokenVersion)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return models.User{}, models.ErrNotFound
@@ -43,3 +53,80 @@ func (repo *AuthRepositoryImpl) GetUser(ctx context.Context, username string) (m
 	}
 	return user, nil
 }
+
+// RenameUser changes the username, e.g. to migrate a legacy HTML-escaped one.
+func (repo *AuthRepositoryImpl) RenameUser(ctx context.Context, userID uint, username string) error {
+	query := `UPDATE "user" SET username = $1, updated_at = NOW() WHERE id = $2`
+	res, err := repo.db.ExecContext(ctx, query, username, userID)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return models.ErrAlreadyExists
+		}
+		return fmt.Errorf("renaming user failed: %w", err)
+	}
+	return checkUserUpdated(res)
+}
+
+// UpdatePasswordHash replaces the hash of an unchanged password, e.g. after a cost upgrade.
+// Issued tokens stay valid. Only oldHash is replaced: if the password was changed in the meantime,
+// the newer hash is kept and nothing happens.
+func (repo *AuthRepositoryImpl) UpdatePasswordHash(ctx context.Context, userID uint, oldHash, newHash string) error {
+	query := `UPDATE "user" SET password_hash = $1, updated_at = NOW() WHERE id = $2 AND password_hash = $3`
+	if _, err := repo.db.ExecContext(ctx, query, newHash, userID, oldHash); err != nil {
+		return fmt.Errorf("updating password hash failed: %w", err)
+	}
+	return nil
+}
+
+// ChangePassword stores a new password hash and bumps token_version, which revokes all issued tokens.
+func (repo *AuthRepositoryImpl) ChangePassword(ctx context.Context, userID uint, hash string) (int, error) {
+	query := `UPDATE "user" SET password_hash = $1, token_version = token_version + 1, updated_at = NOW()
+		WHERE id = $2 RETURNING token_version`
+	var version int
+	err := repo.db.QueryRowContext(ctx, query, hash, userID).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, models.ErrNotFound
+		}
+		return 0, fmt.Errorf("changing password failed: %w", err)
+	}
+	return version, nil
+}
+
+func (repo *AuthRepositoryImpl) GetTokenVersion(ctx context.Context, userID uint) (int, error) {
+	query := `SELECT token_version FROM "user" WHERE id = $1`
+	var version int
+	err := repo.db.QueryRowContext(ctx, query, userID).Scan(&version)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, models.ErrNotFound
+		}
+		return 0, err
+	}
+	return version, nil
+}
+
+func (repo *AuthRepositoryImpl) GetRoles(ctx context.Context, userID uint) ([]string, error) {
+	query := `SELECT roles FROM "user" WHERE id = $1`
+	var roles []string
+	err := repo.db.QueryRowContext(ctx, query, userID).Scan(pq.Array(&roles))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, models.ErrNotFound
+		}
+		return nil, err
+	}
+	return roles, nil
+}
+
+func checkUserUpdated(res sql.Result) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting rows affected failed: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found: %w", models.ErrNotFound)
+	}
+	return nil
+}
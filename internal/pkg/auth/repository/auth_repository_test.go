@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"testing"
+	"time"
 
 	"Merch_store-Avito_test_task/internal/models"
 
@@ -74,19 +75,19 @@ func TestAuthRepository(t *testing.T) {
 		{
 			name: "GetUser - successful",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(`SELECT id, username, password_hash FROM "user" WHERE username = \$1`).
+				mock.ExpectQuery(`SELECT id, username, password_hash, token_version FROM "user" WHERE username = \$1`).
 					WithArgs("test_user").
-					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash"}).
-						AddRow(1, "test_user", "hashed_password"))
+					WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "token_version"}).
+						AddRow(1, "test_user", "hashed_password", 2))
 			},
 			input:       "test_user",
-			expectedRes: models.User{ID: 1, Username: "test_user", PasswordHash: "hashed_password"},
+			expectedRes: models.User{ID: 1, Username: "test_user", PasswordHash: "hashed_password", TokenVersion: 2},
 			expectedErr: nil,
 		},
 		{
 			name: "GetUser - user not found",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(`SELECT id, username, password_hash FROM "user"`).
+				mock.ExpectQuery(`SELECT id, username, password_hash, token_version FROM "user"`).
 					WithArgs("unknown_user").
 					WillReturnError(sql.ErrNoRows)
 			},
@@ -97,7 +98,7 @@ func TestAuthRepository(t *testing.T) {
 		{
 			name: "GetUser - database error",
 			setupMock: func(mock sqlmock.Sqlmock) {
-				mock.ExpectQuery(`SELECT id, username, password_hash FROM "user"`).
+				mock.ExpectQuery(`SELECT id, username, password_hash, token_version FROM "user"`).
 					WithArgs("test_user").
 					WillReturnError(errors.New("db error"))
 			},
@@ -126,3 +127,129 @@ func TestAuthRepository(t *testing.T) {
 	}
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestAuthRepository_Passwords(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAuthRepositoryImpl(db)
+	ctx := context.Background()
+
+	t.Run("GetUserByID", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, username, password_hash, token_version FROM "user" WHERE id = \$1`).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "password_hash", "token_version"}).
+				AddRow(1, "test_user", "hashed_password", 0))
+
+		user, err := repo.GetUserByID(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, models.User{ID: 1, Username: "test_user", PasswordHash: "hashed_password"}, user)
+	})
+
+	t.Run("RenameUser - name taken", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "user" SET username = \$1, updated_at = NOW\(\) WHERE id = \$2`).
+			WithArgs("new_name", uint(1)).
+			WillReturnError(&pq.Error{Code: "23505"})
+
+		err := repo.RenameUser(ctx, 1, "new_name")
+		assert.ErrorIs(t, err, models.ErrAlreadyExists)
+	})
+
+	t.Run("UpdatePasswordHash - replaces only the old hash", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "user" SET password_hash = \$1, updated_at = NOW\(\) WHERE id = \$2 AND password_hash = \$3`).
+			WithArgs("new_hash", uint(1), "old_hash").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.UpdatePasswordHash(ctx, 1, "old_hash", "new_hash"))
+	})
+
+	t.Run("UpdatePasswordHash - password changed meanwhile", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "user" SET password_hash = \$1, updated_at = NOW\(\) WHERE id = \$2 AND password_hash = \$3`).
+			WithArgs("new_hash", uint(1), "old_hash").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, repo.UpdatePasswordHash(ctx, 1, "old_hash", "new_hash"))
+	})
+
+	t.Run("ChangePassword bumps token version", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE "user" SET password_hash = \$1, token_version = token_version \+ 1`).
+			WithArgs("new_hash", uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"token_version"}).AddRow(3))
+
+		version, err := repo.ChangePassword(ctx, 1, "new_hash")
+		assert.NoError(t, err)
+		assert.Equal(t, 3, version)
+	})
+
+	t.Run("GetRoles", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT roles FROM "user" WHERE id = \$1`).
+			WithArgs(uint(1)).
+			WillReturnRows(sqlmock.NewRows([]string{"roles"}).AddRow("{hr}"))
+
+		roles, err := repo.GetRoles(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{models.RoleHR}, roles)
+	})
+
+	t.Run("GetTokenVersion - user not found", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT token_version FROM "user" WHERE id = \$1`).
+			WithArgs(uint(9)).
+			WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetTokenVersion(ctx, 9)
+		assert.ErrorIs(t, err, models.ErrNotFound)
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAttemptsRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewAttemptsRepositoryImpl(db)
+	ctx := context.Background()
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	since := now.Add(-15 * time.Minute)
+
+	t.Run("Get - no attempts", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT failures, last_failure FROM "login_attempt" WHERE key = \$1`).
+			WithArgs("user:alice").
+			WillReturnError(sql.ErrNoRows)
+
+		attempts, err := repo.Get(ctx, "user:alice")
+		assert.NoError(t, err)
+		assert.Equal(t, models.LoginAttempts{}, attempts)
+	})
+
+	t.Run("Increment", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO "login_attempt" \(key, failures, last_failure\) VALUES \(\$1, 1, \$2\)\s+ON CONFLICT \(key\) DO UPDATE`).
+			WithArgs("user:alice", now, since, staleBatch).
+			WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure"}).AddRow(3, now))
+
+		attempts, err := repo.Increment(ctx, "user:alice", now, since)
+		assert.NoError(t, err)
+		assert.Equal(t, models.LoginAttempts{Failures: 3, LastFailure: now}, attempts)
+	})
+
+	t.Run("Increment deletes counters outside the window", func(t *testing.T) {
+		mock.ExpectQuery(`WITH stale AS \(\s+DELETE FROM "login_attempt" WHERE key IN \(\s+SELECT key FROM "login_attempt" WHERE last_failure < \$3 AND key <> \$1\s+LIMIT \$4 FOR UPDATE SKIP LOCKED`).
+			WithArgs("ip:10.0.0.1", now, since, staleBatch).
+			WillReturnRows(sqlmock.NewRows([]string{"failures", "last_failure"}).AddRow(1, now))
+
+		_, err := repo.Increment(ctx, "ip:10.0.0.1", now, since)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Reset", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM "login_attempt" WHERE key = \$1`).
+			WithArgs("user:alice").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, repo.Reset(ctx, "user:alice"))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -3,52 +3,165 @@ package usecase
 import (
 	"Merch_store-Avito_test_task/internal/models"
 	"Merch_store-Avito_test_task/internal/pkg/auth"
+	"Merch_store-Avito_test_task/internal/pkg/auth/password"
+	"Merch_store-Avito_test_task/internal/pkg/config"
 	"context"
 	"errors"
 	"fmt"
-	"golang.org/x/crypto/bcrypt"
 	"log"
 )
 
 type AuthUsecaseImpl struct {
-	repo auth.AuthRepository
+	repo   auth.AuthRepository
+	hasher *password.Hasher
+	policy *password.Policy
 }
 
-func NewAuthUsecase(repo auth.AuthRepository) *AuthUsecaseImpl {
-	return &AuthUsecaseImpl{repo}
+func NewAuthUsecase(repo auth.AuthRepository, passwords config.Password) *AuthUsecaseImpl {
+	return &AuthUsecaseImpl{repo: repo, hasher: password.NewHasher(passwords), policy: password.NewPolicy(passwords)}
 }
 
 func (uc *AuthUsecaseImpl) Login(ctx context.Context, username, password string) (models.User, error) {
-	user, err := uc.repo.GetUser(ctx, username)
+	if err := checkLoginUsername(username); err != nil {
+		return models.User{}, err
+	}
+
+	user, err := uc.getUser(ctx, username)
 	if err != nil {
 		if errors.Is(err, models.ErrNotFound) {
-			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
-			if err != nil {
-				return models.User{}, fmt.Errorf("error hashing password: %w", err)
-			}
-
-			user := models.User{
-				Username:     username,
-				PasswordHash: string(hashedPassword),
-			}
-
-			userID, err := uc.repo.CreateUser(ctx, user)
-			if err != nil {
-				return models.User{}, fmt.Errorf("error creating user: %w", err)
-			}
-
-			user.ID = userID
-			return user, nil
+			return uc.register(ctx, username, password)
 		}
 		return models.User{}, err
 	}
+	if user.PasswordHash == "" {
+		// Accounts without a password cannot be claimed by whoever logs in first; an admin resets it.
+		return models.User{}, models.ErrMismatch
+	}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(password))
-	if err != nil {
+	ok, legacy, err := uc.verify(user.PasswordHash, password)
+	if err != nil || !ok {
 		log.Printf("Password mismatch: %v\n", err)
 		return models.User{}, models.ErrMismatch
 	}
 
+	if legacy || uc.hasher.NeedsRehash(user.PasswordHash) {
+		uc.rehash(ctx, user, password)
+	}
+	if user.Username != username {
+		user = uc.migrateUsername(ctx, user, username)
+	}
+
 	log.Printf("password match\n")
 	return user, nil
 }
+
+// getUser looks username up verbatim and falls back to its legacy HTML-escaped form.
+func (uc *AuthUsecaseImpl) getUser(ctx context.Context, username string) (models.User, error) {
+	user, err := uc.repo.GetUser(ctx, username)
+	if escaped := legacyEscape(username); errors.Is(err, models.ErrNotFound) && escaped != username {
+		return uc.repo.GetUser(ctx, escaped)
+	}
+	return user, err
+}
+
+// verify checks password and then its legacy HTML-escaped form. legacy reports a match of the latter,
+// in which case the stored hash has to be replaced.
+func (uc *AuthUsecaseImpl) verify(encoded, password string) (ok, legacy bool, err error) {
+	ok, err = uc.hasher.Verify(encoded, password)
+	if err != nil || ok {
+		return ok, false, err
+	}
+	if escaped := legacyEscape(password); escaped != password {
+		ok, err = uc.hasher.Verify(encoded, escaped)
+		return ok, ok, err
+	}
+	return false, false, nil
+}
+
+// migrateUsername replaces a legacy escaped username with the verbatim one. An escaped name always
+// contains characters the rules for new usernames reject, so the verbatim name is only held to the
+// legacy charset checkLoginUsername applied in Login. If the name is taken, the user keeps logging in
+// through the fallback lookup.
+func (uc *AuthUsecaseImpl) migrateUsername(ctx context.Context, user models.User, username string) models.User {
+	if err := uc.repo.RenameUser(ctx, user.ID, username); err != nil {
+		log.Printf("Failed to migrate escaped username: %v\n", err)
+		return user
+	}
+	user.Username = username
+	return user
+}
+
+func (uc *AuthUsecaseImpl) register(ctx context.Context, username, password string) (models.User, error) {
+	if err := ValidateUsername(username); err != nil {
+		return models.User{}, err
+	}
+	if err := uc.policy.Validate(password); err != nil {
+		return models.User{}, err
+	}
+	hashedPassword, err := uc.hasher.Hash(password)
+	if err != nil {
+		return models.User{}, fmt.Errorf("error hashing password: %w", err)
+	}
+
+	user := models.User{
+		Username:     username,
+		PasswordHash: hashedPassword,
+	}
+
+	userID, err := uc.repo.CreateUser(ctx, user)
+	if err != nil {
+		return models.User{}, fmt.Errorf("error creating user: %w", err)
+	}
+
+	user.ID = userID
+	return user, nil
+}
+
+// rehash upgrades the stored hash to the configured algorithm. Failures only cost another attempt on
+// the next login, so they are logged rather than returned.
+func (uc *AuthUsecaseImpl) rehash(ctx context.Context, user models.User, password string) {
+	hashedPassword, err := uc.hasher.Hash(password)
+	if err != nil {
+		log.Printf("Failed to rehash password: %v\n", err)
+		return
+	}
+	if err = uc.repo.UpdatePasswordHash(ctx, user.ID, user.PasswordHash, hashedPassword); err != nil {
+		log.Printf("Failed to store rehashed password: %v\n", err)
+	}
+}
+
+func (uc *AuthUsecaseImpl) ChangePassword(ctx context.Context, userID uint, currentPassword, newPassword string) (models.User, error) {
+	user, err := uc.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	ok, _, err := uc.verify(user.PasswordHash, currentPassword)
+	if err != nil || !ok {
+		return models.User{}, models.ErrMismatch
+	}
+	if err = uc.policy.Validate(newPassword); err != nil {
+		return models.User{}, err
+	}
+
+	hashedPassword, err := uc.hasher.Hash(newPassword)
+	if err != nil {
+		return models.User{}, fmt.Errorf("error hashing password: %w", err)
+	}
+	version, err := uc.repo.ChangePassword(ctx, userID, hashedPassword)
+	if err != nil {
+		return models.User{}, err
+	}
+
+	user.PasswordHash = hashedPassword
+	user.TokenVersion = version
+	return user, nil
+}
+
+func (uc *AuthUsecaseImpl) TokenVersion(ctx context.Context, userID uint) (int, error) {
+	return uc.repo.GetTokenVersion(ctx, userID)
+}
+
+func (uc *AuthUsecaseImpl) Roles(ctx context.Context, userID uint) ([]string, error) {
+	return uc.repo.GetRoles(ctx, userID)
+}
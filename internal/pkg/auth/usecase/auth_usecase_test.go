@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+	mocks "Merch_store-Avito_test_task/internal/pkg/auth/mocks"
+	"Merch_store-Avito_test_task/internal/pkg/auth/password"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestAuthUsecase_Login(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockAuthRepository(ctrl)
+	cfg := config.Password{BcryptCost: bcrypt.MinCost}
+	uc := NewAuthUsecase(repo, cfg)
+	ctx := context.Background()
+
+	t.Run("Rehashes outdated hash", func(t *testing.T) {
+		oldHash, err := password.NewHasher(config.Password{BcryptCost: bcrypt.MinCost + 1}).Hash("password")
+		assert.NoError(t, err)
+		repo.EXPECT().GetUser(ctx, "alice").Return(models.User{ID: 1, Username: "alice", PasswordHash: oldHash}, nil)
+		repo.EXPECT().UpdatePasswordHash(ctx, uint(1), oldHash, gomock.Any()).DoAndReturn(func(_ context.Context, _ uint, _, hash string) error {
+			cost, err := bcrypt.Cost([]byte(hash))
+			assert.NoError(t, err)
+			assert.Equal(t, bcrypt.MinCost, cost)
+			return nil
+		})
+
+		user, err := uc.Login(ctx, "alice", "password")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), user.ID)
+	})
+
+	t.Run("Wrong password", func(t *testing.T) {
+		hash, err := password.NewHasher(cfg).Hash("password")
+		assert.NoError(t, err)
+		repo.EXPECT().GetUser(ctx, "alice").Return(models.User{ID: 1, Username: "alice", PasswordHash: hash}, nil)
+
+		_, err = uc.Login(ctx, "alice", "wrong-password")
+		assert.ErrorIs(t, err, models.ErrMismatch)
+	})
+
+	t.Run("Credentials are used verbatim", func(t *testing.T) {
+		repo.EXPECT().GetUser(ctx, "carol").Return(models.User{}, models.ErrNotFound)
+		repo.EXPECT().CreateUser(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, user models.User) (uint, error) {
+			assert.Equal(t, "carol", user.Username)
+			ok, err := password.NewHasher(cfg).Verify(user.PasswordHash, "p&ss<word>")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			return 3, nil
+		})
+
+		user, err := uc.Login(ctx, "carol", "p&ss<word>")
+		assert.NoError(t, err)
+		assert.Equal(t, uint(3), user.ID)
+	})
+
+	t.Run("Invalid username on registration", func(t *testing.T) {
+		repo.EXPECT().GetUser(ctx, "<b>").Return(models.User{}, models.ErrNotFound)
+		repo.EXPECT().GetUser(ctx, "&lt;b&gt;").Return(models.User{}, models.ErrNotFound)
+
+		_, err := uc.Login(ctx, "<b>", "password")
+		assert.ErrorIs(t, err, models.ErrBadUsername)
+	})
+
+	t.Run("Weak password on registration", func(t *testing.T) {
+		repo.EXPECT().GetUser(ctx, "bob").Return(models.User{}, models.ErrNotFound)
+
+		_, err := uc.Login(ctx, "bob", "short")
+		assert.ErrorIs(t, err, models.ErrWeakPassword)
+	})
+
+	t.Run("Account without a password cannot be claimed", func(t *testing.T) {
+		repo.EXPECT().GetUser(ctx, "dave").Return(models.User{ID: 4, Username: "dave"}, nil)
+
+		_, err := uc.Login(ctx, "dave", "my-password")
+		assert.ErrorIs(t, err, models.ErrMismatch)
+	})
+}
+
+func TestAuthUsecase_ChangePassword(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockAuthRepository(ctrl)
+	cfg := config.Password{BcryptCost: bcrypt.MinCost}
+	uc := NewAuthUsecase(repo, cfg)
+	ctx := context.Background()
+	hash, err := password.NewHasher(cfg).Hash("password")
+	assert.NoError(t, err)
+
+	t.Run("Successful change bumps token version", func(t *testing.T) {
+		repo.EXPECT().GetUserByID(ctx, uint(1)).Return(models.User{ID: 1, Username: "alice", PasswordHash: hash}, nil)
+		repo.EXPECT().ChangePassword(ctx, uint(1), gomock.Any()).Return(1, nil)
+
+		user, err := uc.ChangePassword(ctx, 1, "password", "new-password")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, user.TokenVersion)
+		ok, err := password.NewHasher(cfg).Verify(user.PasswordHash, "new-password")
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	})
+
+	t.Run("Wrong current password", func(t *testing.T) {
+		repo.EXPECT().GetUserByID(ctx, uint(1)).Return(models.User{ID: 1, Username: "alice", PasswordHash: hash}, nil)
+
+		_, err := uc.ChangePassword(ctx, 1, "wrong-password", "new-password")
+		assert.ErrorIs(t, err, models.ErrMismatch)
+	})
+
+	t.Run("Weak new password", func(t *testing.T) {
+		repo.EXPECT().GetUserByID(ctx, uint(1)).Return(models.User{ID: 1, Username: "alice", PasswordHash: hash}, nil)
+
+		_, err := uc.ChangePassword(ctx, 1, "password", "short")
+		assert.ErrorIs(t, err, models.ErrWeakPassword)
+	})
+}
+
+func TestAuthUsecase_LoginLegacyEscapedCredentials(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockAuthRepository(ctrl)
+	cfg := config.Password{BcryptCost: bcrypt.MinCost}
+	uc := NewAuthUsecase(repo, cfg)
+	ctx := context.Background()
+	escapedHash, err := password.NewHasher(cfg).Hash("p&amp;ss")
+	assert.NoError(t, err)
+
+	t.Run("Escaped password is rehashed verbatim", func(t *testing.T) {
+		repo.EXPECT().GetUser(ctx, "dave").Return(models.User{ID: 4, Username: "dave", PasswordHash: escapedHash}, nil)
+		repo.EXPECT().UpdatePasswordHash(ctx, uint(4), escapedHash, gomock.Any()).DoAndReturn(func(_ context.Context, _ uint, _, hash string) error {
+			ok, err := password.NewHasher(cfg).Verify(hash, "p&ss")
+			assert.NoError(t, err)
+			assert.True(t, ok)
+			return nil
+		})
+
+		_, err := uc.Login(ctx, "dave", "p&ss")
+		assert.NoError(t, err)
+	})
+
+	t.Run("Escaped username is migrated to the verbatim one", func(t *testing.T) {
+		repo.EXPECT().GetUser(ctx, "o'neil").Return(models.User{}, models.ErrNotFound)
+		repo.EXPECT().GetUser(ctx, "o&#39;neil").Return(models.User{ID: 5, Username: "o&#39;neil", PasswordHash: escapedHash}, nil)
+		repo.EXPECT().UpdatePasswordHash(ctx, uint(5), escapedHash, gomock.Any()).Return(nil)
+		repo.EXPECT().RenameUser(ctx, uint(5), "o'neil").Return(nil)
+
+		user, err := uc.Login(ctx, "o'neil", "p&ss")
+		assert.NoError(t, err)
+		assert.Equal(t, "o'neil", user.Username)
+	})
+
+	t.Run("Escaped username is kept when the verbatim one is taken", func(t *testing.T) {
+		hash, err := password.NewHasher(cfg).Hash("p&ss")
+		assert.NoError(t, err)
+		repo.EXPECT().GetUser(ctx, "a<b").Return(models.User{}, models.ErrNotFound)
+		repo.EXPECT().GetUser(ctx, "a&lt;b").Return(models.User{ID: 7, Username: "a&lt;b", PasswordHash: hash}, nil)
+		repo.EXPECT().RenameUser(ctx, uint(7), "a<b").Return(models.ErrAlreadyExists)
+
+		user, err := uc.Login(ctx, "a<b", "p&ss")
+		assert.NoError(t, err)
+		assert.Equal(t, "a&lt;b", user.Username)
+	})
+
+	t.Run("Escaped password does not match raw escaped input", func(t *testing.T) {
+		hash, err := password.NewHasher(cfg).Hash("p&ss")
+		assert.NoError(t, err)
+		repo.EXPECT().GetUser(ctx, "erin").Return(models.User{ID: 6, Username: "erin", PasswordHash: hash}, nil)
+
+		_, err = uc.Login(ctx, "erin", "p&amp;ss")
+		assert.ErrorIs(t, err, models.ErrMismatch)
+	})
+}
+
+func TestValidateUsername(t *testing.T) {
+	assert.NoError(t, ValidateUsername("john.doe_42"))
+	assert.ErrorIs(t, ValidateUsername("jd"), models.ErrBadUsername)
+	assert.ErrorIs(t, ValidateUsername("john doe"), models.ErrBadUsername)
+	assert.ErrorIs(t, ValidateUsername("<script>"), models.ErrBadUsername)
+	assert.ErrorIs(t, checkLoginUsername("bad\x00name"), models.ErrBadUsername)
+}
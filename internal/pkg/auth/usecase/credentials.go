@@ -0,0 +1,49 @@
+package usecase
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"fmt"
+	"html/template"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MinUsernameLength = 3
+	MaxUsernameLength = 32
+	// maxLoginUsernameLength bounds lookups of existing users, whose names predate the charset rules.
+	maxLoginUsernameLength = 256
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// ValidateUsername checks the rules for new usernames: 3-32 ASCII letters, digits, '.', '_' or '-'.
+func ValidateUsername(username string) error {
+	if len(username) < MinUsernameLength || len(username) > MaxUsernameLength {
+		return fmt.Errorf("%w: must be %d to %d characters long", models.ErrBadUsername, MinUsernameLength, MaxUsernameLength)
+	}
+	if !usernamePattern.MatchString(username) {
+		return fmt.Errorf("%w: only letters, digits, '.', '_' and '-' are allowed", models.ErrBadUsername)
+	}
+	return nil
+}
+
+// checkLoginUsername is the weaker check applied before looking up an existing user.
+func checkLoginUsername(username string) error {
+	if len(username) > maxLoginUsernameLength || !utf8.ValidString(username) {
+		return models.ErrBadUsername
+	}
+	for _, r := range username {
+		if unicode.IsControl(r) {
+			return models.ErrBadUsername
+		}
+	}
+	return nil
+}
+
+// legacyEscape reproduces how credentials were stored before they were taken verbatim:
+// the handler ran template.HTMLEscapeString over both username and password.
+func legacyEscape(s string) string {
+	return template.HTMLEscapeString(s)
+}
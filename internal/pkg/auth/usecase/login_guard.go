@@ -0,0 +1,73 @@
+package usecase
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/auth"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"context"
+	"time"
+)
+
+type LoginGuardImpl struct {
+	store auth.AttemptStore
+	cfg   config.LoginGuard
+	now   func() time.Time
+}
+
+func NewLoginGuard(store auth.AttemptStore, cfg config.LoginGuard) *LoginGuardImpl {
+	return &LoginGuardImpl{store: store, cfg: cfg, now: time.Now}
+}
+
+func userKey(username string) string {
+	return "user:" + username
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Allow reports how long the username or IP is still locked out; zero means the attempt may proceed.
+func (g *LoginGuardImpl) Allow(ctx context.Context, username, ip string) (time.Duration, error) {
+	userAttempts, err := g.store.Get(ctx, userKey(username))
+	if err != nil {
+		return 0, err
+	}
+	ipAttempts, err := g.store.Get(ctx, ipKey(ip))
+	if err != nil {
+		return 0, err
+	}
+	now := g.now()
+	return max(g.retryAfter(userAttempts, g.cfg.MaxUserFailures, now), g.retryAfter(ipAttempts, g.cfg.MaxIPFailures, now)), nil
+}
+
+func (g *LoginGuardImpl) RegisterFailure(ctx context.Context, username, ip string) (time.Duration, error) {
+	now := g.now()
+	since := now.Add(-g.cfg.Window)
+	userAttempts, err := g.store.Increment(ctx, userKey(username), now, since)
+	if err != nil {
+		return 0, err
+	}
+	ipAttempts, err := g.store.Increment(ctx, ipKey(ip), now, since)
+	if err != nil {
+		return 0, err
+	}
+	return max(g.retryAfter(userAttempts, g.cfg.MaxUserFailures, now), g.retryAfter(ipAttempts, g.cfg.MaxIPFailures, now)), nil
+}
+
+// RegisterSuccess clears the username counter only: resetting the IP counter would let an attacker
+// interleave logins to their own account to keep guessing from the same address.
+func (g *LoginGuardImpl) RegisterSuccess(ctx context.Context, username, _ string) error {
+	return g.store.Reset(ctx, userKey(username))
+}
+
+func (g *LoginGuardImpl) retryAfter(attempts models.LoginAttempts, limit int, now time.Time) time.Duration {
+	if attempts.Failures < limit || attempts.LastFailure.Before(now.Add(-g.cfg.Window)) {
+		return 0
+	}
+	lockout := g.cfg.BaseLockout
+	for i := limit; i < attempts.Failures && lockout < g.cfg.MaxLockout; i++ {
+		lockout *= 2
+	}
+	lockout = min(lockout, g.cfg.MaxLockout)
+	return max(attempts.LastFailure.Add(lockout).Sub(now), 0)
+}
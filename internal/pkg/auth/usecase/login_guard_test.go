@@ -0,0 +1,104 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/pkg/auth/repository"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginGuard(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	newGuard := func() *LoginGuardImpl {
+		g := NewLoginGuard(repository.NewMemoryAttemptStore(), config.LoginGuard{
+			MaxUserFailures: 3,
+			MaxIPFailures:   5,
+			BaseLockout:     time.Second,
+			MaxLockout:      10 * time.Second,
+			Window:          time.Minute,
+		})
+		g.now = func() time.Time { return now }
+		return g
+	}
+
+	t.Run("Exponential backoff per username", func(t *testing.T) {
+		g := newGuard()
+		for i := 0; i < 2; i++ {
+			retry, err := g.RegisterFailure(ctx, "alice", "10.0.0.1")
+			assert.NoError(t, err)
+			assert.Zero(t, retry)
+		}
+
+		expected := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+		for _, want := range expected {
+			retry, err := g.RegisterFailure(ctx, "alice", "10.0.0.1")
+			assert.NoError(t, err)
+			assert.Equal(t, want, retry)
+		}
+
+		retry, err := g.Allow(ctx, "alice", "10.0.0.2")
+		assert.NoError(t, err)
+		assert.Equal(t, 10*time.Second, retry)
+
+		now = now.Add(4 * time.Second)
+		retry, err = g.Allow(ctx, "alice", "10.0.0.2")
+		assert.NoError(t, err)
+		assert.Equal(t, 6*time.Second, retry)
+
+		now = now.Add(6 * time.Second)
+		retry, err = g.Allow(ctx, "alice", "10.0.0.2")
+		assert.NoError(t, err)
+		assert.Zero(t, retry)
+	})
+
+	t.Run("Per IP limit across usernames", func(t *testing.T) {
+		g := newGuard()
+		for _, username := range []string{"u1", "u2", "u3", "u4"} {
+			retry, err := g.RegisterFailure(ctx, username, "10.0.0.9")
+			assert.NoError(t, err)
+			assert.Zero(t, retry)
+		}
+		retry, err := g.RegisterFailure(ctx, "u5", "10.0.0.9")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Second, retry)
+
+		retry, err = g.Allow(ctx, "fresh", "10.0.0.9")
+		assert.NoError(t, err)
+		assert.Equal(t, time.Second, retry)
+
+		retry, err = g.Allow(ctx, "fresh", "10.0.0.10")
+		assert.NoError(t, err)
+		assert.Zero(t, retry)
+	})
+
+	t.Run("Success resets username counter", func(t *testing.T) {
+		g := newGuard()
+		for i := 0; i < 3; i++ {
+			_, err := g.RegisterFailure(ctx, "bob", "10.0.0.3")
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, g.RegisterSuccess(ctx, "bob", "10.0.0.3"))
+
+		retry, err := g.Allow(ctx, "bob", "10.0.0.4")
+		assert.NoError(t, err)
+		assert.Zero(t, retry)
+	})
+
+	t.Run("Counters expire after window", func(t *testing.T) {
+		g := newGuard()
+		for i := 0; i < 3; i++ {
+			_, err := g.RegisterFailure(ctx, "carol", "10.0.0.5")
+			assert.NoError(t, err)
+		}
+		now = now.Add(2 * time.Minute)
+
+		retry, err := g.RegisterFailure(ctx, "carol", "10.0.0.5")
+		assert.NoError(t, err)
+		assert.Zero(t, retry)
+	})
+}
@@ -0,0 +1,160 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the counters of one cache since it was created.
+type Stats struct {
+	Hits      uint64 `json:"hits"`
+	Misses    uint64 `json:"misses"`
+	Evictions uint64 `json:"evictions"`
+	Size      int    `json:"size"`
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time
+}
+
+// LRU is a size-bounded cache whose entries expire TTL after they were stored. When full, the least
+// recently used entry is evicted. Values are returned as stored, so callers must not modify them.
+type LRU[K comparable, V any] struct {
+	mu       sync.Mutex
+	items    map[K]*list.Element
+	order    *list.List // front is the most recently used
+	capacity int
+	ttl      time.Duration
+	now      func() time.Time
+	// generation changes on every invalidation, so a load that raced with one is not stored.
+	generation uint64
+
+	hits, misses, evictions atomic.Uint64
+}
+
+// NewLRU creates a cache of up to capacity entries; now may be nil to use the wall clock.
+func NewLRU[K comparable, V any](capacity int, ttl time.Duration, now func() time.Time) *LRU[K, V] {
+	if now == nil {
+		now = time.Now
+	}
+	return &LRU[K, V]{
+		items:    make(map[K]*list.Element),
+		order:    list.New(),
+		capacity: capacity,
+		ttl:      ttl,
+		now:      now,
+	}
+}
+
+// Get returns the cached value for key, if present and not expired.
+func (c *LRU[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	value, ok := c.get(key)
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+	return value, ok
+}
+
+func (c *LRU[K, V]) get(key K) (V, bool) {
+	var zero V
+	elem, ok := c.items[key]
+	if !ok {
+		return zero, false
+	}
+	e := elem.Value.(*entry[K, V])
+	if !c.now().Before(e.expires) {
+		c.remove(elem)
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	return e.value, true
+}
+
+// Set stores value under key, evicting the least recently used entry when the cache is full.
+func (c *LRU[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(key, value)
+}
+
+func (c *LRU[K, V]) set(key K, value V) {
+	expires := c.now().Add(c.ttl)
+	if elem, ok := c.items[key]; ok {
+		e := elem.Value.(*entry[K, V])
+		e.value, e.expires = value, expires
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.items[key] = c.order.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	for c.order.Len() > c.capacity {
+		c.remove(c.order.Back())
+		c.evictions.Add(1)
+	}
+}
+
+// GetOrLoad returns the cached value for key or calls load and caches its result. Errors are not
+// cached. If the cache is invalidated while load runs, its result is returned but not stored, since
+// it may predate the change that caused the invalidation.
+func (c *LRU[K, V]) GetOrLoad(key K, load func() (V, error)) (V, error) {
+	c.mu.Lock()
+	value, ok := c.get(key)
+	generation := c.generation
+	c.mu.Unlock()
+	if ok {
+		c.hits.Add(1)
+		return value, nil
+	}
+	c.misses.Add(1)
+
+	value, err := load()
+	if err != nil {
+		return value, err
+	}
+	c.mu.Lock()
+	if c.generation == generation {
+		c.set(key, value)
+	}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Delete drops keys from the cache.
+func (c *LRU[K, V]) Delete(keys ...K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	for _, key := range keys {
+		if elem, ok := c.items[key]; ok {
+			c.remove(elem)
+		}
+	}
+}
+
+// Purge drops every entry.
+func (c *LRU[K, V]) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.generation++
+	c.items = make(map[K]*list.Element)
+	c.order.Init()
+}
+
+func (c *LRU[K, V]) remove(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.items, elem.Value.(*entry[K, V]).key)
+}
+
+func (c *LRU[K, V]) Stats() Stats {
+	c.mu.Lock()
+	size := c.order.Len()
+	c.mu.Unlock()
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Evictions: c.evictions.Load(), Size: size}
+}
@@ -0,0 +1,91 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func TestLRU(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+
+	t.Run("Evicts least recently used", func(t *testing.T) {
+		c := NewLRU[string, int](2, time.Minute, clock.Now)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		_, _ = c.Get("a")
+		c.Set("c", 3)
+
+		_, ok := c.Get("b")
+		assert.False(t, ok)
+		value, ok := c.Get("a")
+		assert.True(t, ok)
+		assert.Equal(t, 1, value)
+		assert.Equal(t, Stats{Hits: 2, Misses: 1, Evictions: 1, Size: 2}, c.Stats())
+	})
+
+	t.Run("Entries expire", func(t *testing.T) {
+		c := NewLRU[string, int](2, time.Minute, clock.Now)
+		c.Set("a", 1)
+		clock.now = clock.now.Add(time.Minute)
+
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+		assert.Equal(t, 0, c.Stats().Size)
+	})
+
+	t.Run("Delete and purge", func(t *testing.T) {
+		c := NewLRU[string, int](3, time.Minute, clock.Now)
+		c.Set("a", 1)
+		c.Set("b", 2)
+		c.Set("c", 3)
+		c.Delete("a", "missing")
+		_, ok := c.Get("a")
+		assert.False(t, ok)
+
+		c.Purge()
+		assert.Equal(t, 0, c.Stats().Size)
+	})
+}
+
+func TestLRU_GetOrLoad(t *testing.T) {
+	c := NewLRU[uint, string](10, time.Minute, nil)
+	loads := 0
+	load := func() (string, error) {
+		loads++
+		return "alice", nil
+	}
+
+	t.Run("Loads once", func(t *testing.T) {
+		for range 3 {
+			value, err := c.GetOrLoad(1, load)
+			assert.NoError(t, err)
+			assert.Equal(t, "alice", value)
+		}
+		assert.Equal(t, 1, loads)
+	})
+
+	t.Run("Errors are not cached", func(t *testing.T) {
+		_, err := c.GetOrLoad(2, func() (string, error) { return "", errors.New("db error") })
+		assert.Error(t, err)
+		_, ok := c.Get(2)
+		assert.False(t, ok)
+	})
+
+	t.Run("Load racing an invalidation is not stored", func(t *testing.T) {
+		value, err := c.GetOrLoad(3, func() (string, error) {
+			c.Delete(3)
+			return "stale", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "stale", value)
+		_, ok := c.Get(3)
+		assert.False(t, ok)
+	})
+}
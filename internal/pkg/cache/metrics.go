@@ -0,0 +1,11 @@
+package cache
+
+import "expvar"
+
+// metrics holds the stats of every published cache under the "cache" expvar.
+var metrics = expvar.NewMap("cache")
+
+// Publish exposes the stats of a cache as cache.<name> in expvar; publishing a name again replaces it.
+func Publish(name string, cache interface{ Stats() Stats }) {
+	metrics.Set(name, expvar.Func(func() any { return cache.Stats() }))
+}
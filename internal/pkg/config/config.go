@@ -1,39 +1,586 @@
 package config
 
 import (
+	"errors"
+	"fmt"
 	"github.com/ilyakaznacheev/cleanenv"
-	"log"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
 	"os"
+	"regexp"
+	"strings"
 	"time"
 )
 
+const redacted = "***"
+
+const defaultConfigPath = "config/config.yaml"
+
 type Config struct {
-	ConfigPath string `env:"CONFIG_PATH" env-default:"config/config.yaml"`
-	Database   Database
+	ConfigPath string     `yaml:"-" env:"CONFIG_PATH" env-default:"config/config.yaml"`
+	Database   Database   `yaml:"Database"`
 	HttpServer HttpServer `yaml:"HttpServer"`
+	GRPCServer GRPCServer `yaml:"GRPCServer"`
+	JWT        JWT        `yaml:"JWT"`
+	LoginGuard LoginGuard `yaml:"LoginGuard"`
+	RateLimit  RateLimit  `yaml:"RateLimit"`
+	Password   Password   `yaml:"Password"`
+	OpenAPI    OpenAPI    `yaml:"OpenAPI"`
+	Admin      Admin      `yaml:"Admin"`
+	Outbox     Outbox     `yaml:"Outbox"`
+	Webhooks   Webhooks   `yaml:"Webhooks"`
+	Migrations Migrations `yaml:"Migrations"`
+	Cache      Cache      `yaml:"Cache"`
 }
 
 type Database struct {
-	DbHost string `env:"DB_HOST" env-required:"true"`
-	DbPort int    `env:"DB_PORT" env-required:"true"`
-	DbUser string `env:"DB_USER" env-required:"true"`
-	DbPass string `env:"DB_PASS" env-required:"true"`
-	DbName string `env:"DB_NAME" env-required:"true"`
+	DbHost string `yaml:"host" env:"DB_HOST"`
+	DbPort int    `yaml:"port" env:"DB_PORT"`
+	DbUser string `yaml:"user" env:"DB_USER"`
+	DbPass string `yaml:"password" env:"DB_PASS"`
+	DbName string `yaml:"name" env:"DB_NAME"`
+
+	SSLMode     string `yaml:"sslmode" env:"DB_SSLMODE" env-default:"disable"`
+	SSLRootCert string `yaml:"sslrootcert" env:"DB_SSLROOTCERT"`
+	SSLCert     string `yaml:"sslcert" env:"DB_SSLCERT"`
+	SSLKey      string `yaml:"sslkey" env:"DB_SSLKEY"`
+
+	// DSN overrides the individual connection fields above when set.
+	DSN string `yaml:"dsn" env:"DB_DSN"`
+	// ReplicaDSN points read-only queries to a replica; empty means use the primary.
+	ReplicaDSN string `yaml:"replica_dsn" env:"DB_REPLICA_DSN"`
+
+	MaxOpenConns    int           `yaml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" env-default:"100"`
+	MaxIdleConns    int           `yaml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" env-default:"50"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" env-default:"10m"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" env-default:"10m"`
 }
 
 type HttpServer struct {
-	Address      int           `yaml:"Address" env-default:"8080"`
-	IdleTimeout  time.Duration `yaml:"idle_timeout" yaml-default:"60s"`
-	ReadTimeout  time.Duration `yaml:"read_timeout" yaml-default:"10s"`
-	WriteTimeout time.Duration `yaml:"write_timeout" yaml-default:"10s"`
+	Address      int           `yaml:"Address" env:"HTTP_PORT" env-default:"8080"`
+	IdleTimeout  time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT" env-default:"60s"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT" env-default:"10s"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT" env-default:"10s"`
+}
+
+// GRPCServer configures the gRPC API for internal services.
+type GRPCServer struct {
+	Disabled bool `yaml:"disabled" env:"GRPC_DISABLED"`
+	Address  int  `yaml:"Address" env:"GRPC_PORT" env-default:"9090"`
+}
+
+// JWT configures token signing keys. Keys is a comma-separated list of kid:secret HS256 pairs and
+// PrivateKeys a list of kid:path pairs pointing to RSA or Ed25519 PEM files; only the public halves of
+// the latter are published via JWKS. To rotate, add the new key, switch CurrentKey to it and drop the
+// old one once issued tokens expire. A plain Secret is kept for compatibility under the "default" kid.
+type JWT struct {
+	Secret      string            `yaml:"secret" env:"JWT_SECRET"`
+	Keys        map[string]string `yaml:"keys" env:"JWT_KEYS" env-separator:","`
+	PrivateKeys map[string]string `yaml:"private_keys" env:"JWT_PRIVATE_KEYS" env-separator:","`
+	CurrentKey  string            `yaml:"current_key" env:"JWT_CURRENT_KEY"`
+
+	Issuer    string        `yaml:"issuer" env:"JWT_ISSUER" env-default:"merch-store"`
+	Audience  []string      `yaml:"audience" env:"JWT_AUDIENCE" env-separator:"," env-default:"merch-store"`
+	TokenTTL  time.Duration `yaml:"token_ttl" env:"JWT_TOKEN_TTL" env-default:"15m"`
+	ClockSkew time.Duration `yaml:"clock_skew" env:"JWT_CLOCK_SKEW" env-default:"30s"`
+}
+
+const defaultJWTKeyID = "default"
+
+// HMACKeys returns every active HS256 kid with its secret.
+func (j JWT) HMACKeys() map[string]string {
+	keys := make(map[string]string, len(j.Keys)+1)
+	for kid, secret := range j.Keys {
+		keys[kid] = secret
+	}
+	if j.Secret != "" {
+		if _, ok := keys[defaultJWTKeyID]; !ok {
+			keys[defaultJWTKeyID] = j.Secret
+		}
+	}
+	return keys
+}
+
+// CurrentKeyID returns the kid used to sign new tokens.
+func (j JWT) CurrentKeyID() string {
+	if j.CurrentKey != "" {
+		return j.CurrentKey
+	}
+	kids := j.keyIDs()
+	if len(kids) == 1 {
+		return kids[0]
+	}
+	return ""
+}
+
+func (j JWT) keyIDs() []string {
+	var kids []string
+	for kid := range j.HMACKeys() {
+		kids = append(kids, kid)
+	}
+	for kid := range j.PrivateKeys {
+		kids = append(kids, kid)
+	}
+	return kids
+}
+
+func (j JWT) validate() error {
+	kids := j.keyIDs()
+	if len(kids) == 0 {
+		return errors.New("either JWT_SECRET, JWT_KEYS or JWT_PRIVATE_KEYS must be set")
+	}
+	seen := make(map[string]bool, len(kids))
+	for _, kid := range kids {
+		if seen[kid] {
+			return fmt.Errorf("JWT key %q is configured twice", kid)
+		}
+		seen[kid] = true
+	}
+	if j.Issuer == "" || len(j.Audience) == 0 || j.Audience[0] == "" {
+		return errors.New("JWT issuer and audience must not be empty")
+	}
+	if j.TokenTTL <= 0 || j.ClockSkew <= 0 {
+		return errors.New("JWT token_ttl and clock_skew must be positive")
+	}
+	kid := j.CurrentKeyID()
+	if kid == "" {
+		return errors.New("JWT_CURRENT_KEY must be set when several JWT keys are configured")
+	}
+	if !seen[kid] {
+		return fmt.Errorf("JWT_CURRENT_KEY %q is not among the configured keys", kid)
+	}
+	return nil
+}
+
+// LoginGuard configures brute-force protection of /api/auth. After MaxUserFailures (or MaxIPFailures)
+// failed attempts within Window, further attempts are locked out for BaseLockout, doubling with every
+// additional failure up to MaxLockout.
+type LoginGuard struct {
+	Store           string        `yaml:"store" env:"LOGIN_GUARD_STORE" env-default:"postgres"`
+	MaxUserFailures int           `yaml:"max_user_failures" env:"LOGIN_GUARD_MAX_USER_FAILURES" env-default:"5"`
+	MaxIPFailures   int           `yaml:"max_ip_failures" env:"LOGIN_GUARD_MAX_IP_FAILURES" env-default:"20"`
+	BaseLockout     time.Duration `yaml:"base_lockout" env:"LOGIN_GUARD_BASE_LOCKOUT" env-default:"1s"`
+	MaxLockout      time.Duration `yaml:"max_lockout" env:"LOGIN_GUARD_MAX_LOCKOUT" env-default:"15m"`
+	Window          time.Duration `yaml:"window" env:"LOGIN_GUARD_WINDOW" env-default:"15m"`
 }
 
-func Load() *Config {
+func (g LoginGuard) validate() error {
+	var errs []error
+	if g.Store != "memory" && g.Store != "postgres" {
+		errs = append(errs, fmt.Errorf("LoginGuard.store must be memory or postgres, got %q", g.Store))
+	}
+	if g.MaxUserFailures <= 0 || g.MaxIPFailures <= 0 {
+		errs = append(errs, errors.New("LoginGuard failure limits must be positive"))
+	}
+	if g.BaseLockout <= 0 || g.MaxLockout < g.BaseLockout || g.Window <= 0 {
+		errs = append(errs, errors.New("LoginGuard lockouts and window must be positive and max_lockout >= base_lockout"))
+	}
+	return errors.Join(errs...)
+}
+
+// RateLimit configures per-user token buckets for the authenticated API routes, and a per-IP bucket
+// checked before the caller is authenticated.
+type RateLimit struct {
+	Disabled bool            `yaml:"disabled" env:"RATE_LIMIT_DISABLED"`
+	SendCoin RateLimitRule   `yaml:"send_coin" env-prefix:"RATE_LIMIT_SEND_COIN_"`
+	Buy      RateLimitRule   `yaml:"buy" env-prefix:"RATE_LIMIT_BUY_"`
+	Info     RateLimitRule   `yaml:"info" env-prefix:"RATE_LIMIT_INFO_"`
+	Client   ClientRateLimit `yaml:"client"`
+}
+
+// RateLimitRule allows Requests per Period on average with bursts of up to Burst (defaults to Requests).
+type RateLimitRule struct {
+	Requests int           `yaml:"requests" env:"REQUESTS" env-default:"60"`
+	Period   time.Duration `yaml:"period" env:"PERIOD" env-default:"1m"`
+	Burst    int           `yaml:"burst" env:"BURST"`
+}
+
+// ClientRateLimit is the per-IP rule applied to every authenticated route before the token or API key
+// is checked, so floods of bad credentials are throttled before they reach the database. Everyone
+// behind one address shares it, hence the higher default.
+type ClientRateLimit struct {
+	Requests int           `yaml:"requests" env:"RATE_LIMIT_CLIENT_REQUESTS" env-default:"600"`
+	Period   time.Duration `yaml:"period" env:"RATE_LIMIT_CLIENT_PERIOD" env-default:"1m"`
+	Burst    int           `yaml:"burst" env:"RATE_LIMIT_CLIENT_BURST"`
+}
+
+func (c ClientRateLimit) Rule() RateLimitRule {
+	return RateLimitRule(c)
+}
+
+func (r RateLimit) validate() error {
+	var errs []error
+	rules := []struct {
+		name string
+		rule RateLimitRule
+	}{{"send_coin", r.SendCoin}, {"buy", r.Buy}, {"info", r.Info}, {"client", r.Client.Rule()}}
+	for _, route := range rules {
+		if route.rule.Requests <= 0 || route.rule.Period <= 0 || route.rule.Burst < 0 {
+			errs = append(errs, fmt.Errorf("RateLimit.%s requests and period must be positive", route.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// OpenAPI controls checking of requests and responses against the embedded API document.
+// Responses are buffered for validation, so keep it off in production.
+type OpenAPI struct {
+	Validate bool `yaml:"validate" env:"OPENAPI_VALIDATE"`
+}
+
+// Admin lists the users who get every API key scope when they authenticate with a token, so the first
+// API keys can be issued without touching the database.
+type Admin struct {
+	Usernames []string `yaml:"usernames" env:"ADMIN_USERNAMES" env-separator:","`
+}
+
+// Migrations controls the embedded schema migrations. With OnStart the service applies pending
+// migrations before serving; replicas starting together wait on an advisory lock instead of racing.
+type Migrations struct {
+	OnStart bool `yaml:"on_start" env:"MIGRATE_ON_START"`
+}
+
+// Cache configures the in-process LRU caches of /api/info responses and of product prices used by
+// purchases. A transfer or purchase drops the entries it changes on this instance; changes made by
+// other replicas or merchctl become visible once the TTL expires.
+type Cache struct {
+	Disabled     bool          `yaml:"disabled" env:"CACHE_DISABLED"`
+	UserInfoSize int           `yaml:"user_info_size" env:"CACHE_USER_INFO_SIZE" env-default:"10000"`
+	UserInfoTTL  time.Duration `yaml:"user_info_ttl" env:"CACHE_USER_INFO_TTL" env-default:"10s"`
+	ProductsSize int           `yaml:"products_size" env:"CACHE_PRODUCTS_SIZE" env-default:"1000"`
+	ProductsTTL  time.Duration `yaml:"products_ttl" env:"CACHE_PRODUCTS_TTL" env-default:"1m"`
+}
+
+func (c Cache) validate() error {
+	if c.Disabled {
+		return nil
+	}
+	if c.UserInfoSize <= 0 || c.ProductsSize <= 0 || c.UserInfoTTL <= 0 || c.ProductsTTL <= 0 {
+		return errors.New("Cache sizes and TTLs must be positive")
+	}
+	return nil
+}
+
+// Outbox configures the relay that publishes the domain events written by transfers and purchases.
+// Events are delivered at least once: a failed publish is retried after BaseBackoff, doubling up to
+// MaxBackoff, and after MaxAttempts the event is marked dead and left in the table for inspection.
+// Disabling the relay only stops publishing from this instance; events are still recorded.
+type Outbox struct {
+	Disabled     bool          `yaml:"disabled" env:"OUTBOX_DISABLED"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"OUTBOX_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"OUTBOX_MAX_ATTEMPTS" env-default:"10"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" env:"OUTBOX_BASE_BACKOFF" env-default:"1s"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" env:"OUTBOX_MAX_BACKOFF" env-default:"5m"`
+	// Lease hides claimed events from other relays; it must exceed the time to publish a batch.
+	Lease time.Duration `yaml:"lease" env:"OUTBOX_LEASE" env-default:"30s"`
+}
+
+func (o Outbox) validate() error {
+	if o.Disabled {
+		return nil
+	}
+	var errs []error
+	if o.PollInterval <= 0 || o.Lease <= 0 {
+		errs = append(errs, errors.New("Outbox poll_interval and lease must be positive"))
+	}
+	if o.BatchSize <= 0 || o.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("Outbox batch_size and max_attempts must be positive"))
+	}
+	if o.BaseBackoff <= 0 || o.MaxBackoff < o.BaseBackoff {
+		errs = append(errs, errors.New("Outbox base_backoff must be positive and max_backoff >= base_backoff"))
+	}
+	return errors.Join(errs...)
+}
+
+// Webhooks configures delivery of domain events to subscribed endpoints. A failed delivery is retried
+// after BaseBackoff, doubling up to MaxBackoff, and marked failed after MaxAttempts; admins can then
+// redeliver it by hand. Disabling stops sending from this instance, deliveries are still queued.
+type Webhooks struct {
+	Disabled     bool          `yaml:"disabled" env:"WEBHOOKS_DISABLED"`
+	PollInterval time.Duration `yaml:"poll_interval" env:"WEBHOOKS_POLL_INTERVAL" env-default:"1s"`
+	BatchSize    int           `yaml:"batch_size" env:"WEBHOOKS_BATCH_SIZE" env-default:"20"`
+	MaxAttempts  int           `yaml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"8"`
+	BaseBackoff  time.Duration `yaml:"base_backoff" env:"WEBHOOKS_BASE_BACKOFF" env-default:"10s"`
+	MaxBackoff   time.Duration `yaml:"max_backoff" env:"WEBHOOKS_MAX_BACKOFF" env-default:"1h"`
+	Timeout      time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT" env-default:"10s"`
+	Lease        time.Duration `yaml:"lease" env:"WEBHOOKS_LEASE" env-default:"1m"`
+}
+
+func (w Webhooks) validate() error {
+	if w.Disabled {
+		return nil
+	}
+	var errs []error
+	if w.PollInterval <= 0 || w.Timeout <= 0 {
+		errs = append(errs, errors.New("Webhooks poll_interval and timeout must be positive"))
+	}
+	if w.Lease <= w.Timeout {
+		errs = append(errs, errors.New("Webhooks lease must exceed timeout"))
+	}
+	if w.BatchSize <= 0 || w.MaxAttempts <= 0 {
+		errs = append(errs, errors.New("Webhooks batch_size and max_attempts must be positive"))
+	}
+	if w.BaseBackoff <= 0 || w.MaxBackoff < w.BaseBackoff {
+		errs = append(errs, errors.New("Webhooks base_backoff must be positive and max_backoff >= base_backoff"))
+	}
+	return errors.Join(errs...)
+}
+
+// Password configures how passwords are hashed and which new passwords are accepted.
+// Existing hashes are upgraded on the next successful login when the algorithm or its cost changes.
+type Password struct {
+	Algorithm     string `yaml:"algorithm" env:"PASSWORD_ALGORITHM" env-default:"bcrypt"`
+	BcryptCost    int    `yaml:"bcrypt_cost" env:"PASSWORD_BCRYPT_COST" env-default:"10"`
+	Argon2Memory  uint32 `yaml:"argon2_memory_kib" env:"PASSWORD_ARGON2_MEMORY_KIB" env-default:"65536"`
+	Argon2Time    uint32 `yaml:"argon2_time" env:"PASSWORD_ARGON2_TIME" env-default:"3"`
+	Argon2Threads uint8  `yaml:"argon2_threads" env:"PASSWORD_ARGON2_THREADS" env-default:"2"`
+
+	MinLength     int  `yaml:"min_length" env:"PASSWORD_MIN_LENGTH" env-default:"8"`
+	MaxLength     int  `yaml:"max_length" env:"PASSWORD_MAX_LENGTH" env-default:"72"`
+	RequireUpper  bool `yaml:"require_upper" env:"PASSWORD_REQUIRE_UPPER"`
+	RequireLower  bool `yaml:"require_lower" env:"PASSWORD_REQUIRE_LOWER"`
+	RequireDigit  bool `yaml:"require_digit" env:"PASSWORD_REQUIRE_DIGIT"`
+	RequireSymbol bool `yaml:"require_symbol" env:"PASSWORD_REQUIRE_SYMBOL"`
+}
+
+func (p Password) validate() error {
+	var errs []error
+	switch p.Algorithm {
+	case "bcrypt":
+		if p.BcryptCost < 4 || p.BcryptCost > 31 {
+			errs = append(errs, fmt.Errorf("Password.bcrypt_cost must be in range 4-31, got %d", p.BcryptCost))
+		}
+		if p.MaxLength > 72 {
+			errs = append(errs, errors.New("Password.max_length must not exceed 72 bytes with bcrypt"))
+		}
+	case "argon2id":
+		if p.Argon2Memory == 0 || p.Argon2Time == 0 || p.Argon2Threads == 0 {
+			errs = append(errs, errors.New("Password argon2 parameters must be positive"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("Password.algorithm must be bcrypt or argon2id, got %q", p.Algorithm))
+	}
+	if p.MinLength <= 0 || p.MaxLength < p.MinLength {
+		errs = append(errs, errors.New("Password.min_length must be positive and not exceed max_length"))
+	}
+	return errors.Join(errs...)
+}
+
+// Load reads the YAML file from CONFIG_PATH (if it exists) and applies environment
+// overrides on top of it. A missing file is only an error when CONFIG_PATH is set explicitly.
+func Load() (*Config, error) {
 	var cfg Config
 
-	if err := cleanenv.ReadEnv(&cfg); err != nil {
-		log.Printf("cannot read .env file: %s\n (fix: you need to put .env file in main dir)", err)
-		os.Exit(1)
+	path, explicit := os.LookupEnv("CONFIG_PATH")
+	if !explicit {
+		path = defaultConfigPath
+	}
+	_, err := os.Stat(path)
+	switch {
+	case err == nil:
+		if err = cleanenv.ReadConfig(path, &cfg); err != nil {
+			return nil, fmt.Errorf("cannot read config file %s: %w", path, err)
+		}
+	case explicit || !errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("cannot read config file %s: %w", path, err)
+	default:
+		if err = cleanenv.ReadEnv(&cfg); err != nil {
+			return nil, fmt.Errorf("cannot read environment: %w", err)
+		}
+	}
+
+	if err = cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return &cfg, nil
+}
+
+func (c *Config) Validate() error {
+	return errors.Join(c.Database.validate(), c.HttpServer.validate(), c.GRPCServer.validate(), c.JWT.validate(), c.LoginGuard.validate(), c.RateLimit.validate(), c.Password.validate(), c.Outbox.validate(), c.Webhooks.validate(), c.Cache.validate())
+}
+
+// Redacted returns a copy of the config with every secret masked.
+func (c Config) Redacted() Config {
+	if c.Database.DbPass != "" {
+		c.Database.DbPass = redacted
+	}
+	c.Database.DSN = RedactDSN(c.Database.DSN)
+	c.Database.ReplicaDSN = RedactDSN(c.Database.ReplicaDSN)
+	if c.JWT.Secret != "" {
+		c.JWT.Secret = redacted
+	}
+	if len(c.JWT.Keys) > 0 {
+		keys := make(map[string]string, len(c.JWT.Keys))
+		for kid := range c.JWT.Keys {
+			keys[kid] = redacted
+		}
+		c.JWT.Keys = keys
+	}
+	return c
+}
+
+// WriteRedacted dumps the effective config as YAML with secrets masked.
+func (c Config) WriteRedacted(w io.Writer) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(c.Redacted()); err != nil {
+		return fmt.Errorf("cannot encode config: %w", err)
+	}
+	return enc.Close()
+}
+
+func (s GRPCServer) validate() error {
+	if s.Disabled {
+		return nil
+	}
+	if s.Address < 1 || s.Address > 65535 {
+		return fmt.Errorf("GRPCServer.Address must be in range 1-65535, got %d", s.Address)
+	}
+	return nil
+}
+
+func (s HttpServer) validate() error {
+	var errs []error
+	if s.Address < 1 || s.Address > 65535 {
+		errs = append(errs, fmt.Errorf("HttpServer.Address must be in range 1-65535, got %d", s.Address))
+	}
+	if s.IdleTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("HttpServer.idle_timeout must be positive, got %s", s.IdleTimeout))
+	}
+	if s.ReadTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("HttpServer.read_timeout must be positive, got %s", s.ReadTimeout))
+	}
+	if s.WriteTimeout <= 0 {
+		errs = append(errs, fmt.Errorf("HttpServer.write_timeout must be positive, got %s", s.WriteTimeout))
+	}
+	return errors.Join(errs...)
+}
+
+func (d Database) validate() error {
+	var errs []error
+	if d.DSN == "" {
+		var missing []string
+		if d.DbHost == "" {
+			missing = append(missing, "DB_HOST")
+		}
+		if d.DbPort == 0 {
+			missing = append(missing, "DB_PORT")
+		}
+		if d.DbUser == "" {
+			missing = append(missing, "DB_USER")
+		}
+		if d.DbPass == "" {
+			missing = append(missing, "DB_PASS")
+		}
+		if d.DbName == "" {
+			missing = append(missing, "DB_NAME")
+		}
+		if len(missing) > 0 {
+			errs = append(errs, fmt.Errorf("either DB_DSN or %s must be set", strings.Join(missing, ", ")))
+		}
+		if d.DbPort < 0 || d.DbPort > 65535 {
+			errs = append(errs, fmt.Errorf("Database.port must be in range 1-65535, got %d", d.DbPort))
+		}
+		switch d.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			errs = append(errs, fmt.Errorf("Database.sslmode %q is not supported", d.SSLMode))
+		}
+	}
+	if d.MaxOpenConns < 0 || d.MaxIdleConns < 0 {
+		errs = append(errs, errors.New("Database pool sizes must not be negative"))
+	}
+	if d.ConnMaxLifetime < 0 || d.ConnMaxIdleTime < 0 {
+		errs = append(errs, errors.New("Database connection lifetimes must not be negative"))
+	}
+	return errors.Join(errs...)
+}
+
+// ConnString returns the DSN of the primary database.
+func (d Database) ConnString() string {
+	if d.DSN != "" {
+		return d.DSN
+	}
+	params := []string{
+		"host=" + quoteDSNValue(d.DbHost),
+		fmt.Sprintf("port=%d", d.DbPort),
+		"user=" + quoteDSNValue(d.DbUser),
+		"password=" + quoteDSNValue(d.DbPass),
+		"dbname=" + quoteDSNValue(d.DbName),
+		"sslmode=" + quoteDSNValue(d.SSLMode),
+	}
+	if d.SSLRootCert != "" {
+		params = append(params, "sslrootcert="+quoteDSNValue(d.SSLRootCert))
+	}
+	if d.SSLCert != "" {
+		params = append(params, "sslcert="+quoteDSNValue(d.SSLCert))
+	}
+	if d.SSLKey != "" {
+		params = append(params, "sslkey="+quoteDSNValue(d.SSLKey))
+	}
+	return strings.Join(params, " ")
+}
+
+// LogValue implements slog.LogValuer so the config can be logged without leaking secrets.
+func (d Database) LogValue() slog.Value {
+	pass := ""
+	if d.DbPass != "" {
+		pass = redacted
+	}
+	return slog.GroupValue(
+		slog.String("host", d.DbHost),
+		slog.Int("port", d.DbPort),
+		slog.String("user", d.DbUser),
+		slog.String("password", pass),
+		slog.String("name", d.DbName),
+		slog.String("sslmode", d.SSLMode),
+		slog.String("dsn", RedactDSN(d.DSN)),
+		slog.String("replica_dsn", RedactDSN(d.ReplicaDSN)),
+		slog.Int("max_open_conns", d.MaxOpenConns),
+		slog.Int("max_idle_conns", d.MaxIdleConns),
+		slog.Duration("conn_max_lifetime", d.ConnMaxLifetime),
+		slog.Duration("conn_max_idle_time", d.ConnMaxIdleTime),
+	)
+}
+
+var dsnPasswordRe = regexp.MustCompile(`(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// RedactDSN masks the password in both URL and key=value connection strings.
+func RedactDSN(dsn string) string {
+	if dsn == "" {
+		return ""
+	}
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		u, err := url.Parse(dsn)
+		if err != nil {
+			return redacted
+		}
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), redacted)
+		}
+		q := u.Query()
+		if q.Has("password") {
+			q.Set("password", redacted)
+			u.RawQuery = q.Encode()
+		}
+		s, err := url.PathUnescape(u.String())
+		if err != nil {
+			return u.String()
+		}
+		return s
+	}
+	return dsnPasswordRe.ReplaceAllString(dsn, "${1}"+redacted)
+}
+
+func quoteDSNValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, ` '\`) {
+		return value
 	}
-	return &cfg
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
 }
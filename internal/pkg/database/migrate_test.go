@@ -0,0 +1,132 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"Merch_store-Avito_test_task/db/migrations"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMigrations = fstest.MapFS{
+	"01_init.up.sql":     {Data: []byte(`CREATE TABLE a (id INT)`)},
+	"01_init.down.sql":   {Data: []byte(`DROP TABLE a`)},
+	"02_b.up.sql":        {Data: []byte(`CREATE TABLE b (id INT)`)},
+	"10_seed.up.sql":     {Data: []byte(`INSERT INTO b VALUES (1)`)},
+	"products.csv":       {Data: []byte("name;price\n")},
+	"notes/03_x.up.sql":  {Data: []byte(`SELECT 1`)},
+	"02_b.down.sql.orig": {Data: []byte(`DROP TABLE b`)},
+}
+
+func newTestMigrator(t *testing.T) (*Migrator, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := NewMigrator(db, testMigrations, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	assert.NoError(t, err)
+	return migrator, mock
+}
+
+func expectApplied(mock sqlmock.Sqlmock, versions ...int64) {
+	mock.ExpectExec(`CREATE TABLE IF NOT EXISTS "schema_migrations"`).WillReturnResult(sqlmock.NewResult(0, 0))
+	rows := sqlmock.NewRows([]string{"version", "applied_at"})
+	for _, v := range versions {
+		rows.AddRow(v, time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	}
+	mock.ExpectQuery(`SELECT version, applied_at FROM "schema_migrations"`).WillReturnRows(rows)
+}
+
+func TestLoadMigrations(t *testing.T) {
+	loaded, err := LoadMigrations(testMigrations)
+	assert.NoError(t, err)
+	assert.Equal(t, []Migration{
+		{Version: 1, Name: "init", Up: `CREATE TABLE a (id INT)`, Down: `DROP TABLE a`},
+		{Version: 2, Name: "b", Up: `CREATE TABLE b (id INT)`},
+		{Version: 10, Name: "seed", Up: `INSERT INTO b VALUES (1)`},
+	}, loaded)
+
+	_, err = LoadMigrations(fstest.MapFS{"01_a.up.sql": {}, "01_b.up.sql": {}})
+	assert.Error(t, err)
+	_, err = LoadMigrations(fstest.MapFS{"01_a.down.sql": {Data: []byte(`SELECT 1`)}})
+	assert.EqualError(t, err, "migration 1_a has no up script")
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	loaded, err := LoadMigrations(migrations.FS)
+	assert.NoError(t, err)
+	for i, m := range loaded {
+		assert.Equal(t, int64(i+1), m.Version, "versions must be contiguous")
+		assert.NotEmpty(t, m.Down, "migration %d_%s must be reversible", m.Version, m.Name)
+	}
+}
+
+func TestMigrator_Up(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+
+	mock.ExpectExec(`SELECT pg_advisory_lock\(\$1\)`).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+	expectApplied(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectExec(`CREATE TABLE b`).WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`INSERT INTO "schema_migrations" \(version, name\) VALUES \(\$1, \$2\)`).WithArgs(2, "b").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO b`).WillReturnError(errors.New("boom"))
+	mock.ExpectRollback()
+	mock.ExpectExec(`SELECT pg_advisory_unlock\(\$1\)`).WithArgs(migrationLockID).WillReturnResult(sqlmock.NewResult(0, 0))
+
+	n, err := migrator.Up(context.Background())
+	assert.EqualError(t, err, "migration 10_seed failed: boom")
+	assert.Equal(t, 1, n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMigrator_Down(t *testing.T) {
+	t.Run("Newest first", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+
+		mock.ExpectExec(`SELECT pg_advisory_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+		expectApplied(mock, 1)
+		mock.ExpectBegin()
+		mock.ExpectExec(`DROP TABLE a`).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(`DELETE FROM "schema_migrations" WHERE version = \$1`).WithArgs(1).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+		mock.ExpectExec(`SELECT pg_advisory_unlock`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		n, err := migrator.Down(context.Background(), 5)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Irreversible", func(t *testing.T) {
+		migrator, mock := newTestMigrator(t)
+
+		mock.ExpectExec(`SELECT pg_advisory_lock`).WillReturnResult(sqlmock.NewResult(0, 0))
+		expectApplied(mock, 1, 2)
+		mock.ExpectExec(`SELECT pg_advisory_unlock`).WillReturnResult(sqlmock.NewResult(0, 0))
+
+		n, err := migrator.Down(context.Background(), 1)
+		assert.ErrorIs(t, err, ErrIrreversible)
+		assert.Equal(t, 0, n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMigrator_Status(t *testing.T) {
+	migrator, mock := newTestMigrator(t)
+	expectApplied(mock, 1, 2)
+
+	status, err := migrator.Status(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, status, 3)
+	assert.NotNil(t, status[1].AppliedAt)
+	assert.Equal(t, MigrationStatus{Version: 10, Name: "seed"}, status[2])
+}
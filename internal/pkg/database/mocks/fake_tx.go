@@ -0,0 +1,58 @@
+// Package mock_database provides test doubles for the database package.
+package mock_database
+
+import (
+	"context"
+	"sync"
+)
+
+type fakeTxKey struct{}
+
+// FakeTxManager is an in-memory stand-in for database.TxManager in usecase tests. It runs fn directly, joins
+// enclosing calls the same way and counts how the outermost ones ended.
+type FakeTxManager struct {
+	// CommitErr, when set, is returned by outermost calls whose fn succeeded, like a failed commit.
+	CommitErr error
+
+	mu                 sync.Mutex
+	commits, rollbacks int
+}
+
+func (f *FakeTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if InFakeTx(ctx) {
+		return fn(ctx)
+	}
+	err := fn(context.WithValue(ctx, fakeTxKey{}, true))
+	if err == nil {
+		err = f.CommitErr
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err != nil {
+		f.rollbacks++
+	} else {
+		f.commits++
+	}
+	return err
+}
+
+// InFakeTx reports whether ctx was passed down by FakeTxManager.WithinTx, so a test can check that a
+// repository was called inside the unit of work.
+func InFakeTx(ctx context.Context) bool {
+	inTx, _ := ctx.Value(fakeTxKey{}).(bool)
+	return inTx
+}
+
+// Commits returns how many units of work succeeded.
+func (f *FakeTxManager) Commits() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.commits
+}
+
+// Rollbacks returns how many units of work failed.
+func (f *FakeTxManager) Rollbacks() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.rollbacks
+}
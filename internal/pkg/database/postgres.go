@@ -0,0 +1,26 @@
+package database
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"database/sql"
+	"fmt"
+	_ "github.com/lib/pq"
+)
+
+// Open connects to Postgres using dsn and applies the pool settings from cfg.
+func Open(dsn string, cfg config.Database) (*sql.DB, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err = db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	return db, nil
+}
@@ -0,0 +1,84 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/lib/pq"
+	"math/rand/v2"
+	"time"
+)
+
+// SQLSTATEs of transactions Postgres aborted to resolve a conflict; running them again can succeed.
+const (
+	codeSerializationFailure = "40001"
+	codeDeadlockDetected     = "40P01"
+)
+
+// RetryPolicy bounds how often InTx runs a transaction again. The wait before attempt n+1 is a random
+// duration up to BaseBackoff doubled n-1 times, capped at MaxBackoff.
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+}
+
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 5, BaseBackoff: 5 * time.Millisecond, MaxBackoff: 100 * time.Millisecond}
+
+// IsRetryable reports whether err is a serialization failure or a detected deadlock.
+func IsRetryable(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && (pqErr.Code == codeSerializationFailure || pqErr.Code == codeDeadlockDetected)
+}
+
+// InTx runs fn in a transaction and commits it. When fn or the commit fails with a serialization
+// failure or deadlock, the transaction is rolled back and fn runs again in a fresh one, up to
+// policy.MaxAttempts times. fn must not have effects outside tx, since it may run more than once.
+func InTx[T any](ctx context.Context, db *sql.DB, opts *sql.TxOptions, policy RetryPolicy, fn func(tx *sql.Tx) (T, error)) (T, error) {
+	var zero T
+	for attempt := 1; ; attempt++ {
+		result, err := runTx(ctx, db, opts, fn)
+		if err == nil {
+			return result, nil
+		}
+		if !IsRetryable(err) || attempt >= policy.MaxAttempts {
+			return zero, err
+		}
+		select {
+		case <-ctx.Done():
+			return zero, fmt.Errorf("%w (retrying: %w)", err, ctx.Err())
+		case <-time.After(policy.backoff(attempt)):
+		}
+	}
+}
+
+func runTx[T any](ctx context.Context, db *sql.DB, opts *sql.TxOptions, fn func(tx *sql.Tx) (T, error)) (T, error) {
+	var zero T
+	tx, err := db.BeginTx(ctx, opts)
+	if err != nil {
+		return zero, fmt.Errorf("failed start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := fn(tx)
+	if err != nil {
+		return zero, err
+	}
+	if err = tx.Commit(); err != nil {
+		return zero, fmt.Errorf("committing transaction failed: %w", err)
+	}
+	return result, nil
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseBackoff
+	for i := 1; i < attempt && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	d = min(d, p.MaxBackoff)
+	if d <= 0 {
+		return 0
+	}
+	return rand.N(d) + 1
+}
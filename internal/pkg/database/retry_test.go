@@ -0,0 +1,121 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+var testRetryPolicy = RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Millisecond, MaxBackoff: 2 * time.Millisecond}
+
+func TestInTx(t *testing.T) {
+	deadlock := &pq.Error{Code: codeDeadlockDetected}
+	update := func(tx *sql.Tx) (int64, error) {
+		res, err := tx.Exec(`UPDATE "user" SET coins = coins + 1`)
+		if err != nil {
+			return 0, err
+		}
+		return res.RowsAffected()
+	}
+
+	t.Run("Retries a deadlock", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "user"`).WillReturnError(deadlock)
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "user"`).WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
+
+		n, err := InTx(context.Background(), db, nil, testRetryPolicy, update)
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retries a serialization failure on commit", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "user"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit().WillReturnError(&pq.Error{Code: codeSerializationFailure})
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "user"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		_, err = InTx(context.Background(), db, nil, testRetryPolicy, update)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Gives up after max attempts", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		for range testRetryPolicy.MaxAttempts {
+			mock.ExpectBegin()
+			mock.ExpectExec(`UPDATE "user"`).WillReturnError(deadlock)
+			mock.ExpectRollback()
+		}
+
+		_, err = InTx(context.Background(), db, nil, testRetryPolicy, update)
+		assert.ErrorIs(t, err, deadlock)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Other errors are not retried", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "user"`).WillReturnError(&pq.Error{Code: "23514"})
+		mock.ExpectRollback()
+
+		_, err = InTx(context.Background(), db, nil, testRetryPolicy, update)
+		assert.False(t, IsRetryable(err))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Stops when the context is done", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		ctx, cancel := context.WithCancel(context.Background())
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "user"`).WillReturnError(deadlock)
+		mock.ExpectRollback()
+
+		_, err = InTx(ctx, db, nil, RetryPolicy{MaxAttempts: 3, BaseBackoff: time.Hour, MaxBackoff: time.Hour}, func(tx *sql.Tx) (int64, error) {
+			defer cancel()
+			return update(tx)
+		})
+		assert.ErrorIs(t, err, deadlock)
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := RetryPolicy{BaseBackoff: 10 * time.Millisecond, MaxBackoff: 25 * time.Millisecond}
+	for attempt, limit := range map[int]time.Duration{1: 10 * time.Millisecond, 2: 20 * time.Millisecond, 5: 25 * time.Millisecond} {
+		for range 20 {
+			d := policy.backoff(attempt)
+			assert.Positive(t, d)
+			assert.LessOrEqual(t, d, limit)
+		}
+	}
+	assert.True(t, IsRetryable(errors.Join(errors.New("wrapped"), &pq.Error{Code: codeDeadlockDetected})))
+}
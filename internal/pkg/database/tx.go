@@ -0,0 +1,50 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+)
+
+// Querier is satisfied by *sql.DB and *sql.Tx.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// txKey stores the transaction running on db in a context.
+type txKey struct{ db *sql.DB }
+
+// TxManager runs units of work in a transaction carried by the context. Repositories that run their
+// statements on Conn(ctx, db) take part in the transaction of their caller, so several repositories
+// can change data atomically.
+type TxManager struct {
+	db     *sql.DB
+	policy RetryPolicy
+}
+
+func NewTxManager(db *sql.DB) *TxManager {
+	return &TxManager{db: db, policy: DefaultRetryPolicy}
+}
+
+// WithinTx runs fn in a transaction and commits it if fn succeeds. Inside a transaction on the same
+// database it joins that one, leaving the commit to the outermost call. As with InTx, fn is run
+// again after a serialization failure or deadlock, so it must not have effects outside the database.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	key := txKey{m.db}
+	if _, ok := ctx.Value(key).(*sql.Tx); ok {
+		return fn(ctx)
+	}
+	_, err := InTx(ctx, m.db, nil, m.policy, func(tx *sql.Tx) (struct{}, error) {
+		return struct{}{}, fn(context.WithValue(ctx, key, tx))
+	})
+	return err
+}
+
+// Conn returns the transaction on db that ctx carries, or db itself outside of one.
+func Conn(ctx context.Context, db *sql.DB) Querier {
+	if tx, ok := ctx.Value(txKey{db}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
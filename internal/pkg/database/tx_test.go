@@ -0,0 +1,114 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	mocks "Merch_store-Avito_test_task/internal/pkg/database/mocks"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTxManager(t *testing.T) {
+	exec := func(ctx context.Context, db *sql.DB, query string) error {
+		_, err := Conn(ctx, db).ExecContext(ctx, query)
+		return err
+	}
+
+	t.Run("Repositories share the transaction", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		m := NewTxManager(db)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "purchase"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`INSERT INTO "outbox_event"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err = m.WithinTx(context.Background(), func(ctx context.Context) error {
+			assert.IsType(t, &sql.Tx{}, Conn(ctx, db))
+			if err := exec(ctx, db, `INSERT INTO "purchase"`); err != nil {
+				return err
+			}
+			// A repository starting its own unit of work joins the enclosing one.
+			return m.WithinTx(ctx, func(ctx context.Context) error {
+				return exec(ctx, db, `INSERT INTO "outbox_event"`)
+			})
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Failure rolls everything back", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		m := NewTxManager(db)
+		failure := errors.New("audit failed")
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO "purchase"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectRollback()
+
+		err = m.WithinTx(context.Background(), func(ctx context.Context) error {
+			if err := exec(ctx, db, `INSERT INTO "purchase"`); err != nil {
+				return err
+			}
+			return failure
+		})
+		assert.ErrorIs(t, err, failure)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Retries the whole unit of work", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		m := &TxManager{db: db, policy: testRetryPolicy}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "user"`).WillReturnError(&pq.Error{Code: codeDeadlockDetected})
+		mock.ExpectRollback()
+		mock.ExpectBegin()
+		mock.ExpectExec(`UPDATE "user"`).WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		runs := 0
+		err = m.WithinTx(context.Background(), func(ctx context.Context) error {
+			runs++
+			return exec(ctx, db, `UPDATE "user"`)
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, runs)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Outside a transaction", func(t *testing.T) {
+		db, _, err := sqlmock.New()
+		assert.NoError(t, err)
+		defer db.Close()
+		assert.Equal(t, Querier(db), Conn(context.Background(), db))
+	})
+}
+
+func TestFakeTxManager(t *testing.T) {
+	var m mocks.FakeTxManager
+	ctx := context.Background()
+
+	assert.NoError(t, m.WithinTx(ctx, func(ctx context.Context) error {
+		assert.True(t, mocks.InFakeTx(ctx))
+		return m.WithinTx(ctx, func(context.Context) error { return nil })
+	}))
+	assert.Error(t, m.WithinTx(ctx, func(context.Context) error { return errors.New("failed") }))
+	m.CommitErr = errors.New("commit failed")
+	assert.ErrorIs(t, m.WithinTx(ctx, func(context.Context) error { return nil }), m.CommitErr)
+
+	assert.False(t, mocks.InFakeTx(ctx))
+	assert.Equal(t, 1, m.Commits())
+	assert.Equal(t, 2, m.Rollbacks())
+}
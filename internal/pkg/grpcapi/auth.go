@@ -0,0 +1,75 @@
+package grpcapi
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/jwt"
+	"Merch_store-Avito_test_task/internal/pkg/middleware"
+	"context"
+	"log/slog"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AuthInterceptor authenticates every call with the same access tokens as the HTTP API. The token is
+// taken from "authorization: Bearer <token>" or "access-token" metadata, and tokens revoked by a
+// password change are rejected. The user is stored under the middleware context keys, as the HTTP
+// middleware does.
+func AuthInterceptor(jwtService jwt.JWTInterface, versions middleware.TokenVersionSource, logger *slog.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		token := tokenFromMetadata(ctx)
+		if token == "" {
+			return nil, status.Error(codes.Unauthenticated, "token is missing")
+		}
+		claims, err := jwtService.ParseToken(token)
+		if err != nil || claims == nil {
+			logger.WarnContext(ctx, "token is invalid", slog.String("method", info.FullMethod), slog.Any("error", err))
+			return nil, status.Error(codes.Unauthenticated, "token is invalid")
+		}
+		userID, err := claims.UserID()
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, "token is invalid")
+		}
+
+		current, err := versions.TokenVersion(ctx, userID)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to check token version", slog.Any("error", err))
+			return nil, status.Error(codes.Unauthenticated, "token is invalid")
+		}
+		if current != claims.TokenVersion {
+			return nil, status.Error(codes.Unauthenticated, "token is revoked")
+		}
+
+		ctx = context.WithValue(ctx, middleware.IdKey, userID)
+		ctx = context.WithValue(ctx, middleware.UsernameKey, claims.Username)
+		ctx = context.WithValue(ctx, middleware.TokenVersionKey, claims.TokenVersion)
+		return handler(ctx, req)
+	}
+}
+
+// authenticatedUser returns the user AuthInterceptor authenticated the call as.
+func authenticatedUser(ctx context.Context) (uint, error) {
+	userID, ok := middleware.UserID(ctx)
+	if !ok {
+		return 0, status.Error(codes.Unauthenticated, "user is not authenticated")
+	}
+	return userID, nil
+}
+
+func tokenFromMetadata(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get("authorization"); len(values) > 0 {
+		if token, found := strings.CutPrefix(values[0], "Bearer "); found {
+			return token
+		}
+	}
+	if values := md.Get("access-token"); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
@@ -0,0 +1,241 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: merchstore/v1/merchstore.proto
+
+package merchstorev1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	MerchStore_GetUserInfo_FullMethodName  = "/merchstore.v1.MerchStore/GetUserInfo"
+	MerchStore_SendCoins_FullMethodName    = "/merchstore.v1.MerchStore/SendCoins"
+	MerchStore_BuyItem_FullMethodName      = "/merchstore.v1.MerchStore/BuyItem"
+	MerchStore_ListProducts_FullMethodName = "/merchstore.v1.MerchStore/ListProducts"
+)
+
+// MerchStoreClient is the client API for MerchStore service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// MerchStore exposes the merch store to internal services. Every call acts on behalf of the user
+// whose access token is sent in the "authorization: Bearer <token>" metadata.
+type MerchStoreClient interface {
+	GetUserInfo(ctx context.Context, in *GetUserInfoRequest, opts ...grpc.CallOption) (*GetUserInfoResponse, error)
+	SendCoins(ctx context.Context, in *SendCoinsRequest, opts ...grpc.CallOption) (*SendCoinsResponse, error)
+	BuyItem(ctx context.Context, in *BuyItemRequest, opts ...grpc.CallOption) (*BuyItemResponse, error)
+	ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error)
+}
+
+type merchStoreClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMerchStoreClient(cc grpc.ClientConnInterface) MerchStoreClient {
+	return &merchStoreClient{cc}
+}
+
+func (c *merchStoreClient) GetUserInfo(ctx context.Context, in *GetUserInfoRequest, opts ...grpc.CallOption) (*GetUserInfoResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetUserInfoResponse)
+	err := c.cc.Invoke(ctx, MerchStore_GetUserInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchStoreClient) SendCoins(ctx context.Context, in *SendCoinsRequest, opts ...grpc.CallOption) (*SendCoinsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SendCoinsResponse)
+	err := c.cc.Invoke(ctx, MerchStore_SendCoins_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchStoreClient) BuyItem(ctx context.Context, in *BuyItemRequest, opts ...grpc.CallOption) (*BuyItemResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BuyItemResponse)
+	err := c.cc.Invoke(ctx, MerchStore_BuyItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *merchStoreClient) ListProducts(ctx context.Context, in *ListProductsRequest, opts ...grpc.CallOption) (*ListProductsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListProductsResponse)
+	err := c.cc.Invoke(ctx, MerchStore_ListProducts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// MerchStoreServer is the server API for MerchStore service.
+// All implementations must embed UnimplementedMerchStoreServer
+// for forward compatibility.
+//
+// MerchStore exposes the merch store to internal services. Every call acts on behalf of the user
+// whose access token is sent in the "authorization: Bearer <token>" metadata.
+type MerchStoreServer interface {
+	GetUserInfo(context.Context, *GetUserInfoRequest) (*GetUserInfoResponse, error)
+	SendCoins(context.Context, *SendCoinsRequest) (*SendCoinsResponse, error)
+	BuyItem(context.Context, *BuyItemRequest) (*BuyItemResponse, error)
+	ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error)
+	mustEmbedUnimplementedMerchStoreServer()
+}
+
+// UnimplementedMerchStoreServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMerchStoreServer struct{}
+
+func (UnimplementedMerchStoreServer) GetUserInfo(context.Context, *GetUserInfoRequest) (*GetUserInfoResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetUserInfo not implemented")
+}
+func (UnimplementedMerchStoreServer) SendCoins(context.Context, *SendCoinsRequest) (*SendCoinsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SendCoins not implemented")
+}
+func (UnimplementedMerchStoreServer) BuyItem(context.Context, *BuyItemRequest) (*BuyItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BuyItem not implemented")
+}
+func (UnimplementedMerchStoreServer) ListProducts(context.Context, *ListProductsRequest) (*ListProductsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListProducts not implemented")
+}
+func (UnimplementedMerchStoreServer) mustEmbedUnimplementedMerchStoreServer() {}
+func (UnimplementedMerchStoreServer) testEmbeddedByValue()                    {}
+
+// UnsafeMerchStoreServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MerchStoreServer will
+// result in compilation errors.
+type UnsafeMerchStoreServer interface {
+	mustEmbedUnimplementedMerchStoreServer()
+}
+
+func RegisterMerchStoreServer(s grpc.ServiceRegistrar, srv MerchStoreServer) {
+	// If the following call pancis, it indicates UnimplementedMerchStoreServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&MerchStore_ServiceDesc, srv)
+}
+
+func _MerchStore_GetUserInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetUserInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchStoreServer).GetUserInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchStore_GetUserInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchStoreServer).GetUserInfo(ctx, req.(*GetUserInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchStore_SendCoins_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SendCoinsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchStoreServer).SendCoins(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchStore_SendCoins_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchStoreServer).SendCoins(ctx, req.(*SendCoinsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchStore_BuyItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BuyItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchStoreServer).BuyItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchStore_BuyItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchStoreServer).BuyItem(ctx, req.(*BuyItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _MerchStore_ListProducts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListProductsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MerchStoreServer).ListProducts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: MerchStore_ListProducts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MerchStoreServer).ListProducts(ctx, req.(*ListProductsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// MerchStore_ServiceDesc is the grpc.ServiceDesc for MerchStore service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var MerchStore_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "merchstore.v1.MerchStore",
+	HandlerType: (*MerchStoreServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetUserInfo",
+			Handler:    _MerchStore_GetUserInfo_Handler,
+		},
+		{
+			MethodName: "SendCoins",
+			Handler:    _MerchStore_SendCoins_Handler,
+		},
+		{
+			MethodName: "BuyItem",
+			Handler:    _MerchStore_BuyItem_Handler,
+		},
+		{
+			MethodName: "ListProducts",
+			Handler:    _MerchStore_ListProducts_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "merchstore/v1/merchstore.proto",
+}
@@ -0,0 +1,114 @@
+package grpcapi
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	pb "Merch_store-Avito_test_task/internal/pkg/grpcapi/pb/merchstore/v1"
+	"Merch_store-Avito_test_task/internal/pkg/payments"
+	"Merch_store-Avito_test_task/internal/pkg/service"
+	"context"
+	"errors"
+	"log/slog"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MerchStoreServer implements the gRPC MerchStore service on top of the same usecases as the HTTP API.
+type MerchStoreServer struct {
+	pb.UnimplementedMerchStoreServer
+	payments payments.PaymentsUsecase
+	service  service.ServiceUsecase
+	logger   *slog.Logger
+}
+
+func NewMerchStoreServer(payments payments.PaymentsUsecase, service service.ServiceUsecase, logger *slog.Logger) *MerchStoreServer {
+	return &MerchStoreServer{payments: payments, service: service, logger: logger}
+}
+
+func (s *MerchStoreServer) GetUserInfo(ctx context.Context, _ *pb.GetUserInfoRequest) (*pb.GetUserInfoResponse, error) {
+	userID, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.service.GetUserInfo(ctx, userID)
+	if err != nil {
+		return nil, s.toStatus(ctx, "failed to get user info", err)
+	}
+
+	response := &pb.GetUserInfoResponse{
+		Coins:       int64(info.Coins),
+		CoinHistory: &pb.CoinHistory{},
+	}
+	for _, item := range info.Inventory {
+		response.Inventory = append(response.Inventory, &pb.InventoryItem{Type: item.Type, Quantity: int64(item.Quantity)})
+	}
+	for _, t := range info.CoinHistory.Received {
+		response.CoinHistory.Received = append(response.CoinHistory.Received, &pb.CoinHistory_Received{FromUser: t.FromUser, Amount: int64(t.Amount)})
+	}
+	for _, t := range info.CoinHistory.Sent {
+		response.CoinHistory.Sent = append(response.CoinHistory.Sent, &pb.CoinHistory_Sent{ToUser: t.ToUser, Amount: int64(t.Amount)})
+	}
+	return response, nil
+}
+
+func (s *MerchStoreServer) SendCoins(ctx context.Context, req *pb.SendCoinsRequest) (*pb.SendCoinsResponse, error) {
+	if req.GetToUser() == "" {
+		return nil, status.Error(codes.InvalidArgument, "to_user is required")
+	}
+	userID, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.payments.SendCoins(ctx, userID, req.GetToUser(), uint(req.GetAmount())); err != nil {
+		return nil, s.toStatus(ctx, "failed to send coins", err)
+	}
+	return &pb.SendCoinsResponse{}, nil
+}
+
+func (s *MerchStoreServer) BuyItem(ctx context.Context, req *pb.BuyItemRequest) (*pb.BuyItemResponse, error) {
+	userID, err := authenticatedUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.payments.BuyItem(ctx, userID, uint(req.GetItemId())); err != nil {
+		return nil, s.toStatus(ctx, "failed to buy item", err)
+	}
+	return &pb.BuyItemResponse{}, nil
+}
+
+func (s *MerchStoreServer) ListProducts(ctx context.Context, _ *pb.ListProductsRequest) (*pb.ListProductsResponse, error) {
+	products, err := s.service.ListProducts(ctx)
+	if err != nil {
+		return nil, s.toStatus(ctx, "failed to list products", err)
+	}
+	response := &pb.ListProductsResponse{}
+	for _, p := range products {
+		response.Products = append(response.Products, &pb.Product{Id: uint32(p.ID), Name: p.Name, Price: int64(p.Price)})
+	}
+	return response, nil
+}
+
+// sentinelCodes maps models.Err* sentinels the same way httpresponses maps them to HTTP statuses.
+var sentinelCodes = []struct {
+	err  error
+	code codes.Code
+}{
+	{models.ErrNotFound, codes.NotFound},
+	{models.ErrAlreadyExists, codes.AlreadyExists},
+	{models.ErrMismatch, codes.Unauthenticated},
+	{models.ErrNotEnough, codes.FailedPrecondition},
+	{models.ErrWeakPassword, codes.InvalidArgument},
+	{models.ErrBadUsername, codes.InvalidArgument},
+	{models.ErrBadScope, codes.InvalidArgument},
+	{models.ErrInvalidInput, codes.InvalidArgument},
+}
+
+func (s *MerchStoreServer) toStatus(ctx context.Context, msg string, err error) error {
+	for _, sc := range sentinelCodes {
+		if errors.Is(err, sc.err) {
+			return status.Error(sc.code, sc.err.Error())
+		}
+	}
+	s.logger.ErrorContext(ctx, msg, slog.String("error", err.Error()))
+	return status.Error(codes.Internal, "internal server error")
+}
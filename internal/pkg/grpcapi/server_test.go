@@ -0,0 +1,125 @@
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+	pb "Merch_store-Avito_test_task/internal/pkg/grpcapi/pb/merchstore/v1"
+	"Merch_store-Avito_test_task/internal/pkg/jwt"
+	jwtmock "Merch_store-Avito_test_task/internal/pkg/jwt/mocks"
+	paymentsmock "Merch_store-Avito_test_task/internal/pkg/payments/mocks"
+	servicemock "Merch_store-Avito_test_task/internal/pkg/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+type tokenVersions map[uint]int
+
+func (v tokenVersions) TokenVersion(_ context.Context, userID uint) (int, error) {
+	return v[userID], nil
+}
+
+func claimsFor(userID string, version int) *jwt.Claims {
+	claims := &jwt.Claims{Username: "alice", TokenVersion: version}
+	claims.Subject = userID
+	return claims
+}
+
+func TestMerchStoreServer(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	mockJWT := jwtmock.NewMockJWTInterface(ctrl)
+	mockPayments := paymentsmock.NewMockPaymentsUsecase(ctrl)
+	mockService := servicemock.NewMockServiceUsecase(ctrl)
+
+	listener := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(AuthInterceptor(mockJWT, tokenVersions{1: 2}, logger)))
+	pb.RegisterMerchStoreServer(srv, NewMerchStoreServer(mockPayments, mockService, logger))
+	go func() { _ = srv.Serve(listener) }()
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	defer conn.Close()
+	client := pb.NewMerchStoreClient(conn)
+	authorized := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer good")
+
+	t.Run("Missing token", func(t *testing.T) {
+		_, err := client.ListProducts(context.Background(), &pb.ListProductsRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+
+	t.Run("Revoked token", func(t *testing.T) {
+		mockJWT.EXPECT().ParseToken("old").Return(claimsFor("1", 1), nil)
+		ctx := metadata.AppendToOutgoingContext(context.Background(), "access-token", "old")
+
+		_, err := client.ListProducts(ctx, &pb.ListProductsRequest{})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+		assert.Equal(t, "token is revoked", status.Convert(err).Message())
+	})
+
+	t.Run("GetUserInfo runs as the token's user", func(t *testing.T) {
+		mockJWT.EXPECT().ParseToken("good").Return(claimsFor("1", 2), nil)
+		mockService.EXPECT().GetUserInfo(gomock.Any(), uint(1)).Return(models.UserData{
+			Coins:       900,
+			Inventory:   []models.Inventory{{Type: "cup", Quantity: 1}},
+			CoinHistory: models.CoinHistory{Sent: []models.Transaction{{ToUser: "bob", Amount: 80}}},
+		}, nil)
+
+		info, err := client.GetUserInfo(authorized, &pb.GetUserInfoRequest{})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(900), info.GetCoins())
+		assert.Equal(t, "cup", info.GetInventory()[0].GetType())
+		assert.Equal(t, "bob", info.GetCoinHistory().GetSent()[0].GetToUser())
+	})
+
+	t.Run("SendCoins maps sentinel errors", func(t *testing.T) {
+		mockJWT.EXPECT().ParseToken("good").Return(claimsFor("1", 2), nil)
+		mockPayments.EXPECT().SendCoins(gomock.Any(), uint(1), "bob", uint(5000)).Return(models.ErrNotEnough)
+
+		_, err := client.SendCoins(authorized, &pb.SendCoinsRequest{ToUser: "bob", Amount: 5000})
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+
+	t.Run("BuyItem hides internal errors", func(t *testing.T) {
+		mockJWT.EXPECT().ParseToken("good").Return(claimsFor("1", 2), nil)
+		mockPayments.EXPECT().BuyItem(gomock.Any(), uint(1), uint(3)).Return(errors.New("pq: connection refused"))
+
+		_, err := client.BuyItem(authorized, &pb.BuyItemRequest{ItemId: 3})
+		assert.Equal(t, codes.Internal, status.Code(err))
+		assert.Equal(t, "internal server error", status.Convert(err).Message())
+	})
+
+	t.Run("ListProducts", func(t *testing.T) {
+		mockJWT.EXPECT().ParseToken("good").Return(claimsFor("1", 2), nil)
+		mockService.EXPECT().ListProducts(gomock.Any()).Return([]models.Product{{ID: 1, Name: "t-shirt", Price: 80}}, nil)
+
+		products, err := client.ListProducts(authorized, &pb.ListProductsRequest{})
+		assert.NoError(t, err)
+		assert.Len(t, products.GetProducts(), 1)
+		assert.Equal(t, int64(80), products.GetProducts()[0].GetPrice())
+	})
+
+	t.Run("Calls without a user are rejected", func(t *testing.T) {
+		server := NewMerchStoreServer(mockPayments, mockService, logger)
+
+		_, err := server.BuyItem(context.Background(), &pb.BuyItemRequest{ItemId: 3})
+		assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	})
+}
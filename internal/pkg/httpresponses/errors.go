@@ -0,0 +1,130 @@
+package httpresponses
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/requestid"
+	"errors"
+	"log/slog"
+	"net/http"
+)
+
+// Error codes are part of the API contract: clients branch on them, so they never change once published.
+const (
+	CodeInvalidRequest     = "invalid_request"
+	CodeUnauthorized       = "unauthorized"
+	CodeInvalidToken       = "invalid_token"
+	CodeTokenRevoked       = "token_revoked"
+	CodeInvalidAPIKey      = "invalid_api_key"
+	CodeForbidden          = "forbidden"
+	CodeInvalidCredentials = "invalid_credentials"
+	CodeInvalidUsername    = "invalid_username"
+	CodeWeakPassword       = "weak_password"
+	CodeNotFound           = "not_found"
+	CodeAlreadyExists      = "already_exists"
+	CodeInsufficientFunds  = "insufficient_funds"
+	CodeTooManyRequests    = "too_many_requests"
+	CodeInternal           = "internal_error"
+)
+
+// Error is the body of every error response.
+type Error struct {
+	Status    int    `json:"-"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+func NewError(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// WithDetails returns a copy of e carrying details.
+func (e *Error) WithDetails(details any) *Error {
+	c := *e
+	c.Details = details
+	return &c
+}
+
+var ErrInternal = NewError(http.StatusInternalServerError, CodeInternal, "internal server error")
+
+// sentinels maps model errors to responses. The wrapped error text is only exposed as details for
+// errors that describe the client's own input.
+var sentinels = []struct {
+	err         error
+	response    *Error
+	withDetails bool
+}{
+	{models.ErrNotFound, NewError(http.StatusNotFound, CodeNotFound, "not found"), false},
+	{models.ErrAlreadyExists, NewError(http.StatusConflict, CodeAlreadyExists, "already exists"), false},
+	{models.ErrMismatch, NewError(http.StatusUnauthorized, CodeInvalidCredentials, "invalid credentials"), false},
+	{models.ErrNotEnough, NewError(http.StatusForbidden, CodeInsufficientFunds, "not enough coins"), false},
+	{models.ErrWeakPassword, NewError(http.StatusBadRequest, CodeWeakPassword, "password does not satisfy the password policy"), true},
+	{models.ErrBadUsername, NewError(http.StatusBadRequest, CodeInvalidUsername, "invalid username"), true},
+	{models.ErrBadScope, NewError(http.StatusBadRequest, CodeInvalidRequest, "invalid scope"), true},
+	{models.ErrInvalidInput, NewError(http.StatusBadRequest, CodeInvalidRequest, "invalid input"), true},
+	{models.ErrForbidden, NewError(http.StatusForbidden, CodeForbidden, "forbidden"), true},
+}
+
+// FromError converts err into a response: *Error values are used as is, models.Err* sentinels are
+// mapped and anything else becomes ErrInternal.
+func FromError(err error) *Error {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+	for _, s := range sentinels {
+		if errors.Is(err, s.err) {
+			if s.withDetails && err != s.err {
+				return s.response.WithDetails(err.Error())
+			}
+			return s.response
+		}
+	}
+	return ErrInternal
+}
+
+// Problem is the RFC 9457 form of Error. The code, details and request id are carried as extension members.
+type Problem struct {
+	Type      string `json:"type"`
+	Title     string `json:"title"`
+	Status    int    `json:"status"`
+	Detail    string `json:"detail,omitempty"`
+	Instance  string `json:"instance,omitempty"`
+	Code      string `json:"code"`
+	Details   any    `json:"details,omitempty"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Problem converts e for a request to instance.
+func (e *Error) Problem(instance string) Problem {
+	return Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(e.Status),
+		Status:    e.Status,
+		Detail:    e.Message,
+		Instance:  instance,
+		Code:      e.Code,
+		Details:   e.Details,
+		RequestID: e.RequestID,
+	}
+}
+
+// SendError writes err in the error envelope, tagged with the request id. The body is
+// application/problem+json if the request's Accept header prefers it, application/json otherwise.
+func SendError(w http.ResponseWriter, r *http.Request, err error, logger *slog.Logger) {
+	ctx := r.Context()
+	response := *FromError(err)
+	response.RequestID = requestid.FromContext(ctx)
+
+	w.Header().Add("Vary", "Accept")
+	if NegotiateErrorType(r.Header.Get("Accept")) == ContentTypeProblem {
+		writeJSON(ctx, w, ContentTypeProblem, response.Problem(r.URL.Path), response.Status, logger)
+		return
+	}
+	writeJSON(ctx, w, ContentTypeJSON, response, response.Status, logger)
+}
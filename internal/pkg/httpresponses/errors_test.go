@@ -0,0 +1,62 @@
+package httpresponses
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/requestid"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFromError(t *testing.T) {
+	custom := NewError(http.StatusBadRequest, CodeInvalidRequest, "invalid item id")
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+		expectedCode   string
+		expectedDetail any
+	}{
+		{"Not found", fmt.Errorf("receiver not found: %w", models.ErrNotFound), http.StatusNotFound, CodeNotFound, nil},
+		{"Already exists", models.ErrAlreadyExists, http.StatusConflict, CodeAlreadyExists, nil},
+		{"Mismatch", models.ErrMismatch, http.StatusUnauthorized, CodeInvalidCredentials, nil},
+		{"Not enough coins", fmt.Errorf("not enough coins to buy: %w", models.ErrNotEnough), http.StatusForbidden, CodeInsufficientFunds, nil},
+		{"Weak password exposes details", fmt.Errorf("%w: too short", models.ErrWeakPassword), http.StatusBadRequest, CodeWeakPassword, "weak password: too short"},
+		{"Bad username", models.ErrBadUsername, http.StatusBadRequest, CodeInvalidUsername, nil},
+		{"Bad scope exposes details", fmt.Errorf("%w: unknown scope \"root\"", models.ErrBadScope), http.StatusBadRequest, CodeInvalidRequest, `invalid scope: unknown scope "root"`},
+		{"Invalid input exposes details", fmt.Errorf("%w: url must be absolute", models.ErrInvalidInput), http.StatusBadRequest, CodeInvalidRequest, "invalid input: url must be absolute"},
+		{"Forbidden exposes details", fmt.Errorf("%w: not your key", models.ErrForbidden), http.StatusForbidden, CodeForbidden, "forbidden: not your key"},
+		{"Wrapped API error", fmt.Errorf("parsing: %w", custom), http.StatusBadRequest, CodeInvalidRequest, nil},
+		{"Unknown error is hidden", errors.New("pq: connection refused"), http.StatusInternalServerError, CodeInternal, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response := FromError(tt.err)
+			assert.Equal(t, tt.expectedStatus, response.Status)
+			assert.Equal(t, tt.expectedCode, response.Code)
+			assert.Equal(t, tt.expectedDetail, response.Details)
+		})
+	}
+}
+
+func TestSendError(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+	req = req.WithContext(requestid.WithContext(req.Context(), "req-1"))
+	rr := httptest.NewRecorder()
+
+	SendError(rr, req, errors.New("pq: connection refused"), logger)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	var body map[string]any
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, map[string]any{"code": CodeInternal, "message": "internal server error", "request_id": "req-1"}, body)
+}
@@ -0,0 +1,38 @@
+package httpresponses
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+// NegotiateErrorType picks the media type of an error response from an Accept header.
+// application/json is the default; application/problem+json wins when the client ranks it at least
+// as high, since only clients that understand RFC 9457 ask for it.
+func NegotiateErrorType(accept string) string {
+	var jsonQ, problemQ float64 = -1, -1
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		q := 1.0
+		if v, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(v, 64); err != nil {
+				continue
+			}
+		}
+		switch mediaType {
+		case ContentTypeProblem:
+			problemQ = q
+		case ContentTypeJSON:
+			jsonQ = q
+		case "application/*", "*/*":
+			jsonQ = max(jsonQ, q)
+		}
+	}
+	if problemQ > 0 && problemQ >= jsonQ {
+		return ContentTypeProblem
+	}
+	return ContentTypeJSON
+}
@@ -1,22 +1,43 @@
 package httpresponses
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
+	"strconv"
 )
 
-type Response struct {
-	Message string `json:"message"`
-}
+const (
+	ContentTypeJSON    = "application/json"
+	ContentTypeProblem = "application/problem+json"
+)
 
+// encodeFailureBody is sent when the response itself cannot be encoded.
+const encodeFailureBody = `{"code":"` + CodeInternal + `","message":"internal server error"}` + "\n"
+
+// SendJSONResponse encodes data as application/json. The body is encoded before anything is written,
+// so a failure can still be reported with a proper status.
 func SendJSONResponse(logCtx context.Context, w http.ResponseWriter, data interface{}, status int, logger *slog.Logger) {
-	w.WriteHeader(status)
+	writeJSON(logCtx, w, ContentTypeJSON, data, status, logger)
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+func writeJSON(logCtx context.Context, w http.ResponseWriter, contentType string, data interface{}, status int, logger *slog.Logger) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(data); err != nil {
 		logger.ErrorContext(logCtx, "Failed to encode response to JSON", slog.Any("error", err.Error()))
-		http.Error(w, "Failed to convert to json", http.StatusInternalServerError)
+		buf.Reset()
+		buf.WriteString(encodeFailureBody)
+		contentType, status = ContentTypeJSON, http.StatusInternalServerError
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", contentType)
+	header.Set("Content-Length", strconv.Itoa(buf.Len()))
+	header.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		logger.WarnContext(logCtx, "Failed to write response", slog.Any("error", err.Error()))
 	}
 }
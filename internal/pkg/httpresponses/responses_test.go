@@ -0,0 +1,84 @@
+package httpresponses
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSendJSONResponse(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	t.Run("Headers are sent with the status", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		SendJSONResponse(context.Background(), rr, map[string]int{"coins": 1000}, http.StatusCreated, logger)
+
+		assert.Equal(t, http.StatusCreated, rr.Code)
+		assert.Equal(t, ContentTypeJSON, rr.Result().Header.Get("Content-Type"))
+		assert.Equal(t, "nosniff", rr.Result().Header.Get("X-Content-Type-Options"))
+		assert.Equal(t, strconv.Itoa(rr.Body.Len()), rr.Result().Header.Get("Content-Length"))
+		assert.JSONEq(t, `{"coins": 1000}`, rr.Body.String())
+	})
+
+	t.Run("Encoding failure is reported as 500", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+
+		SendJSONResponse(context.Background(), rr, map[string]any{"broken": make(chan int)}, http.StatusOK, logger)
+
+		assert.Equal(t, http.StatusInternalServerError, rr.Code)
+		assert.Equal(t, ContentTypeJSON, rr.Result().Header.Get("Content-Type"))
+		assert.JSONEq(t, `{"code": "internal_error", "message": "internal server error"}`, rr.Body.String())
+	})
+}
+
+func TestNegotiateErrorType(t *testing.T) {
+	tests := map[string]string{
+		"":                                    ContentTypeJSON,
+		"*/*":                                 ContentTypeJSON,
+		"text/html":                           ContentTypeJSON,
+		"application/json":                    ContentTypeJSON,
+		"application/problem+json":            ContentTypeProblem,
+		"application/problem+json, */*;q=0.1": ContentTypeProblem,
+		"application/json, application/problem+json;q=0.5": ContentTypeJSON,
+		"application/problem+json;q=0.9, application/json": ContentTypeJSON,
+		"application/problem+json;q=0":                     ContentTypeJSON,
+		"application/problem+json;q=bad":                   ContentTypeJSON,
+	}
+	for accept, expected := range tests {
+		assert.Equal(t, expected, NegotiateErrorType(accept), accept)
+	}
+}
+
+func TestSendError_Problem(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	req := httptest.NewRequest(http.MethodGet, "/api/buy/42", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	rr := httptest.NewRecorder()
+
+	SendError(rr, req, errors.Join(errors.New("buying"), models.ErrNotFound), logger)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, ContentTypeProblem, rr.Result().Header.Get("Content-Type"))
+	assert.Equal(t, "Accept", rr.Result().Header.Get("Vary"))
+	var problem map[string]any
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &problem))
+	assert.Equal(t, map[string]any{
+		"type":     "about:blank",
+		"title":    "Not Found",
+		"status":   float64(http.StatusNotFound),
+		"detail":   "not found",
+		"instance": "/api/buy/42",
+		"code":     CodeNotFound,
+	}, problem)
+}
@@ -0,0 +1,25 @@
+package jwt
+
+import (
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"strconv"
+)
+
+// Claims are the claims of an access token. The user id is carried in "sub" as a decimal string
+// so it survives JSON round trips without float precision loss.
+type Claims struct {
+	Username string `json:"username"`
+	// TokenVersion is the user's token_version at issue time; a password change bumps it.
+	TokenVersion int `json:"ver,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// UserID parses the subject claim.
+func (c *Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 64)
+	if err != nil || id == 0 {
+		return 0, fmt.Errorf("invalid subject %q", c.Subject)
+	}
+	return uint(id), nil
+}
@@ -0,0 +1,22 @@
+package http
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
+	"Merch_store-Avito_test_task/internal/pkg/jwt"
+	"log/slog"
+	"net/http"
+)
+
+type JWKSHandler struct {
+	provider jwt.JWKSProvider
+	logger   *slog.Logger
+}
+
+func NewJWKSHandler(provider jwt.JWKSProvider, logger *slog.Logger) *JWKSHandler {
+	return &JWKSHandler{provider: provider, logger: logger}
+}
+
+func (h *JWKSHandler) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	httpresponses.SendJSONResponse(r.Context(), w, h.provider.JWKS(), http.StatusOK, h.logger)
+}
@@ -0,0 +1,37 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/pkg/jwt"
+	jwtmock "Merch_store-Avito_test_task/internal/pkg/jwt/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJWKSHandler_GetJWKS(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProvider := jwtmock.NewMockJWKSProvider(ctrl)
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	handler := NewJWKSHandler(mockProvider, logger)
+
+	expected := jwt.JWKS{Keys: []jwt.JWK{{Kty: "OKP", Kid: "ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519", X: "11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"}}}
+	mockProvider.EXPECT().JWKS().Return(expected)
+
+	req := httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+	rr := httptest.NewRecorder()
+	handler.GetJWKS(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+	var body jwt.JWKS
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	assert.Equal(t, expected, body)
+}
@@ -1,9 +1,11 @@
 package jwt
 
-import "github.com/dgrijalva/jwt-go"
-
 //go:generate mockgen -source=interfaces.go -destination=mocks/mock.go
 type JWTInterface interface {
-	GenerateToken(userID uint, username string) (string, error)
-	ParseToken(tokenString string) (jwt.MapClaims, error)
+	GenerateToken(userID uint, username string, tokenVersion int) (string, error)
+	ParseToken(tokenString string) (*Claims, error)
+}
+
+type JWKSProvider interface {
+	JWKS() JWKS
 }
@@ -1,30 +1,134 @@
 package jwt
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"github.com/dgrijalva/jwt-go"
+	"github.com/golang-jwt/jwt/v5"
 	"log/slog"
+	"strconv"
 	"time"
 )
 
+const (
+	// MinSecretLength is the minimal HS256 secret size in bytes (RFC 7518, section 3.2).
+	MinSecretLength  = 32
+	minDistinctBytes = 8
+
+	DefaultIssuer    = "merch-store"
+	DefaultAudience  = "merch-store"
+	DefaultTokenTTL  = 15 * time.Minute
+	DefaultClockSkew = 30 * time.Second
+)
+
+var ErrWeakSecret = errors.New("jwt secret is too weak")
+
+// Options control the registered claims of issued tokens and how strictly they are validated.
+// Zero values fall back to the Default* constants.
+type Options struct {
+	Issuer string
+	// Audience is put into issued tokens; a token is accepted if it names Audience[0].
+	Audience  []string
+	TokenTTL  time.Duration
+	ClockSkew time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.Issuer == "" {
+		o.Issuer = DefaultIssuer
+	}
+	if len(o.Audience) == 0 {
+		o.Audience = []string{DefaultAudience}
+	}
+	if o.TokenTTL <= 0 {
+		o.TokenTTL = DefaultTokenTTL
+	}
+	if o.ClockSkew <= 0 {
+		o.ClockSkew = DefaultClockSkew
+	}
+	return o
+}
+
 type JWT struct {
-	secret []byte
-	logger *slog.Logger
+	// The empty kid is reserved for the legacy single-secret mode where tokens carry no kid header.
+	keys    *KeySet
+	current string
+	opts    Options
+	parser  *jwt.Parser
+	logger  *slog.Logger
 }
 
 func NewJTW(secret string, logger *slog.Logger) *JWT {
-	return &JWT{[]byte(secret), logger}
+	ks := NewKeySet()
+	ks.keys[""] = signingKey{method: jwt.SigningMethodHS256, sign: []byte(secret), verify: []byte(secret)}
+	return newJWT(ks, "", Options{}, logger)
+}
+
+// NewJWTWithKeySet creates a JWT that signs with the currentKID key and accepts any key of ks.
+func NewJWTWithKeySet(ks *KeySet, currentKID string, opts Options, logger *slog.Logger) (*JWT, error) {
+	if len(ks.keys) == 0 {
+		return nil, fmt.Errorf("%w: no signing keys configured", ErrWeakSecret)
+	}
+	if _, ok := ks.keys[currentKID]; !ok {
+		return nil, fmt.Errorf("current signing key %q is not configured", currentKID)
+	}
+	return newJWT(ks, currentKID, opts, logger), nil
+}
+
+func newJWT(ks *KeySet, currentKID string, opts Options, logger *slog.Logger) *JWT {
+	opts = opts.withDefaults()
+	parser := jwt.NewParser(
+		jwt.WithIssuer(opts.Issuer),
+		jwt.WithAudience(opts.Audience[0]),
+		jwt.WithLeeway(opts.ClockSkew),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	return &JWT{keys: ks, current: currentKID, opts: opts, parser: parser, logger: logger}
+}
+
+// ValidateSecret rejects empty, short and low-entropy HMAC secrets.
+func ValidateSecret(secret []byte) error {
+	if len(secret) < MinSecretLength {
+		return fmt.Errorf("%w: must be at least %d bytes, got %d", ErrWeakSecret, MinSecretLength, len(secret))
+	}
+	distinct := make(map[byte]struct{})
+	for _, b := range secret {
+		distinct[b] = struct{}{}
+	}
+	if len(distinct) < minDistinctBytes {
+		return fmt.Errorf("%w: must contain at least %d distinct characters", ErrWeakSecret, minDistinctBytes)
+	}
+	return nil
 }
 
-func (j *JWT) GenerateToken(userID uint, username string) (string, error) {
-	claims := jwt.MapClaims{
-		"userID":   userID,
-		"username": username,
-		"exp":      time.Now().Add(time.Minute * 15).Unix(),
+func (j *JWT) GenerateToken(userID uint, username string, tokenVersion int) (string, error) {
+	jti, err := newTokenID()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := &Claims{
+		Username:     username,
+		TokenVersion: tokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    j.opts.Issuer,
+			Subject:   strconv.FormatUint(uint64(userID), 10),
+			Audience:  j.opts.Audience,
+			ExpiresAt: jwt.NewNumericDate(now.Add(j.opts.TokenTTL)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        jti,
+		},
 	}
 	j.logger.Debug("checking claims", "claims:", claims)
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(j.secret)
+	key := j.keys.keys[j.current]
+	token := jwt.NewWithClaims(key.method, claims)
+	if j.current != "" {
+		token.Header["kid"] = j.current
+	}
+	tokenString, err := token.SignedString(key.sign)
 	if err != nil {
 		j.logger.Error("error signing token", "err", err)
 		return "", err
@@ -32,22 +136,56 @@ func (j *JWT) GenerateToken(userID uint, username string) (string, error) {
 	return tokenString, nil
 }
 
-func (j *JWT) ParseToken(tokenString string) (jwt.MapClaims, error) {
-	parsedToken, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+func (j *JWT) ParseToken(tokenString string) (*Claims, error) {
+	claims := &Claims{}
+	parsedToken, err := j.parser.ParseWithClaims(tokenString, claims, j.keyFunc)
+	if err != nil || parsedToken == nil || !parsedToken.Valid {
+		j.logger.Error("Error parsing token", slog.Any("error", err))
+		return nil, fmt.Errorf("invalid token")
+	}
+	if claims.ID == "" {
+		j.logger.Error("Error parsing token", slog.String("error", "token has no jti"))
+		return nil, fmt.Errorf("invalid token")
+	}
+	if _, err = claims.UserID(); err != nil {
+		j.logger.Error("Error parsing token", slog.String("error", err.Error()))
+		return nil, fmt.Errorf("invalid token")
+	}
+
+	j.logger.Debug("from parsed token", "claims", claims)
+	return claims, nil
+}
+
+// keyFunc picks the key named by the kid header. Tokens without kid were issued before
+// rotation was enabled, so they are checked against every active HS256 key.
+func (j *JWT) keyFunc(token *jwt.Token) (interface{}, error) {
+	if kid, _ := token.Header["kid"].(string); kid != "" {
+		key, ok := j.keys.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 		}
-		return j.secret, nil
-	})
+		return key.verify, nil
+	}
 
-	if err != nil || parsedToken == nil {
-		j.logger.Error("Error parsing token", slog.String("error", err.Error()))
-		return nil, fmt.Errorf("invalid token")
+	if token.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
 	}
+	var set jwt.VerificationKeySet
+	for _, kid := range j.keys.sortedIDs() {
+		if key := j.keys.keys[kid]; key.method == jwt.SigningMethodHS256 {
+			set.Keys = append(set.Keys, key.verify)
+		}
+	}
+	return set, nil
+}
 
-	if claims, ok := parsedToken.Claims.(jwt.MapClaims); ok && parsedToken.Valid {
-		j.logger.Debug("from parsed token", "claims", claims)
-		return claims, nil
+func newTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating token id: %w", err)
 	}
-	return nil, err
+	return hex.EncodeToString(b), nil
 }
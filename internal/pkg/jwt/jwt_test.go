@@ -0,0 +1,228 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"log/slog"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	oldSecret = "0f4c2a9b7e1d3c5a8b6e9f1a2c4d6e8f"
+	newSecret = "a1b2c3d4e5f60718293a4b5c6d7e8f90"
+)
+
+func TestValidateSecret(t *testing.T) {
+	assert.True(t, errors.Is(ValidateSecret(nil), ErrWeakSecret))
+	assert.True(t, errors.Is(ValidateSecret([]byte("test-secret")), ErrWeakSecret))
+	assert.True(t, errors.Is(ValidateSecret(bytes.Repeat([]byte("ab"), 32)), ErrWeakSecret))
+	assert.NoError(t, ValidateSecret([]byte(oldSecret)))
+}
+
+func newHMACJWT(t *testing.T, keys map[string]string, currentKID string) *JWT {
+	ks := NewKeySet()
+	for kid, secret := range keys {
+		assert.NoError(t, ks.AddHMAC(kid, secret))
+	}
+	j, err := NewJWTWithKeySet(ks, currentKID, Options{}, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	assert.NoError(t, err)
+	return j
+}
+
+func TestNewJWTWithKeySet(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	assert.True(t, errors.Is(NewKeySet().AddHMAC("k1", ""), ErrWeakSecret))
+
+	_, err := NewJWTWithKeySet(NewKeySet(), "k1", Options{}, logger)
+	assert.True(t, errors.Is(err, ErrWeakSecret))
+
+	ks := NewKeySet()
+	assert.NoError(t, ks.AddHMAC("k1", oldSecret))
+	_, err = NewJWTWithKeySet(ks, "k2", Options{}, logger)
+	assert.Error(t, err)
+}
+
+func TestJWT_KeyRotation(t *testing.T) {
+	before := newHMACJWT(t, map[string]string{"k1": oldSecret}, "k1")
+	during := newHMACJWT(t, map[string]string{"k1": oldSecret, "k2": newSecret}, "k2")
+	after := newHMACJWT(t, map[string]string{"k2": newSecret}, "k2")
+
+	oldToken, err := before.GenerateToken(1, "alice", 0)
+	assert.NoError(t, err)
+	newToken, err := during.GenerateToken(2, "bob", 0)
+	assert.NoError(t, err)
+
+	claims, err := during.ParseToken(oldToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "alice", claims.Username)
+
+	claims, err = after.ParseToken(newToken)
+	assert.NoError(t, err)
+	assert.Equal(t, "bob", claims.Username)
+
+	_, err = after.ParseToken(oldToken)
+	assert.Error(t, err)
+	_, err = before.ParseToken(newToken)
+	assert.Error(t, err)
+}
+
+func TestJWT_LegacyTokenWithoutKid(t *testing.T) {
+	legacy := NewJTW(oldSecret, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	token, err := legacy.GenerateToken(1, "alice", 0)
+	assert.NoError(t, err)
+
+	rotated := newHMACJWT(t, map[string]string{"default": oldSecret, "k2": newSecret}, "k2")
+	claims, err := rotated.ParseToken(token)
+	assert.NoError(t, err)
+	userID, err := claims.UserID()
+	assert.NoError(t, err)
+	assert.Equal(t, uint(1), userID)
+}
+
+func TestJWT_TypedClaims(t *testing.T) {
+	j := newHMACJWT(t, map[string]string{"k1": oldSecret}, "k1")
+
+	t.Run("Registered claims are set and survive large ids", func(t *testing.T) {
+		token, err := j.GenerateToken(math.MaxUint32+(1<<40), "dave", 0)
+		assert.NoError(t, err)
+
+		claims, err := j.ParseToken(token)
+		assert.NoError(t, err)
+		userID, err := claims.UserID()
+		assert.NoError(t, err)
+		assert.Equal(t, uint(math.MaxUint32+(1<<40)), userID)
+		assert.Equal(t, DefaultIssuer, claims.Issuer)
+		assert.Equal(t, jwt.ClaimStrings{DefaultAudience}, claims.Audience)
+		assert.NotEmpty(t, claims.ID)
+		assert.NotNil(t, claims.IssuedAt)
+		assert.NotNil(t, claims.NotBefore)
+	})
+
+	sign := func(claims Claims) string {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+		token.Header["kid"] = "k1"
+		s, err := token.SignedString([]byte(oldSecret))
+		assert.NoError(t, err)
+		return s
+	}
+	now := time.Now()
+	valid := Claims{
+		Username: "erin",
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    DefaultIssuer,
+			Subject:   "5",
+			Audience:  jwt.ClaimStrings{DefaultAudience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        "jti-1",
+		},
+	}
+
+	tests := []struct {
+		name   string
+		mutate func(c *Claims)
+		valid  bool
+	}{
+		{"Valid", func(c *Claims) {}, true},
+		{"Wrong issuer", func(c *Claims) { c.Issuer = "evil" }, false},
+		{"Wrong audience", func(c *Claims) { c.Audience = jwt.ClaimStrings{"other-service"} }, false},
+		{"Missing jti", func(c *Claims) { c.ID = "" }, false},
+		{"Missing expiry", func(c *Claims) { c.ExpiresAt = nil }, false},
+		{"Non numeric subject", func(c *Claims) { c.Subject = "admin" }, false},
+		{"Not yet valid within skew", func(c *Claims) { c.NotBefore = jwt.NewNumericDate(now.Add(10 * time.Second)) }, true},
+		{"Not yet valid beyond skew", func(c *Claims) { c.NotBefore = jwt.NewNumericDate(now.Add(time.Minute)) }, false},
+		{"Expired within skew", func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-10 * time.Second)) }, true},
+		{"Expired beyond skew", func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(now.Add(-time.Minute)) }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := valid
+			tt.mutate(&claims)
+
+			_, err := j.ParseToken(sign(claims))
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func pkcs8PEM(t *testing.T, key interface{}) []byte {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	assert.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
+
+func TestJWT_AsymmetricKeys(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.NoError(t, err)
+
+	ks := NewKeySet()
+	assert.NoError(t, ks.AddHMAC("hs", oldSecret))
+	assert.NoError(t, ks.AddPrivateKeyPEM("rsa", pkcs8PEM(t, rsaKey)))
+	assert.NoError(t, ks.AddPrivateKeyPEM("ed", pkcs8PEM(t, edKey)))
+
+	for _, kid := range []string{"rsa", "ed"} {
+		t.Run(kid, func(t *testing.T) {
+			j, err := NewJWTWithKeySet(ks, kid, Options{}, logger)
+			assert.NoError(t, err)
+
+			token, err := j.GenerateToken(7, "carol", 0)
+			assert.NoError(t, err)
+			claims, err := j.ParseToken(token)
+			assert.NoError(t, err)
+			assert.Equal(t, "carol", claims.Username)
+		})
+	}
+
+	t.Run("JWKS publishes only public keys", func(t *testing.T) {
+		j, err := NewJWTWithKeySet(ks, "rsa", Options{}, logger)
+		assert.NoError(t, err)
+
+		set := j.JWKS()
+		assert.Len(t, set.Keys, 2)
+		assert.Equal(t, JWK{Kty: "OKP", Kid: "ed", Use: "sig", Alg: "EdDSA", Crv: "Ed25519",
+			X: base64.RawURLEncoding.EncodeToString(edKey.Public().(ed25519.PublicKey))}, set.Keys[0])
+		assert.Equal(t, "RSA", set.Keys[1].Kty)
+		assert.Equal(t, "RS256", set.Keys[1].Alg)
+		assert.Equal(t, "AQAB", set.Keys[1].E)
+	})
+
+	t.Run("Algorithm confusion is rejected", func(t *testing.T) {
+		j, err := NewJWTWithKeySet(ks, "rsa", Options{}, logger)
+		assert.NoError(t, err)
+
+		forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "1", "username": "mallory"})
+		forged.Header["kid"] = "rsa"
+		tokenString, err := forged.SignedString(x509.MarshalPKCS1PublicKey(&rsaKey.PublicKey))
+		assert.NoError(t, err)
+
+		_, err = j.ParseToken(tokenString)
+		assert.Error(t, err)
+	})
+
+	t.Run("Weak RSA key", func(t *testing.T) {
+		weak, err := rsa.GenerateKey(rand.Reader, 1024)
+		assert.NoError(t, err)
+		assert.True(t, errors.Is(NewKeySet().AddPrivateKeyPEM("weak", pkcs8PEM(t, weak)), ErrWeakSecret))
+	})
+}
@@ -0,0 +1,103 @@
+package jwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"github.com/golang-jwt/jwt/v5"
+	"os"
+	"sort"
+)
+
+const minRSAKeyBits = 2048
+
+type signingKey struct {
+	method jwt.SigningMethod
+	sign   interface{}
+	verify jwt.VerificationKey
+	public crypto.PublicKey
+}
+
+// KeySet holds every key a JWT accepts, indexed by kid.
+type KeySet struct {
+	keys map[string]signingKey
+}
+
+func NewKeySet() *KeySet {
+	return &KeySet{keys: make(map[string]signingKey)}
+}
+
+// AddHMAC registers an HS256 secret. The secret has to pass ValidateSecret.
+func (ks *KeySet) AddHMAC(kid, secret string) error {
+	if err := ValidateSecret([]byte(secret)); err != nil {
+		return fmt.Errorf("key %q: %w", kid, err)
+	}
+	return ks.add(kid, signingKey{method: jwt.SigningMethodHS256, sign: []byte(secret), verify: []byte(secret)})
+}
+
+// AddPrivateKeyFile registers an RSA (RS256) or Ed25519 (EdDSA) private key stored as PEM.
+func (ks *KeySet) AddPrivateKeyFile(kid, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("key %q: %w", kid, err)
+	}
+	return ks.AddPrivateKeyPEM(kid, data)
+}
+
+// AddPrivateKeyPEM accepts PKCS#8 ("PRIVATE KEY") and PKCS#1 ("RSA PRIVATE KEY") blocks.
+func (ks *KeySet) AddPrivateKeyPEM(kid string, data []byte) error {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("key %q: no PEM block found", kid)
+	}
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	default:
+		return fmt.Errorf("key %q: unsupported PEM block %q", kid, block.Type)
+	}
+	if err != nil {
+		return fmt.Errorf("key %q: %w", kid, err)
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		if key.N.BitLen() < minRSAKeyBits {
+			return fmt.Errorf("key %q: %w: RSA key must be at least %d bits", kid, ErrWeakSecret, minRSAKeyBits)
+		}
+		return ks.add(kid, signingKey{method: jwt.SigningMethodRS256, sign: key, verify: &key.PublicKey, public: &key.PublicKey})
+	case ed25519.PrivateKey:
+		public := key.Public().(ed25519.PublicKey)
+		return ks.add(kid, signingKey{method: jwt.SigningMethodEdDSA, sign: key, verify: public, public: public})
+	default:
+		return fmt.Errorf("key %q: unsupported private key type %T", kid, parsed)
+	}
+}
+
+func (ks *KeySet) add(kid string, key signingKey) error {
+	if kid == "" {
+		return errors.New("signing key id must not be empty")
+	}
+	if _, ok := ks.keys[kid]; ok {
+		return fmt.Errorf("signing key %q is configured twice", kid)
+	}
+	ks.keys[kid] = key
+	return nil
+}
+
+func (ks *KeySet) sortedIDs() []string {
+	kids := make([]string, 0, len(ks.keys))
+	for kid := range ks.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	return kids
+}
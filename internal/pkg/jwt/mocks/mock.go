@@ -5,9 +5,9 @@
 package mock_jwt
 
 import (
+	jwt "Merch_store-Avito_test_task/internal/pkg/jwt"
 	reflect "reflect"
 
-	jwt "github.com/dgrijalva/jwt-go"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -35,25 +35,25 @@ func (m *MockJWTInterface) EXPECT() *MockJWTInterfaceMockRecorder {
 }
 
 // GenerateToken mocks base method.
-func (m *MockJWTInterface) GenerateToken(userID uint, username string) (string, error) {
+func (m *MockJWTInterface) GenerateToken(userID uint, username string, tokenVersion int) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GenerateToken", userID, username)
+	ret := m.ctrl.Call(m, "GenerateToken", userID, username, tokenVersion)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GenerateToken indicates an expected call of GenerateToken.
-func (mr *MockJWTInterfaceMockRecorder) GenerateToken(userID, username interface{}) *gomock.Call {
+func (mr *MockJWTInterfaceMockRecorder) GenerateToken(userID, username, tokenVersion interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockJWTInterface)(nil).GenerateToken), userID, username)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GenerateToken", reflect.TypeOf((*MockJWTInterface)(nil).GenerateToken), userID, username, tokenVersion)
 }
 
 // ParseToken mocks base method.
-func (m *MockJWTInterface) ParseToken(tokenString string) (jwt.MapClaims, error) {
+func (m *MockJWTInterface) ParseToken(tokenString string) (*jwt.Claims, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "ParseToken", tokenString)
-	ret0, _ := ret[0].(jwt.MapClaims)
+	ret0, _ := ret[0].(*jwt.Claims)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
@@ -63,3 +63,40 @@ func (mr *MockJWTInterfaceMockRecorder) ParseToken(tokenString interface{}) *gom
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseToken", reflect.TypeOf((*MockJWTInterface)(nil).ParseToken), tokenString)
 }
+
+// MockJWKSProvider is a mock of JWKSProvider interface.
+type MockJWKSProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockJWKSProviderMockRecorder
+}
+
+// MockJWKSProviderMockRecorder is the mock recorder for MockJWKSProvider.
+type MockJWKSProviderMockRecorder struct {
+	mock *MockJWKSProvider
+}
+
+// NewMockJWKSProvider creates a new mock instance.
+func NewMockJWKSProvider(ctrl *gomock.Controller) *MockJWKSProvider {
+	mock := &MockJWKSProvider{ctrl: ctrl}
+	mock.recorder = &MockJWKSProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJWKSProvider) EXPECT() *MockJWKSProviderMockRecorder {
+	return m.recorder
+}
+
+// JWKS mocks base method.
+func (m *MockJWKSProvider) JWKS() jwt.JWKS {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JWKS")
+	ret0, _ := ret[0].(jwt.JWKS)
+	return ret0
+}
+
+// JWKS indicates an expected call of JWKS.
+func (mr *MockJWKSProviderMockRecorder) JWKS() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JWKS", reflect.TypeOf((*MockJWKSProvider)(nil).JWKS))
+}
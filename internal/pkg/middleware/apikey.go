@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"slices"
+)
+
+// APIKeyHeader carries service-to-service credentials.
+const APIKeyHeader = "X-API-Key"
+
+// ScopesKey holds the scopes granted to the caller.
+const ScopesKey ContextKey = "scopes"
+
+var (
+	errAPIKeyInvalid = httpresponses.NewError(http.StatusUnauthorized, httpresponses.CodeInvalidAPIKey, "api key is invalid")
+	errForbidden     = httpresponses.NewError(http.StatusForbidden, httpresponses.CodeForbidden, "missing required scope")
+)
+
+// APIKeyAuthenticator resolves a plaintext API key.
+type APIKeyAuthenticator interface {
+	Authenticate(ctx context.Context, key string) (models.APIKey, error)
+}
+
+// APIKeyMiddleware authenticates the request by its X-API-Key header and fills the same context keys
+// as AuthMiddleware, acting as the key's owner, plus ScopesKey with the key's scopes.
+func APIKeyMiddleware(keys APIKeyAuthenticator, next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		key, err := keys.Authenticate(ctx, r.Header.Get(APIKeyHeader))
+		if err != nil {
+			if !errors.Is(err, models.ErrNotFound) {
+				logger.ErrorContext(ctx, "Failed to check api key", slog.Any("error", err))
+				httpresponses.SendError(w, r, err, logger)
+				return
+			}
+			logger.WarnContext(ctx, "api key is invalid")
+			httpresponses.SendError(w, r, errAPIKeyInvalid, logger)
+			return
+		}
+
+		logger.Debug("API key accepted", slog.Int("keyID", int(key.ID)), slog.Int("userID", int(key.UserID)))
+		ctx = context.WithValue(ctx, IdKey, key.UserID)
+		ctx = context.WithValue(ctx, UsernameKey, key.Username)
+		ctx = context.WithValue(ctx, ScopesKey, key.Scopes)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// AdminScopesMiddleware grants every scope to the listed users, so admins can work with their own token
+// and issue the first API keys. It has to run inside AuthMiddleware.
+func AdminScopesMiddleware(admins []string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, _ := r.Context().Value(UsernameKey).(string)
+		if username != "" && slices.Contains(admins, username) {
+			r = r.WithContext(context.WithValue(r.Context(), ScopesKey, models.Scopes))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RoleSource returns the roles a user holds.
+type RoleSource interface {
+	Roles(ctx context.Context, userID uint) ([]string, error)
+}
+
+// RoleScopesMiddleware adds the scopes of the token user's roles to ScopesKey. It costs a lookup per
+// request, so it is meant for scoped routes only, and has to run inside AuthMiddleware.
+func RoleScopesMiddleware(source RoleSource, next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		userID, _ := ctx.Value(IdKey).(uint)
+		roles, err := source.Roles(ctx, userID)
+		if err != nil {
+			logger.ErrorContext(ctx, "Failed to load user roles", slog.Any("error", err))
+			httpresponses.SendError(w, r, err, logger)
+			return
+		}
+		if len(roles) > 0 {
+			scopes, _ := ctx.Value(ScopesKey).([]string)
+			scopes = slices.Clone(scopes)
+			for _, scope := range models.RoleScopes(roles) {
+				if !slices.Contains(scopes, scope) {
+					scopes = append(scopes, scope)
+				}
+			}
+			ctx = context.WithValue(ctx, ScopesKey, scopes)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// HasScope reports whether the caller was granted scope.
+func HasScope(ctx context.Context, scope string) bool {
+	scopes, _ := ctx.Value(ScopesKey).([]string)
+	return slices.Contains(scopes, scope)
+}
+
+// RequireScopeMiddleware rejects callers lacking scope with 403.
+func RequireScopeMiddleware(scope string, next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !HasScope(r.Context(), scope) {
+			logger.WarnContext(r.Context(), "missing required scope", slog.String("scope", scope), slog.String("path", r.URL.Path))
+			httpresponses.SendError(w, r, errForbidden.WithDetails(scope), logger)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type apiKeys map[string]models.APIKey
+
+func (k apiKeys) Authenticate(_ context.Context, key string) (models.APIKey, error) {
+	if key == "broken" {
+		return models.APIKey{}, errors.New("pq: connection refused")
+	}
+	found, ok := k[key]
+	if !ok {
+		return models.APIKey{}, models.ErrNotFound
+	}
+	return found, nil
+}
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	keys := apiKeys{"msk_good": {ID: 7, UserID: 3, Username: "billing-bot", Scopes: []string{models.ScopeUsersRead}}}
+
+	var ctx context.Context
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx = r.Context()
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := APIKeyMiddleware(keys, next, logger)
+
+	tests := []struct {
+		name           string
+		key            string
+		expectedStatus int
+	}{
+		{name: "Valid key", key: "msk_good", expectedStatus: http.StatusNoContent},
+		{name: "Unknown key", key: "msk_bad", expectedStatus: http.StatusUnauthorized},
+		{name: "Lookup failure", key: "broken", expectedStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx = nil
+			req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+			req.Header.Set(APIKeyHeader, tt.key)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus != http.StatusNoContent {
+				assert.Nil(t, ctx)
+			}
+		})
+	}
+
+	t.Run("Fills the same context as AuthMiddleware", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+		req.Header.Set(APIKeyHeader, "msk_good")
+
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		assert.Equal(t, uint(3), ctx.Value(IdKey))
+		assert.Equal(t, "billing-bot", ctx.Value(UsernameKey))
+		assert.True(t, HasScope(ctx, models.ScopeUsersRead))
+		assert.False(t, HasScope(ctx, models.ScopeKeysAdmin))
+	})
+}
+
+func TestRequireScopeMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := AdminScopesMiddleware([]string{"root"}, RequireScopeMiddleware(models.ScopeKeysAdmin, next, logger))
+
+	tests := []struct {
+		name           string
+		ctx            context.Context
+		expectedStatus int
+	}{
+		{
+			name:           "Key with the scope",
+			ctx:            context.WithValue(context.Background(), ScopesKey, []string{models.ScopeKeysAdmin}),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Key without the scope",
+			ctx:            context.WithValue(context.Background(), ScopesKey, []string{models.ScopeCatalogWrite}),
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "Admin user token",
+			ctx:            context.WithValue(context.Background(), UsernameKey, "root"),
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:           "Regular user token",
+			ctx:            context.WithValue(context.Background(), UsernameKey, "alice"),
+			expectedStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/admin/keys", nil).WithContext(tt.ctx)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.Contains(t, rr.Body.String(), `"code":"forbidden"`)
+			}
+		})
+	}
+}
+
+type userRoles map[uint][]string
+
+func (s userRoles) Roles(_ context.Context, userID uint) ([]string, error) {
+	if userID == 0 {
+		return nil, errors.New("pq: connection refused")
+	}
+	return s[userID], nil
+}
+
+func TestRoleScopesMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := RoleScopesMiddleware(userRoles{1: {models.RoleHR}, 2: nil},
+		RequireScopeMiddleware(models.ScopeUsersWrite, next, logger), logger)
+
+	tests := []struct {
+		name           string
+		userID         uint
+		expectedStatus int
+	}{
+		{name: "HR user", userID: 1, expectedStatus: http.StatusNoContent},
+		{name: "User without roles", userID: 2, expectedStatus: http.StatusForbidden},
+		{name: "Lookup failure", userID: 0, expectedStatus: http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/admin/users/import", nil)
+			req = req.WithContext(context.WithValue(req.Context(), IdKey, tt.userID))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
@@ -3,55 +3,111 @@ package middleware
 import (
 	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
 	"Merch_store-Avito_test_task/internal/pkg/jwt"
+	"Merch_store-Avito_test_task/internal/pkg/requestid"
 	"context"
 	"log/slog"
+	"net"
 	"net/http"
 )
 
 type ContextKey string
 
 const (
-	IdKey       ContextKey = "userID"
-	UsernameKey ContextKey = "username"
+	IdKey           ContextKey = "userID"
+	UsernameKey     ContextKey = "username"
+	TokenVersionKey ContextKey = "tokenVersion"
 )
 
+// UserID returns the ID of the user AuthMiddleware or APIKeyMiddleware authenticated the request as.
+func UserID(ctx context.Context) (uint, bool) {
+	userID, ok := ctx.Value(IdKey).(uint)
+	return userID, ok
+}
+
+var (
+	errTokenMissing = httpresponses.NewError(http.StatusUnauthorized, httpresponses.CodeUnauthorized, "token is missing")
+	errTokenInvalid = httpresponses.NewError(http.StatusUnauthorized, httpresponses.CodeInvalidToken, "token is invalid")
+	errTokenRevoked = httpresponses.NewError(http.StatusUnauthorized, httpresponses.CodeTokenRevoked, "token is revoked")
+)
+
+// TokenVersionSource returns the current token version of a user.
+type TokenVersionSource interface {
+	TokenVersion(ctx context.Context, userID uint) (int, error)
+}
+
 func AuthMiddleware(jwtService jwt.JWTInterface, next http.Handler, logger *slog.Logger) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		token := r.Header.Get("Access-Token")
 		if token == "" {
-			response := httpresponses.Response{
-				Message: "token is missing",
-			}
 			logger.Error("token is missing")
-			httpresponses.SendJSONResponse(r.Context(), w, response, http.StatusUnauthorized, logger)
+			httpresponses.SendError(w, r, errTokenMissing, logger)
 			return
 		}
 		claims, err := jwtService.ParseToken(token)
 		if err != nil || claims == nil {
-			response := httpresponses.Response{
-				Message: "token is invalid",
-			}
-			logger.Error("token is invalid", slog.Any("error", err.Error()))
-			httpresponses.SendJSONResponse(r.Context(), w, response, http.StatusUnauthorized, logger)
+			logger.Error("token is invalid", slog.Any("error", err))
+			httpresponses.SendError(w, r, errTokenInvalid, logger)
 			return
 		}
-		userIDFloat, ok1 := claims["userID"].(float64)
-		username, ok2 := claims["username"].(string)
-		if !ok1 || !ok2 {
-			logger.Error("Invalid token claims")
-			response := httpresponses.Response{
-				Message: "Invalid token claims",
-			}
-			httpresponses.SendJSONResponse(r.Context(), w, response, http.StatusUnauthorized, logger)
+		userID, err := claims.UserID()
+		if err != nil {
+			logger.Error("Invalid token claims", slog.String("error", err.Error()))
+			httpresponses.SendError(w, r, errTokenInvalid, logger)
 			return
 		}
-		userID := uint(userIDFloat)
+		username := claims.Username
 
 		logger.Debug("Token parsed", slog.Int("userID", int(userID)), slog.String("username", username))
 		ctx := context.WithValue(r.Context(), IdKey, userID)
 		ctx = context.WithValue(ctx, UsernameKey, username)
+		ctx = context.WithValue(ctx, TokenVersionKey, claims.TokenVersion)
 
 		r = r.WithContext(ctx)
 		next.ServeHTTP(w, r)
 	})
 }
+
+// RevocationMiddleware rejects tokens issued before the user's last password change.
+// It has to run inside AuthMiddleware.
+func RevocationMiddleware(source TokenVersionSource, next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, _ := r.Context().Value(IdKey).(uint)
+		tokenVersion, _ := r.Context().Value(TokenVersionKey).(int)
+
+		current, err := source.TokenVersion(r.Context(), userID)
+		if err != nil {
+			logger.Error("Failed to check token version", slog.Any("error", err))
+			httpresponses.SendError(w, r, errTokenInvalid, logger)
+			return
+		}
+		if current != tokenVersion {
+			logger.Warn("token is revoked", slog.Int("userID", int(userID)))
+			httpresponses.SendError(w, r, errTokenRevoked, logger)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// RequestIDMiddleware tags every request with an id, reusing a well-formed X-Request-ID from the
+// client, and echoes it in the response so error reports can be matched with the logs.
+func RequestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithContext(r.Context(), id)))
+	})
+}
+
+// ClientIP returns the host part of the connection's remote address. Forwarded headers are ignored
+// on purpose, they are client-controlled unless a trusted proxy rewrites them.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
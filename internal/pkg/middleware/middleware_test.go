@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/pkg/requestid"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type versionSource map[uint]int
+
+func (s versionSource) TokenVersion(_ context.Context, userID uint) (int, error) {
+	version, ok := s[userID]
+	if !ok {
+		return 0, errors.New("not found")
+	}
+	return version, nil
+}
+
+func TestUserID(t *testing.T) {
+	userID, ok := UserID(context.WithValue(context.Background(), IdKey, uint(7)))
+	assert.True(t, ok)
+	assert.Equal(t, uint(7), userID)
+
+	_, ok = UserID(context.Background())
+	assert.False(t, ok)
+}
+
+func TestRevocationMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	handler := RevocationMiddleware(versionSource{1: 2}, next, logger)
+
+	tests := []struct {
+		name           string
+		userID         uint
+		tokenVersion   int
+		expectedStatus int
+	}{
+		{name: "Current token", userID: 1, tokenVersion: 2, expectedStatus: http.StatusNoContent},
+		{name: "Token issued before password change", userID: 1, tokenVersion: 1, expectedStatus: http.StatusUnauthorized},
+		{name: "Unknown user", userID: 7, tokenVersion: 0, expectedStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+			ctx := context.WithValue(req.Context(), IdKey, tt.userID)
+			ctx = context.WithValue(ctx, TokenVersionKey, tt.tokenVersion)
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req.WithContext(ctx))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
+
+func TestRequestIDMiddleware(t *testing.T) {
+	var seen string
+	handler := RequestIDMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = requestid.FromContext(r.Context())
+	}))
+
+	t.Run("Client id is reused", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+		req.Header.Set(requestid.Header, "abc-123")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Equal(t, "abc-123", seen)
+		assert.Equal(t, "abc-123", rr.Header().Get(requestid.Header))
+	})
+
+	t.Run("Malformed id is replaced", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+		req.Header.Set(requestid.Header, "bad id\n")
+		rr := httptest.NewRecorder()
+
+		handler.ServeHTTP(rr, req)
+
+		assert.Len(t, seen, 32)
+		assert.Equal(t, seen, rr.Header().Get(requestid.Header))
+	})
+}
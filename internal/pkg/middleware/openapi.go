@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
+	"bytes"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// OpenAPIValidator checks traffic against the API document. Responses are buffered in full, so it is
+// meant for tests and staging rather than production.
+type OpenAPIValidator struct {
+	router  routers.Router
+	options *openapi3filter.Options
+	report  func(r *http.Request, err error)
+}
+
+// NewOpenAPIValidator creates a validator that passes every violation to report, e.g. t.Errorf in tests.
+func NewOpenAPIValidator(spec *openapi3.T, report func(r *http.Request, err error)) (*OpenAPIValidator, error) {
+	router, err := gorillamux.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build openapi router: %w", err)
+	}
+	options := &openapi3filter.Options{
+		// Tokens are checked by AuthMiddleware; the validator only checks the shape of the traffic.
+		AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+		IncludeResponseStatus: true,
+		MultiError:            true,
+	}
+	return &OpenAPIValidator{router: router, options: options, report: report}, nil
+}
+
+// OpenAPIValidationMiddleware rejects requests that violate the document with 400 and reports responses
+// that violate it. Undocumented routes are reported unless the router answers them with 404 or 405.
+func OpenAPIValidationMiddleware(validator *OpenAPIValidator, next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := validator.router.FindRoute(r)
+		if err != nil {
+			rec := newResponseRecorder()
+			next.ServeHTTP(rec, r)
+			if rec.status != http.StatusNotFound && rec.status != http.StatusMethodNotAllowed {
+				validator.report(r, fmt.Errorf("%s %s is not documented: %w", r.Method, r.URL.Path, err))
+			}
+			rec.flush(w, r, logger)
+			return
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    validator.options,
+		}
+		if err = openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			validator.report(r, fmt.Errorf("request violates the openapi document: %w", err))
+			apiErr := httpresponses.NewError(http.StatusBadRequest, httpresponses.CodeInvalidRequest, "request does not match the API specification")
+			httpresponses.SendError(w, r, apiErr.WithDetails(err.Error()), logger)
+			return
+		}
+
+		rec := newResponseRecorder()
+		next.ServeHTTP(rec, r)
+		err = openapi3filter.ValidateResponse(r.Context(), &openapi3filter.ResponseValidationInput{
+			RequestValidationInput: input,
+			Status:                 rec.status,
+			Header:                 rec.header,
+			Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+			Options:                validator.options,
+		})
+		if err != nil {
+			validator.report(r, fmt.Errorf("%d response of %s %s violates the openapi document: %w", rec.status, r.Method, r.URL.Path, err))
+		}
+		rec.flush(w, r, logger)
+	})
+}
+
+// responseRecorder buffers a response so it can be validated before it is sent.
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{header: make(http.Header)}
+}
+
+func (rec *responseRecorder) Header() http.Header {
+	return rec.header
+}
+
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
+
+func (rec *responseRecorder) flush(w http.ResponseWriter, r *http.Request, logger *slog.Logger) {
+	for key, values := range rec.header {
+		w.Header()[key] = values
+	}
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	w.WriteHeader(rec.status)
+	if _, err := w.Write(rec.body.Bytes()); err != nil {
+		logger.WarnContext(r.Context(), "Failed to write response", slog.String("error", err.Error()))
+	}
+}
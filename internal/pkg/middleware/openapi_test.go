@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
+	"Merch_store-Avito_test_task/internal/pkg/openapi"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIValidationMiddleware(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	spec, err := openapi.Load()
+	assert.NoError(t, err)
+
+	var violations []error
+	validator, err := NewOpenAPIValidator(spec, func(r *http.Request, err error) {
+		violations = append(violations, err)
+	})
+	assert.NoError(t, err)
+
+	var infoBody any
+	router := mux.NewRouter()
+	router.HandleFunc("/api/info", func(w http.ResponseWriter, r *http.Request) {
+		httpresponses.SendJSONResponse(r.Context(), w, infoBody, http.StatusOK, logger)
+	}).Methods(http.MethodGet)
+	router.HandleFunc("/api/sendCoin", func(w http.ResponseWriter, r *http.Request) {
+		httpresponses.SendJSONResponse(r.Context(), w, struct{}{}, http.StatusOK, logger)
+	}).Methods(http.MethodPost)
+	router.HandleFunc("/api/undocumented", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := OpenAPIValidationMiddleware(validator, router, logger)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		violations = nil
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Access-Token", "token")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Valid traffic", func(t *testing.T) {
+		infoBody = map[string]any{"coins": 1000, "inventory": []any{}, "coinHistory": map[string]any{"received": []any{}, "sent": []any{}}}
+		rr := serve(http.MethodGet, "/api/info", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Empty(t, violations)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	})
+
+	t.Run("Response drift is reported and passed through", func(t *testing.T) {
+		infoBody = map[string]any{"coins": 1000, "inventory": nil}
+		rr := serve(http.MethodGet, "/api/info", "")
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Len(t, violations, 1)
+	})
+
+	t.Run("Invalid request is rejected", func(t *testing.T) {
+		rr := serve(http.MethodPost, "/api/sendCoin", `{"toUser": "bob", "amount": "ten"}`)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+		assert.Len(t, violations, 1)
+		var body map[string]any
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+		assert.Equal(t, httpresponses.CodeInvalidRequest, body["code"])
+	})
+
+	t.Run("Undocumented route is reported", func(t *testing.T) {
+		serve(http.MethodGet, "/api/undocumented", "")
+		assert.Len(t, violations, 1)
+
+		rr := serve(http.MethodGet, "/api/missing", "")
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.Empty(t, violations)
+	})
+}
@@ -0,0 +1,125 @@
+package middleware
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// sweepThreshold bounds the bucket map; full buckets carry no state and can be dropped.
+const sweepThreshold = 10000
+
+var errTooManyRequests = httpresponses.NewError(http.StatusTooManyRequests, httpresponses.CodeTooManyRequests, "too many requests")
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// RateLimiter is a token bucket limiter: every key may burst up to capacity requests, refilled
+// at Requests per Period.
+type RateLimiter struct {
+	mu       sync.Mutex
+	buckets  map[string]*bucket
+	capacity float64
+	rate     float64 // tokens per second
+	policy   string
+	now      func() time.Time
+}
+
+// NewRateLimiter creates a limiter for rule; now may be nil to use the wall clock.
+func NewRateLimiter(rule config.RateLimitRule, now func() time.Time) *RateLimiter {
+	if now == nil {
+		now = time.Now
+	}
+	capacity := rule.Burst
+	if capacity <= 0 {
+		capacity = rule.Requests
+	}
+	return &RateLimiter{
+		buckets:  make(map[string]*bucket),
+		capacity: float64(capacity),
+		rate:     float64(rule.Requests) / rule.Period.Seconds(),
+		policy:   fmt.Sprintf("%d;w=%d", rule.Requests, int(rule.Period.Seconds())),
+		now:      now,
+	}
+}
+
+type rateLimitResult struct {
+	allowed    bool
+	remaining  int
+	reset      time.Duration
+	retryAfter time.Duration
+}
+
+func (l *RateLimiter) take(key string) rateLimitResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if len(l.buckets) >= sweepThreshold {
+		for k, b := range l.buckets {
+			if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.capacity {
+				delete(l.buckets, k)
+			}
+		}
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.capacity, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.capacity, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	result := rateLimitResult{allowed: b.tokens >= 1}
+	if result.allowed {
+		b.tokens--
+	} else {
+		result.retryAfter = l.secondsToDuration((1 - b.tokens) / l.rate)
+	}
+	result.remaining = int(b.tokens)
+	result.reset = l.secondsToDuration((l.capacity - b.tokens) / l.rate)
+	return result
+}
+
+func (l *RateLimiter) secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+func ceilSeconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
+
+// RateLimitMiddleware limits requests per authenticated user (IdKey) or, for anonymous requests,
+// per client IP. Mounted inside AuthMiddleware it limits users; mounted outside it limits clients
+// before their credentials are checked.
+func RateLimitMiddleware(limiter *RateLimiter, next http.Handler, logger *slog.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := "ip:" + ClientIP(r)
+		if userID, ok := r.Context().Value(IdKey).(uint); ok {
+			key = "user:" + strconv.FormatUint(uint64(userID), 10)
+		}
+
+		result := limiter.take(key)
+		w.Header().Set("RateLimit-Policy", limiter.policy)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(int(limiter.capacity)))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.remaining))
+		w.Header().Set("RateLimit-Reset", ceilSeconds(result.reset))
+
+		if !result.allowed {
+			logger.WarnContext(r.Context(), "rate limit exceeded", slog.String("key", key), slog.String("path", r.URL.Path))
+			w.Header().Set("Retry-After", ceilSeconds(result.retryAfter))
+			httpresponses.SendError(w, r, errTooManyRequests, logger)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/pkg/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)}
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	limiter := NewRateLimiter(config.RateLimitRule{Requests: 2, Period: 10 * time.Second, Burst: 3}, clock.Now)
+	handler := RateLimitMiddleware(limiter, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), logger)
+
+	send := func(userID uint, remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/info", nil)
+		req.RemoteAddr = remoteAddr
+		if userID != 0 {
+			req = req.WithContext(context.WithValue(req.Context(), IdKey, userID))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("Burst then reject", func(t *testing.T) {
+		for _, remaining := range []string{"2", "1", "0"} {
+			rr := send(1, "192.0.2.1:1000")
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "3", rr.Header().Get("RateLimit-Limit"))
+			assert.Equal(t, remaining, rr.Header().Get("RateLimit-Remaining"))
+			assert.Equal(t, "2;w=10", rr.Header().Get("RateLimit-Policy"))
+		}
+
+		rr := send(1, "192.0.2.1:1000")
+		assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+		assert.Equal(t, "5", rr.Header().Get("Retry-After"))
+		assert.Equal(t, "15", rr.Header().Get("RateLimit-Reset"))
+	})
+
+	t.Run("Other users and anonymous IPs have their own buckets", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, send(2, "192.0.2.1:1000").Code)
+		assert.Equal(t, http.StatusOK, send(0, "192.0.2.1:1000").Code)
+	})
+
+	t.Run("Refill over time", func(t *testing.T) {
+		clock.now = clock.now.Add(5 * time.Second)
+		rr := send(1, "192.0.2.1:1000")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "0", rr.Header().Get("RateLimit-Remaining"))
+
+		assert.Equal(t, http.StatusTooManyRequests, send(1, "192.0.2.1:1000").Code)
+
+		clock.now = clock.now.Add(time.Minute)
+		rr = send(1, "192.0.2.1:1000")
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "2", rr.Header().Get("RateLimit-Remaining"))
+	})
+}
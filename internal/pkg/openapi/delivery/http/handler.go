@@ -0,0 +1,56 @@
+package http
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/openapi"
+	"encoding/json"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"log/slog"
+	"net/http"
+)
+
+type DocsHandler struct {
+	document []byte
+	page     []byte
+	script   []byte
+	logger   *slog.Logger
+}
+
+// NewDocsHandler renders spec once; the document is served as is for the lifetime of the process.
+func NewDocsHandler(spec *openapi3.T, logger *slog.Logger) (*DocsHandler, error) {
+	document, err := json.Marshal(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode openapi document: %w", err)
+	}
+	page, err := openapi.Docs.ReadFile("docs/index.html")
+	if err != nil {
+		return nil, err
+	}
+	script, err := openapi.Docs.ReadFile("docs/docs.js")
+	if err != nil {
+		return nil, err
+	}
+	return &DocsHandler{document: document, page: page, script: script, logger: logger}, nil
+}
+
+func (h *DocsHandler) GetOpenAPI(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, "application/json", h.document)
+}
+
+func (h *DocsHandler) GetDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'self' 'unsafe-inline'")
+	h.write(w, r, "text/html; charset=utf-8", h.page)
+}
+
+func (h *DocsHandler) GetDocsScript(w http.ResponseWriter, r *http.Request) {
+	h.write(w, r, "text/javascript; charset=utf-8", h.script)
+}
+
+func (h *DocsHandler) write(w http.ResponseWriter, r *http.Request, contentType string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if _, err := w.Write(body); err != nil {
+		h.logger.WarnContext(r.Context(), "Failed to write docs response", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,42 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/pkg/openapi"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocsHandler(t *testing.T) {
+	spec, err := openapi.Load()
+	assert.NoError(t, err)
+	handler, err := NewDocsHandler(spec, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	assert.NoError(t, err)
+
+	t.Run("OpenAPI document", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.GetOpenAPI(rr, httptest.NewRequest(http.MethodGet, "/api/openapi.json", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+		var document map[string]any
+		assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &document))
+		assert.Equal(t, "3.0.3", document["openapi"])
+	})
+
+	t.Run("Docs page", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		handler.GetDocs(rr, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Equal(t, "text/html; charset=utf-8", rr.Header().Get("Content-Type"))
+		assert.Contains(t, rr.Body.String(), `<script src="/api/docs/docs.js"></script>`)
+		assert.NotEmpty(t, rr.Header().Get("Content-Security-Policy"))
+	})
+}
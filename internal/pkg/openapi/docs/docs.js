@@ -0,0 +1,63 @@
+// Renders /api/openapi.json without third-party assets, so the page works offline and under a strict CSP.
+(function () {
+  'use strict';
+
+  function el(tag, text, className) {
+    var node = document.createElement(tag);
+    if (text !== undefined) node.textContent = text;
+    if (className) node.className = className;
+    return node;
+  }
+
+  function resolve(spec, value) {
+    while (value && value.$ref) {
+      value = value.$ref.replace(/^#\//, '').split('/').reduce(function (obj, key) {
+        return obj[key];
+      }, spec);
+    }
+    return value;
+  }
+
+  function schemaBlock(spec, content) {
+    var block = el('div');
+    Object.keys(content || {}).forEach(function (type) {
+      block.appendChild(el('div', type));
+      block.appendChild(el('pre', JSON.stringify(resolve(spec, content[type].schema), null, 2)));
+    });
+    return block;
+  }
+
+  function render(spec) {
+    document.getElementById('title').textContent = spec.info.title + ' ' + spec.info.version;
+    var root = document.getElementById('operations');
+    root.textContent = '';
+    Object.keys(spec.paths).sort().forEach(function (path) {
+      Object.keys(spec.paths[path]).forEach(function (method) {
+        var op = spec.paths[path][method];
+        var box = el('div', undefined, 'op');
+        var head = el('h3');
+        head.appendChild(el('span', method, 'method'));
+        head.appendChild(document.createTextNode(path));
+        box.appendChild(head);
+        box.appendChild(el('p', op.summary || ''));
+        if (op.requestBody) {
+          box.appendChild(el('h4', 'Request'));
+          box.appendChild(schemaBlock(spec, resolve(spec, op.requestBody).content));
+        }
+        Object.keys(op.responses || {}).forEach(function (status) {
+          var response = resolve(spec, op.responses[status]);
+          box.appendChild(el('h4', status + ' ' + (response.description || '')));
+          box.appendChild(schemaBlock(spec, response.content));
+        });
+        root.appendChild(box);
+      });
+    });
+  }
+
+  fetch('/api/openapi.json')
+    .then(function (res) { return res.json(); })
+    .then(render)
+    .catch(function (err) {
+      document.getElementById('operations').textContent = 'Failed to load the API document: ' + err;
+    });
+})();
@@ -0,0 +1,29 @@
+package openapi
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+//go:embed openapi.yaml
+var document []byte
+
+// Docs holds the documentation page: docs/index.html and the script rendering the document.
+//
+//go:embed docs
+var Docs embed.FS
+
+// Load parses the embedded document and checks that it is a valid OpenAPI 3 description.
+func Load() (*openapi3.T, error) {
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromData(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse openapi document: %w", err)
+	}
+	if err = spec.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid openapi document: %w", err)
+	}
+	return spec, nil
+}
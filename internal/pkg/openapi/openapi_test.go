@@ -0,0 +1,16 @@
+package openapi
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoad(t *testing.T) {
+	spec, err := Load()
+	assert.NoError(t, err)
+
+	for _, path := range []string{"/api/auth", "/api/auth/password", "/api/info", "/api/sendCoin", "/api/buy/{item}", "/api/admin/keys", "/api/admin/keys/{id}", "/api/admin/webhooks", "/api/admin/webhooks/{id}/deliveries/{delivery}/redeliver", "/api/admin/users/import", "/api/admin/catalog/sync", "/api/admin/metrics", "/.well-known/jwks.json"} {
+		assert.NotNil(t, spec.Paths.Find(path), path)
+	}
+}
@@ -0,0 +1,22 @@
+package outbox
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+	"time"
+)
+
+//go:generate mockgen -source=interfaces.go -destination=mocks/mock.go
+
+// Publisher delivers events downstream. Delivery is at least once, so consumers deduplicate by Event.ID.
+type Publisher interface {
+	Publish(ctx context.Context, event models.Event) error
+}
+
+type OutboxRepository interface {
+	// Claim returns up to limit due events and hides them from other relays for lease.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]models.Event, error)
+	MarkPublished(ctx context.Context, id int64) error
+	// MarkFailed records a failed attempt; the event is retried after retryIn or, if dead, never again.
+	MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration, dead bool) error
+}
@@ -0,0 +1,20 @@
+package publisher
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/outbox"
+	"context"
+)
+
+// Fanout passes every event to all publishers. If one fails the relay retries the event for all of
+// them, so each publisher has to tolerate duplicates.
+type Fanout []outbox.Publisher
+
+func (f Fanout) Publish(ctx context.Context, event models.Event) error {
+	for _, p := range f {
+		if err := p.Publish(ctx, event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package publisher
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+	"log/slog"
+)
+
+// LogPublisher writes every event to the log. It never fails.
+type LogPublisher struct {
+	logger *slog.Logger
+}
+
+func NewLogPublisher(logger *slog.Logger) *LogPublisher {
+	return &LogPublisher{logger: logger}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, event models.Event) error {
+	p.logger.InfoContext(ctx, "domain event",
+		slog.Int64("id", event.ID),
+		slog.String("type", event.Type),
+		slog.String("payload", string(event.Payload)),
+	)
+	return nil
+}
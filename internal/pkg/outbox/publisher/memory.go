@@ -0,0 +1,39 @@
+package publisher
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+	"slices"
+	"sync"
+)
+
+// MemoryPublisher keeps published events in memory, for tests and local runs. Setting Fail makes
+// Publish return its result instead, e.g. to exercise retries.
+type MemoryPublisher struct {
+	mu     sync.Mutex
+	events []models.Event
+	Fail   func(event models.Event) error
+}
+
+func NewMemoryPublisher() *MemoryPublisher {
+	return &MemoryPublisher{}
+}
+
+func (p *MemoryPublisher) Publish(_ context.Context, event models.Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.Fail != nil {
+		if err := p.Fail(event); err != nil {
+			return err
+		}
+	}
+	p.events = append(p.events, event)
+	return nil
+}
+
+// Events returns a copy of everything published so far.
+func (p *MemoryPublisher) Events() []models.Event {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return slices.Clone(p.events)
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/database"
+	"cmp"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+type OutboxRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewOutboxRepositoryImpl(db *sql.DB) *OutboxRepositoryImpl {
+	return &OutboxRepositoryImpl{db: db}
+}
+
+// Enqueue writes an event to the outbox within the transaction ctx carries, if any. Run it in the
+// transaction of the change the event describes, so the event is stored if and only if the change commits.
+func (repo *OutboxRepositoryImpl) Enqueue(ctx context.Context, eventType string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encoding %s event failed: %w", eventType, err)
+	}
+	query := `INSERT INTO "outbox_event" (event_type, payload) VALUES ($1, $2)`
+	if _, err = database.Conn(ctx, repo.db).ExecContext(ctx, query, eventType, data); err != nil {
+		return fmt.Errorf("inserting %s event failed: %w", eventType, err)
+	}
+	return nil
+}
+
+// Claim leases due events by pushing their next attempt into the future. SKIP LOCKED lets several
+// relays claim concurrently; an event whose relay dies is picked up again once the lease expires.
+func (repo *OutboxRepositoryImpl) Claim(ctx context.Context, limit int, lease time.Duration) ([]models.Event, error) {
+	query := `UPDATE "outbox_event" SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM "outbox_event" WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, payload, attempts, created_at`
+	rows, err := repo.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claiming outbox events failed: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.Event
+	for rows.Next() {
+		var event models.Event
+		var payload []byte
+		if err = rows.Scan(&event.ID, &event.Type, &payload, &event.Attempts, &event.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning outbox event failed: %w", err)
+		}
+		event.Payload = payload
+		events = append(events, event)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("claiming outbox events failed: %w", err)
+	}
+	// RETURNING does not keep the order of the subquery.
+	slices.SortFunc(events, func(a, b models.Event) int { return cmp.Compare(a.ID, b.ID) })
+	return events, nil
+}
+
+func (repo *OutboxRepositoryImpl) MarkPublished(ctx context.Context, id int64) error {
+	query := `UPDATE "outbox_event" SET status = 'published', published_at = NOW(), attempts = attempts + 1, last_error = NULL
+		WHERE id = $1`
+	if _, err := repo.db.ExecContext(ctx, query, id); err != nil {
+		return fmt.Errorf("marking outbox event %d published failed: %w", id, err)
+	}
+	return nil
+}
+
+func (repo *OutboxRepositoryImpl) MarkFailed(ctx context.Context, id int64, reason string, retryIn time.Duration, dead bool) error {
+	status := "pending"
+	if dead {
+		status = "dead"
+	}
+	query := `UPDATE "outbox_event" SET status = $2, attempts = attempts + 1, last_error = $3,
+		next_attempt_at = NOW() + make_interval(secs => $4) WHERE id = $1`
+	if _, err := repo.db.ExecContext(ctx, query, id, status, reason, retryIn.Seconds()); err != nil {
+		return fmt.Errorf("marking outbox event %d failed: %w", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/database"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutboxRepository_Enqueue(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectExec(`INSERT INTO "outbox_event" \(event_type, payload\) VALUES \(\$1, \$2\)`).
+		WithArgs(models.EventItemPurchased, []byte(`{"userId":1,"user":"alice","productId":2,"product":"cup","price":20}`)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = NewOutboxRepositoryImpl(db).Enqueue(context.Background(), models.EventItemPurchased,
+		models.ItemPurchasedEvent{UserID: 1, Username: "alice", ProductID: 2, Product: "cup", Price: 20})
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository_EnqueueJoinsTransaction(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO "outbox_event"`).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectRollback()
+
+	repo := NewOutboxRepositoryImpl(db)
+	err = database.NewTxManager(db).WithinTx(context.Background(), func(ctx context.Context) error {
+		if err := repo.Enqueue(ctx, models.EventCoinsTransferred, models.CoinsTransferredEvent{}); err != nil {
+			return err
+		}
+		return models.ErrNotEnough
+	})
+	assert.ErrorIs(t, err, models.ErrNotEnough)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestOutboxRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewOutboxRepositoryImpl(db)
+	ctx := context.Background()
+	created := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("Claim leases due events in id order", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE "outbox_event" SET next_attempt_at = NOW\(\) \+ make_interval\(secs => \$2\)\s+WHERE id IN \(\s+SELECT id FROM "outbox_event" WHERE status = 'pending' AND next_attempt_at <= NOW\(\)\s+ORDER BY id LIMIT \$1 FOR UPDATE SKIP LOCKED`).
+			WithArgs(50, 30.0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "payload", "attempts", "created_at"}).
+				AddRow(9, models.EventItemPurchased, []byte(`{}`), 0, created).
+				AddRow(4, models.EventCoinsTransferred, []byte(`{"amount":1}`), 2, created))
+
+		events, err := repo.Claim(ctx, 50, 30*time.Second)
+		assert.NoError(t, err)
+		assert.Len(t, events, 2)
+		assert.Equal(t, int64(4), events[0].ID)
+		assert.Equal(t, 2, events[0].Attempts)
+		assert.JSONEq(t, `{"amount":1}`, string(events[0].Payload))
+	})
+
+	t.Run("MarkPublished", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "outbox_event" SET status = 'published', published_at = NOW\(\)`).
+			WithArgs(4).WillReturnResult(sqlmock.NewResult(0, 1))
+		assert.NoError(t, repo.MarkPublished(ctx, 4))
+	})
+
+	t.Run("MarkFailed keeps the event pending", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "outbox_event" SET status = \$2, attempts = attempts \+ 1, last_error = \$3`).
+			WithArgs(4, "pending", "timeout", 2.0).WillReturnResult(sqlmock.NewResult(0, 1))
+		assert.NoError(t, repo.MarkFailed(ctx, 4, "timeout", 2*time.Second, false))
+	})
+
+	t.Run("MarkFailed dead letter", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "outbox_event" SET status = \$2`).
+			WithArgs(4, "dead", "timeout", 60.0).WillReturnResult(sqlmock.NewResult(0, 1))
+		assert.NoError(t, repo.MarkFailed(ctx, 4, "timeout", time.Minute, true))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/outbox"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Relay moves events from the outbox to a Publisher. Events are marked published only after Publish
+// returns, so a crash in between publishes them again: delivery is at least once.
+type Relay struct {
+	repo      outbox.OutboxRepository
+	publisher outbox.Publisher
+	cfg       config.Outbox
+	logger    *slog.Logger
+}
+
+func NewRelay(repo outbox.OutboxRepository, publisher outbox.Publisher, cfg config.Outbox, logger *slog.Logger) *Relay {
+	return &Relay{repo: repo, publisher: publisher, cfg: cfg, logger: logger}
+}
+
+// Run relays events every PollInterval until ctx is cancelled. A full batch is followed by the next one
+// right away, so a backlog drains without waiting for the ticker.
+func (r *Relay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := r.RelayOnce(ctx)
+			if err != nil {
+				r.logger.ErrorContext(ctx, "outbox relay failed", slog.String("error", err.Error()))
+				break
+			}
+			if n < r.cfg.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RelayOnce publishes one batch of due events and returns how many were claimed.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	events, err := r.repo.Claim(ctx, r.cfg.BatchSize, r.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range events {
+		if err = r.publish(ctx, event); err != nil {
+			return len(events), err
+		}
+	}
+	return len(events), nil
+}
+
+func (r *Relay) publish(ctx context.Context, event models.Event) error {
+	publishErr := r.publisher.Publish(ctx, event)
+	if publishErr == nil {
+		return r.repo.MarkPublished(ctx, event.ID)
+	}
+
+	attempts := event.Attempts + 1
+	dead := attempts >= r.cfg.MaxAttempts
+	if dead {
+		r.logger.ErrorContext(ctx, "outbox event is dead", slog.Int64("id", event.ID), slog.String("type", event.Type),
+			slog.Int("attempts", attempts), slog.String("error", publishErr.Error()))
+	} else {
+		r.logger.WarnContext(ctx, "failed to publish outbox event", slog.Int64("id", event.ID), slog.String("type", event.Type),
+			slog.Int("attempts", attempts), slog.String("error", publishErr.Error()))
+	}
+	if err := r.repo.MarkFailed(ctx, event.ID, publishErr.Error(), r.backoff(attempts), dead); err != nil {
+		return fmt.Errorf("recording publish failure: %w", err)
+	}
+	return nil
+}
+
+// backoff returns BaseBackoff doubled for every attempt after the first, capped at MaxBackoff.
+func (r *Relay) backoff(attempts int) time.Duration {
+	d := r.cfg.BaseBackoff
+	for i := 1; i < attempts && d < r.cfg.MaxBackoff; i++ {
+		d *= 2
+	}
+	return min(d, r.cfg.MaxBackoff)
+}
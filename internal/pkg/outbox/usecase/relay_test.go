@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	mocks "Merch_store-Avito_test_task/internal/pkg/outbox/mocks"
+	"Merch_store-Avito_test_task/internal/pkg/outbox/publisher"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var relayConfig = config.Outbox{
+	PollInterval: time.Millisecond,
+	BatchSize:    10,
+	MaxAttempts:  3,
+	BaseBackoff:  time.Second,
+	MaxBackoff:   3 * time.Second,
+	Lease:        time.Minute,
+}
+
+func TestRelayOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	pub := publisher.NewMemoryPublisher()
+	relay := NewRelay(repo, pub, relayConfig, logger)
+	ctx := context.Background()
+
+	purchase := models.Event{ID: 1, Type: models.EventItemPurchased, Payload: []byte(`{"product":"hoody"}`)}
+	transfer := models.Event{ID: 2, Type: models.EventCoinsTransferred, Payload: []byte(`{"amount":10}`), Attempts: 1}
+	dying := models.Event{ID: 3, Type: models.EventCoinsTransferred, Payload: []byte(`{"amount":20}`), Attempts: 2}
+
+	t.Run("Publishes and marks events", func(t *testing.T) {
+		repo.EXPECT().Claim(ctx, 10, time.Minute).Return([]models.Event{purchase, transfer}, nil)
+		repo.EXPECT().MarkPublished(ctx, int64(1)).Return(nil)
+		repo.EXPECT().MarkPublished(ctx, int64(2)).Return(nil)
+
+		n, err := relay.RelayOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+		assert.Equal(t, []models.Event{purchase, transfer}, pub.Events())
+	})
+
+	t.Run("Failed publish is retried with backoff, then dead", func(t *testing.T) {
+		pub.Fail = func(models.Event) error { return errors.New("broker unavailable") }
+		defer func() { pub.Fail = nil }()
+
+		repo.EXPECT().Claim(ctx, 10, time.Minute).Return([]models.Event{transfer, dying}, nil)
+		repo.EXPECT().MarkFailed(ctx, int64(2), "broker unavailable", 2*time.Second, false).Return(nil)
+		repo.EXPECT().MarkFailed(ctx, int64(3), "broker unavailable", 3*time.Second, true).Return(nil)
+
+		n, err := relay.RelayOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, n)
+	})
+
+	t.Run("Claim failure", func(t *testing.T) {
+		repo.EXPECT().Claim(ctx, 10, time.Minute).Return(nil, errors.New("connection refused"))
+
+		_, err := relay.RelayOnce(ctx)
+		assert.Error(t, err)
+	})
+}
+
+func TestRelayBackoff(t *testing.T) {
+	relay := NewRelay(nil, nil, relayConfig, nil)
+	assert.Equal(t, time.Second, relay.backoff(1))
+	assert.Equal(t, 2*time.Second, relay.backoff(2))
+	assert.Equal(t, 3*time.Second, relay.backoff(3))
+	assert.Equal(t, 3*time.Second, relay.backoff(50))
+}
+
+func TestRelayRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockOutboxRepository(ctrl)
+	pub := publisher.NewMemoryPublisher()
+	relay := NewRelay(repo, pub, relayConfig, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	event := models.Event{ID: 7, Type: models.EventItemPurchased}
+	repo.EXPECT().Claim(gomock.Any(), 10, time.Minute).Return([]models.Event{event}, nil)
+	repo.EXPECT().MarkPublished(gomock.Any(), int64(7)).Return(nil)
+	repo.EXPECT().Claim(gomock.Any(), 10, time.Minute).DoAndReturn(func(context.Context, int, time.Duration) ([]models.Event, error) {
+		cancel()
+		return nil, nil
+	}).MinTimes(1)
+
+	done := make(chan struct{})
+	go func() {
+		relay.Run(ctx)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("relay did not stop after cancellation")
+	}
+	assert.Equal(t, []models.Event{event}, pub.Events())
+}
@@ -26,7 +26,7 @@ func TestSendCoins(t *testing.T) {
 	handler := NewPaymentsHandler(mockUsecase, logger)
 
 	t.Run("successful coin transfer", func(t *testing.T) {
-		mockUsecase.EXPECT().SendCoins(gomock.Any(), "user2", uint(100)).Return(nil)
+		mockUsecase.EXPECT().SendCoins(gomock.Any(), uint(1), "user2", uint(100)).Return(nil)
 
 		data := map[string]interface{}{
 			"toUser": "user2",
@@ -40,10 +40,11 @@ func TestSendCoins(t *testing.T) {
 
 		handler.SendCoins(w, req)
 		assert.Equal(t, h.StatusOK, w.Code)
+		assert.JSONEq(t, `{}`, w.Body.String())
 	})
 
 	t.Run("not enough money", func(t *testing.T) {
-		mockUsecase.EXPECT().SendCoins(gomock.Any(), "user2", uint(100)).Return(models.ErrNotEnough)
+		mockUsecase.EXPECT().SendCoins(gomock.Any(), uint(1), "user2", uint(100)).Return(models.ErrNotEnough)
 
 		data := map[string]interface{}{
 			"toUser": "user2",
@@ -69,7 +70,7 @@ func TestBuyItem(t *testing.T) {
 	handler := NewPaymentsHandler(mockUsecase, logger)
 
 	t.Run("successful item purchase", func(t *testing.T) {
-		mockUsecase.EXPECT().BuyItem(gomock.Any(), uint(1)).Return(nil)
+		mockUsecase.EXPECT().BuyItem(gomock.Any(), uint(1), uint(1)).Return(nil)
 
 		req := httptest.NewRequest(h.MethodGet, "/buy/1", nil)
 		req = req.WithContext(context.WithValue(req.Context(), middleware.IdKey, uint(1)))
@@ -81,7 +82,7 @@ func TestBuyItem(t *testing.T) {
 	})
 
 	t.Run("not enough money to buy item", func(t *testing.T) {
-		mockUsecase.EXPECT().BuyItem(gomock.Any(), uint(1)).Return(models.ErrNotEnough)
+		mockUsecase.EXPECT().BuyItem(gomock.Any(), uint(1), uint(1)).Return(models.ErrNotEnough)
 
 		req := httptest.NewRequest(h.MethodGet, "/buy/1", nil)
 		req = req.WithContext(context.WithValue(req.Context(), middleware.IdKey, uint(1)))
@@ -90,5 +91,6 @@ func TestBuyItem(t *testing.T) {
 
 		handler.BuyItem(w, req)
 		assert.Equal(t, h.StatusForbidden, w.Code)
+		assert.JSONEq(t, `{"code": "insufficient_funds", "message": "not enough coins"}`, w.Body.String())
 	})
 }
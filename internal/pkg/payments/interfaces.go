@@ -1,14 +1,36 @@
 package payments
 
-import "context"
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+)
 
 //go:generate mockgen -source=interfaces.go -destination=mocks/mock.go
 type PaymentsUsecase interface {
-	SendCoins(ctx context.Context, toUser string, amount uint) error
-	BuyItem(ctx context.Context, itemId uint) error
+	SendCoins(ctx context.Context, fromUserID uint, toUser string, amount uint) error
+	BuyItem(ctx context.Context, userID, itemId uint) error
 }
 
+// PaymentsRepository records payments and returns the event describing them. Each call is atomic on
+// its own and joins the unit of work in ctx, if any.
 type PaymentsRepository interface {
-	Transfer(ctx context.Context, toUser string, amount uint) error
-	BuyItem(ctx context.Context, itemId uint) error
+	Transfer(ctx context.Context, fromUserID uint, toUser string, amount uint) (models.CoinsTransferredEvent, error)
+	// BuyItem charges the product's price as of the purchase; unknown and retired products are ErrNotFound.
+	BuyItem(ctx context.Context, userID, productID uint) (models.ItemPurchasedEvent, error)
+}
+
+// EventOutbox stores events for delivery; an event enqueued in a unit of work commits with it.
+type EventOutbox interface {
+	Enqueue(ctx context.Context, eventType string, payload any) error
+}
+
+// UnitOfWork runs fn in a transaction that repositories called with the ctx passed to fn take part in.
+// fn may be run again if the transaction has to be retried.
+type UnitOfWork interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// UserInfoInvalidator drops cached user info that a committed payment made stale.
+type UserInfoInvalidator interface {
+	InvalidateUserInfo(userIDs ...uint)
 }
@@ -5,6 +5,7 @@
 package mock_payments
 
 import (
+	models "Merch_store-Avito_test_task/internal/models"
 	context "context"
 	reflect "reflect"
 
@@ -35,31 +36,31 @@ func (m *MockPaymentsUsecase) EXPECT() *MockPaymentsUsecaseMockRecorder {
 }
 
 // BuyItem mocks base method.
-func (m *MockPaymentsUsecase) BuyItem(ctx context.Context, itemId uint) error {
+func (m *MockPaymentsUsecase) BuyItem(ctx context.Context, userID, itemId uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BuyItem", ctx, itemId)
+	ret := m.ctrl.Call(m, "BuyItem", ctx, userID, itemId)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // BuyItem indicates an expected call of BuyItem.
-func (mr *MockPaymentsUsecaseMockRecorder) BuyItem(ctx, itemId interface{}) *gomock.Call {
+func (mr *MockPaymentsUsecaseMockRecorder) BuyItem(ctx, userID, itemId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockPaymentsUsecase)(nil).BuyItem), ctx, itemId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockPaymentsUsecase)(nil).BuyItem), ctx, userID, itemId)
 }
 
 // SendCoins mocks base method.
-func (m *MockPaymentsUsecase) SendCoins(ctx context.Context, toUser string, amount uint) error {
+func (m *MockPaymentsUsecase) SendCoins(ctx context.Context, fromUserID uint, toUser string, amount uint) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendCoins", ctx, toUser, amount)
+	ret := m.ctrl.Call(m, "SendCoins", ctx, fromUserID, toUser, amount)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SendCoins indicates an expected call of SendCoins.
-func (mr *MockPaymentsUsecaseMockRecorder) SendCoins(ctx, toUser, amount interface{}) *gomock.Call {
+func (mr *MockPaymentsUsecaseMockRecorder) SendCoins(ctx, fromUserID, toUser, amount interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCoins", reflect.TypeOf((*MockPaymentsUsecase)(nil).SendCoins), ctx, toUser, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCoins", reflect.TypeOf((*MockPaymentsUsecase)(nil).SendCoins), ctx, fromUserID, toUser, amount)
 }
 
 // MockPaymentsRepository is a mock of PaymentsRepository interface.
@@ -86,29 +87,144 @@ func (m *MockPaymentsRepository) EXPECT() *MockPaymentsRepositoryMockRecorder {
 }
 
 // BuyItem mocks base method.
-func (m *MockPaymentsRepository) BuyItem(ctx context.Context, itemId uint) error {
+func (m *MockPaymentsRepository) BuyItem(ctx context.Context, userID, productID uint) (models.ItemPurchasedEvent, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "BuyItem", ctx, itemId)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret := m.ctrl.Call(m, "BuyItem", ctx, userID, productID)
+	ret0, _ := ret[0].(models.ItemPurchasedEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // BuyItem indicates an expected call of BuyItem.
-func (mr *MockPaymentsRepositoryMockRecorder) BuyItem(ctx, itemId interface{}) *gomock.Call {
+func (mr *MockPaymentsRepositoryMockRecorder) BuyItem(ctx, userID, productID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockPaymentsRepository)(nil).BuyItem), ctx, itemId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BuyItem", reflect.TypeOf((*MockPaymentsRepository)(nil).BuyItem), ctx, userID, productID)
 }
 
 // Transfer mocks base method.
-func (m *MockPaymentsRepository) Transfer(ctx context.Context, toUser string, amount uint) error {
+func (m *MockPaymentsRepository) Transfer(ctx context.Context, fromUserID uint, toUser string, amount uint) (models.CoinsTransferredEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Transfer", ctx, fromUserID, toUser, amount)
+	ret0, _ := ret[0].(models.CoinsTransferredEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Transfer indicates an expected call of Transfer.
+func (mr *MockPaymentsRepositoryMockRecorder) Transfer(ctx, fromUserID, toUser, amount interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockPaymentsRepository)(nil).Transfer), ctx, fromUserID, toUser, amount)
+}
+
+// MockEventOutbox is a mock of EventOutbox interface.
+type MockEventOutbox struct {
+	ctrl     *gomock.Controller
+	recorder *MockEventOutboxMockRecorder
+}
+
+// MockEventOutboxMockRecorder is the mock recorder for MockEventOutbox.
+type MockEventOutboxMockRecorder struct {
+	mock *MockEventOutbox
+}
+
+// NewMockEventOutbox creates a new mock instance.
+func NewMockEventOutbox(ctrl *gomock.Controller) *MockEventOutbox {
+	mock := &MockEventOutbox{ctrl: ctrl}
+	mock.recorder = &MockEventOutboxMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockEventOutbox) EXPECT() *MockEventOutboxMockRecorder {
+	return m.recorder
+}
+
+// Enqueue mocks base method.
+func (m *MockEventOutbox) Enqueue(ctx context.Context, eventType string, payload any) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Transfer", ctx, toUser, amount)
+	ret := m.ctrl.Call(m, "Enqueue", ctx, eventType, payload)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// Transfer indicates an expected call of Transfer.
-func (mr *MockPaymentsRepositoryMockRecorder) Transfer(ctx, toUser, amount interface{}) *gomock.Call {
+// Enqueue indicates an expected call of Enqueue.
+func (mr *MockEventOutboxMockRecorder) Enqueue(ctx, eventType, payload interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enqueue", reflect.TypeOf((*MockEventOutbox)(nil).Enqueue), ctx, eventType, payload)
+}
+
+// MockUnitOfWork is a mock of UnitOfWork interface.
+type MockUnitOfWork struct {
+	ctrl     *gomock.Controller
+	recorder *MockUnitOfWorkMockRecorder
+}
+
+// MockUnitOfWorkMockRecorder is the mock recorder for MockUnitOfWork.
+type MockUnitOfWorkMockRecorder struct {
+	mock *MockUnitOfWork
+}
+
+// NewMockUnitOfWork creates a new mock instance.
+func NewMockUnitOfWork(ctrl *gomock.Controller) *MockUnitOfWork {
+	mock := &MockUnitOfWork{ctrl: ctrl}
+	mock.recorder = &MockUnitOfWorkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUnitOfWork) EXPECT() *MockUnitOfWorkMockRecorder {
+	return m.recorder
+}
+
+// WithinTx mocks base method.
+func (m *MockUnitOfWork) WithinTx(ctx context.Context, fn func(context.Context) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WithinTx", ctx, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WithinTx indicates an expected call of WithinTx.
+func (mr *MockUnitOfWorkMockRecorder) WithinTx(ctx, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WithinTx", reflect.TypeOf((*MockUnitOfWork)(nil).WithinTx), ctx, fn)
+}
+
+// MockUserInfoInvalidator is a mock of UserInfoInvalidator interface.
+type MockUserInfoInvalidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockUserInfoInvalidatorMockRecorder
+}
+
+// MockUserInfoInvalidatorMockRecorder is the mock recorder for MockUserInfoInvalidator.
+type MockUserInfoInvalidatorMockRecorder struct {
+	mock *MockUserInfoInvalidator
+}
+
+// NewMockUserInfoInvalidator creates a new mock instance.
+func NewMockUserInfoInvalidator(ctrl *gomock.Controller) *MockUserInfoInvalidator {
+	mock := &MockUserInfoInvalidator{ctrl: ctrl}
+	mock.recorder = &MockUserInfoInvalidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockUserInfoInvalidator) EXPECT() *MockUserInfoInvalidatorMockRecorder {
+	return m.recorder
+}
+
+// InvalidateUserInfo mocks base method.
+func (m *MockUserInfoInvalidator) InvalidateUserInfo(userIDs ...uint) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range userIDs {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InvalidateUserInfo", varargs...)
+}
+
+// InvalidateUserInfo indicates an expected call of InvalidateUserInfo.
+func (mr *MockUserInfoInvalidatorMockRecorder) InvalidateUserInfo(userIDs ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Transfer", reflect.TypeOf((*MockPaymentsRepository)(nil).Transfer), ctx, toUser, amount)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateUserInfo", reflect.TypeOf((*MockUserInfoInvalidator)(nil).InvalidateUserInfo), userIDs...)
 }
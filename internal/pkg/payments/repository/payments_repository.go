@@ -2,125 +2,130 @@ package repository
 
 import (
 	"Merch_store-Avito_test_task/internal/models"
-	"Merch_store-Avito_test_task/internal/pkg/middleware"
+	"Merch_store-Avito_test_task/internal/pkg/database"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/lib/pq"
 )
 
 type PaymentsRepositoryImpl struct {
 	db *sql.DB
+	tx *database.TxManager
 }
 
 func NewAuthRepositoryImpl(db *sql.DB) *PaymentsRepositoryImpl {
-	return &PaymentsRepositoryImpl{db}
+	return &PaymentsRepositoryImpl{db: db, tx: database.NewTxManager(db)}
 }
 
-func (r *PaymentsRepositoryImpl) Transfer(ctx context.Context, toUser string, amount uint) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed start transaction: %w", err)
-	}
-	defer tx.Rollback()
-
-	userID := ctx.Value(middleware.IdKey).(uint)
-
-	query := `UPDATE "user" SET coins = coins - $1 WHERE id = $2`
-	res, err := tx.ExecContext(ctx, query, amount, userID)
-	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Code == "23514" {
-				return fmt.Errorf("not enough coins to send: %w", models.ErrNotEnough)
+// Transfer moves amount coins from the user fromUserID to toUser. Both rows are locked in id order
+// before either is updated, so transfers in opposite directions wait for each other instead of
+// deadlocking; a transaction Postgres still aborts is retried.
+func (r *PaymentsRepositoryImpl) Transfer(ctx context.Context, fromUserID uint, toUser string, amount uint) (models.CoinsTransferredEvent, error) {
+	var event models.CoinsTransferredEvent
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		event = models.CoinsTransferredEvent{FromUserID: fromUserID, ToUser: toUser, Amount: amount}
+		conn := database.Conn(ctx, r.db)
+		query := `SELECT id, username, coins FROM "user" WHERE id = $1 OR username = $2 ORDER BY id FOR UPDATE`
+		rows, err := conn.QueryContext(ctx, query, fromUserID, toUser)
+		if err != nil {
+			return fmt.Errorf("locking users failed: %w", err)
+		}
+		var balance uint
+		var senderFound, receiverFound bool
+		for rows.Next() {
+			var id, coins uint
+			var username string
+			if err = rows.Scan(&id, &username, &coins); err != nil {
+				rows.Close()
+				return fmt.Errorf("locking users failed: %w", err)
+			}
+			if id == fromUserID {
+				event.FromUser, balance, senderFound = username, coins, true
+			}
+			if username == toUser {
+				event.ToUserID, receiverFound = id, true
 			}
 		}
-		return fmt.Errorf("updating balance failed: %v", err)
-	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("getting rows affected failed: %v", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("receiver not found: %w", models.ErrNotFound)
-	}
-
-	query = `UPDATE "user" SET coins = coins + $1 WHERE username = $2`
-	res, err = tx.ExecContext(ctx, query, amount, toUser)
-	if err != nil {
-		return fmt.Errorf("updating balance failed: %v", err)
-	}
-	rowsAffected, err = res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("getting rows affected failed: %v", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("receiver not found: %w", models.ErrNotFound)
-	}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("locking users failed: %w", err)
+		}
+		if !senderFound {
+			return fmt.Errorf("sender not found: %w", models.ErrNotFound)
+		}
+		if !receiverFound {
+			return fmt.Errorf("receiver not found: %w", models.ErrNotFound)
+		}
+		if balance < amount {
+			return fmt.Errorf("not enough coins to send: %w", models.ErrNotEnough)
+		}
 
-	query = `INSERT INTO "transaction" (amount, from_user_id, to_user_id) VALUES ($1, $2, (SELECT id FROM "user" WHERE username = $3))`
-	res, err = tx.ExecContext(ctx, query, amount, userID, toUser)
-	if err != nil {
-		return fmt.Errorf("inserting transaction failed: %v", err)
-	}
-	rowsAffected, err = res.RowsAffected()
+		query = `UPDATE "user" SET coins = coins - $1 WHERE id = $2`
+		if _, err = conn.ExecContext(ctx, query, amount, event.FromUserID); err != nil {
+			return fmt.Errorf("updating balance failed: %w", err)
+		}
+		query = `UPDATE "user" SET coins = coins + $1 WHERE id = $2`
+		if _, err = conn.ExecContext(ctx, query, amount, event.ToUserID); err != nil {
+			return fmt.Errorf("updating balance failed: %w", err)
+		}
+		query = `INSERT INTO "transaction" (amount, from_user_id, to_user_id) VALUES ($1, $2, $3)`
+		if _, err = conn.ExecContext(ctx, query, amount, event.FromUserID, event.ToUserID); err != nil {
+			return fmt.Errorf("inserting transaction failed: %w", err)
+		}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("getting rows affected failed: %v", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("receiver not found: %w", models.ErrNotFound)
+		return models.CoinsTransferredEvent{}, err
 	}
-
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("committing transaction failed: %v", err)
-	}
-	return nil
+	return event, nil
 }
 
-func (r *PaymentsRepositoryImpl) BuyItem(ctx context.Context, itemID uint) error {
-	tx, err := r.db.BeginTx(ctx, nil)
-	if err != nil {
-		return fmt.Errorf("failed start transaction: %w", err)
-	}
-	defer tx.Rollback()
-	userID := ctx.Value(middleware.IdKey).(uint)
-	var amount uint
-	query := `SELECT price FROM "product" WHERE id = $1`
-	row := tx.QueryRowContext(ctx, query, itemID)
-	err = row.Scan(&amount)
-	if err != nil {
-		return fmt.Errorf("getting product failed: %v", err)
-	}
-	query = `UPDATE "user" SET coins = coins - $1 WHERE id = $2`
-	res, err := tx.ExecContext(ctx, query, amount, userID)
-	if err != nil {
-		if pqErr, ok := err.(*pq.Error); ok {
-			if pqErr.Code == "23514" {
-				return fmt.Errorf("not enough coins to buy: %w", models.ErrNotEnough)
+// BuyItem charges the current price of the product. The product row is read with a share lock in the
+// purchase transaction, so a concurrent price change or retirement either waits for the purchase or
+// is already visible to it; unknown and retired products are ErrNotFound.
+func (r *PaymentsRepositoryImpl) BuyItem(ctx context.Context, userID, productID uint) (models.ItemPurchasedEvent, error) {
+	var event models.ItemPurchasedEvent
+	err := r.tx.WithinTx(ctx, func(ctx context.Context) error {
+		conn := database.Conn(ctx, r.db)
+		product := models.Product{ID: productID}
+		query := `SELECT name, price FROM "product" WHERE id = $1 AND retired_at IS NULL FOR SHARE`
+		err := conn.QueryRowContext(ctx, query, productID).Scan(&product.Name, &product.Price)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("product %d: %w", productID, models.ErrNotFound)
 			}
+			return fmt.Errorf("getting product failed: %w", err)
 		}
-		return fmt.Errorf("updating balance failed: %v", err)
-	}
-	rowsAffected, err := res.RowsAffected()
-	if err != nil {
-		return fmt.Errorf("getting rows affected failed: %v", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("receiver not found: %w", models.ErrNotFound)
-	}
-	query = `INSERT INTO "purchase" (user_id, product_id) VALUES ($1, $2)`
-	res, err = tx.ExecContext(ctx, query, userID, itemID)
-	if err != nil {
-		return fmt.Errorf("inserting purchase failed: %v", err)
-	}
-	rowsAffected, err = res.RowsAffected()
+		amount := uint(product.Price)
+
+		var username string
+		query = `UPDATE "user" SET coins = coins - $1 WHERE id = $2 RETURNING username`
+		err = conn.QueryRowContext(ctx, query, amount, userID).Scan(&username)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok {
+				if pqErr.Code == "23514" {
+					return fmt.Errorf("not enough coins to buy: %w", models.ErrNotEnough)
+				}
+			}
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("buyer not found: %w", models.ErrNotFound)
+			}
+			return fmt.Errorf("updating balance failed: %w", err)
+		}
+		query = `INSERT INTO "purchase" (user_id, product_id) VALUES ($1, $2)`
+		if _, err = conn.ExecContext(ctx, query, userID, product.ID); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+				return fmt.Errorf("product not found: %w", models.ErrNotFound)
+			}
+			return fmt.Errorf("inserting purchase failed: %w", err)
+		}
+		event = models.ItemPurchasedEvent{UserID: userID, Username: username, ProductID: product.ID, Product: product.Name, Price: amount}
+		return nil
+	})
 	if err != nil {
-		return fmt.Errorf("getting rows affected failed: %v", err)
-	}
-	if rowsAffected == 0 {
-		return fmt.Errorf("receiver not found: %w", models.ErrNotFound)
-	}
-	if err = tx.Commit(); err != nil {
-		return fmt.Errorf("committing transaction failed: %v", err)
+		return models.ItemPurchasedEvent{}, err
 	}
-	return nil
+	return event, nil
 }
@@ -1,14 +1,15 @@
 package repository
 
 import (
-	"Merch_store-Avito_test_task/internal/pkg/middleware"
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/database"
 	"context"
 	"database/sql"
-	"errors"
 	"log"
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -19,7 +20,13 @@ func TestPaymentsRepository(t *testing.T) {
 	}
 	defer db.Close()
 
-	repo := &PaymentsRepositoryImpl{db}
+	repo := NewAuthRepositoryImpl(db)
+	expectHoody := func() {
+		mock.ExpectQuery(`SELECT name, price FROM "product" WHERE id = \$1 AND retired_at IS NULL FOR SHARE`).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "price"}).AddRow("hoody", 500))
+	}
+	ctx := context.Background()
 
 	tests := []struct {
 		name     string
@@ -28,61 +35,100 @@ func TestPaymentsRepository(t *testing.T) {
 		{"Transfer - Successful", func(t *testing.T) {
 			mock.ExpectBegin()
 
+			mock.ExpectQuery(`SELECT id, username, coins FROM "user" WHERE id = \$1 OR username = \$2 ORDER BY id FOR UPDATE`).
+				WithArgs(1, "receiver").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins"}).
+					AddRow(1, "sender", 1000).
+					AddRow(2, "receiver", 0))
+
 			mock.ExpectExec(`UPDATE "user" SET coins = coins - \$1 WHERE id = \$2`).
 				WithArgs(100, 1).
 				WillReturnResult(sqlmock.NewResult(0, 1))
 
-			mock.ExpectExec(`UPDATE "user" SET coins = coins \+ \$1 WHERE username = \$2`).
-				WithArgs(100, "receiver").
+			mock.ExpectExec(`UPDATE "user" SET coins = coins \+ \$1 WHERE id = \$2`).
+				WithArgs(100, 2).
 				WillReturnResult(sqlmock.NewResult(0, 1))
 
-			mock.ExpectExec(`INSERT INTO "transaction" \(amount, from_user_id, to_user_id\) VALUES \(\$1, \$2, \(SELECT id FROM "user" WHERE username = \$3\)\)`).
-				WithArgs(100, 1, "receiver").
+			mock.ExpectExec(`INSERT INTO "transaction" \(amount, from_user_id, to_user_id\) VALUES \(\$1, \$2, \$3\)`).
+				WithArgs(100, 1, 2).
 				WillReturnResult(sqlmock.NewResult(0, 1))
 
 			mock.ExpectCommit()
 
-			err := repo.Transfer(context.WithValue(context.Background(), middleware.IdKey, uint(1)), "receiver", 100)
+			event, err := repo.Transfer(ctx, 1, "receiver", 100)
+			assert.NoError(t, err)
+			assert.Equal(t, models.CoinsTransferredEvent{FromUserID: 1, FromUser: "sender", ToUserID: 2, ToUser: "receiver", Amount: 100}, event)
+		}},
+
+		{"Transfer - Locks in id order when receiver id is lower", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id, username, coins FROM "user" .+ ORDER BY id FOR UPDATE`).
+				WithArgs(5, "receiver").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins"}).
+					AddRow(2, "receiver", 0).
+					AddRow(5, "sender", 100))
+			mock.ExpectExec(`UPDATE "user" SET coins = coins - \$1`).WithArgs(100, 5).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE "user" SET coins = coins \+ \$1`).WithArgs(100, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO "transaction"`).WithArgs(100, 5, 2).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			event, err := repo.Transfer(ctx, 5, "receiver", 100)
 			assert.NoError(t, err)
+			assert.Equal(t, uint(2), event.ToUserID)
 		}},
 
 		{"Transfer - Not Enough Coins", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectExec(`UPDATE "user" SET coins = coins - \$1 WHERE id = \$2`).
-				WithArgs(100, 1).
-				WillReturnError(errors.New("not enough coins to send"))
+			mock.ExpectQuery(`SELECT id, username, coins FROM "user"`).
+				WithArgs(1, "receiver").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins"}).
+					AddRow(1, "sender", 50).
+					AddRow(2, "receiver", 0))
 			mock.ExpectRollback()
 
-			err := repo.Transfer(context.WithValue(context.Background(), middleware.IdKey, uint(1)), "receiver", 100)
-			assert.Error(t, err)
+			_, err := repo.Transfer(ctx, 1, "receiver", 100)
+			assert.ErrorIs(t, err, models.ErrNotEnough)
 		}},
 
 		{"Transfer - Receiver Not Found", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectExec(`UPDATE "user" SET coins = coins - \$1 WHERE id = \$2`).
-				WithArgs(100, 1).
-				WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectQuery(`SELECT id, username, coins FROM "user"`).
+				WithArgs(1, "unknown_user").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins"}).AddRow(1, "sender", 1000))
+			mock.ExpectRollback()
 
-			mock.ExpectExec(`UPDATE "user" SET coins = coins \+ \$1 WHERE username = \$2`).
-				WithArgs(100, "unknown_user").
-				WillReturnResult(sqlmock.NewResult(0, 0))
+			_, err := repo.Transfer(ctx, 1, "unknown_user", 100)
+			assert.ErrorIs(t, err, models.ErrNotFound)
+		}},
 
+		{"Transfer - Deadlock is retried", func(t *testing.T) {
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id, username, coins FROM "user"`).
+				WithArgs(1, "receiver").
+				WillReturnError(&pq.Error{Code: "40P01"})
 			mock.ExpectRollback()
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT id, username, coins FROM "user"`).
+				WithArgs(1, "receiver").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "username", "coins"}).
+					AddRow(1, "sender", 1000).
+					AddRow(2, "receiver", 0))
+			mock.ExpectExec(`UPDATE "user" SET coins = coins - \$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`UPDATE "user" SET coins = coins \+ \$1`).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO "transaction"`).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
 
-			err := repo.Transfer(context.WithValue(context.Background(), middleware.IdKey, uint(1)), "unknown_user", 100)
-			assert.Error(t, err)
+			_, err := repo.Transfer(ctx, 1, "receiver", 100)
+			assert.NoError(t, err)
 		}},
 
 		{"BuyItem - Successful", func(t *testing.T) {
 			mock.ExpectBegin()
+			expectHoody()
 
-			mock.ExpectQuery(`SELECT price FROM "product" WHERE id = \$1`).
-				WithArgs(1).
-				WillReturnRows(sqlmock.NewRows([]string{"price"}).AddRow(500))
-
-			mock.ExpectExec(`UPDATE "user" SET coins = coins - \$1 WHERE id = \$2`).
+			mock.ExpectQuery(`UPDATE "user" SET coins = coins - \$1 WHERE id = \$2 RETURNING username`).
 				WithArgs(500, 1).
-				WillReturnResult(sqlmock.NewResult(0, 1))
+				WillReturnRows(sqlmock.NewRows([]string{"username"}).AddRow("buyer"))
 
 			mock.ExpectExec(`INSERT INTO "purchase" \(user_id, product_id\) VALUES \(\$1, \$2\)`).
 				WithArgs(1, 1).
@@ -90,36 +136,66 @@ func TestPaymentsRepository(t *testing.T) {
 
 			mock.ExpectCommit()
 
-			err := repo.BuyItem(context.WithValue(context.Background(), middleware.IdKey, uint(1)), 1)
+			event, err := repo.BuyItem(ctx, 1, 1)
 			assert.NoError(t, err)
+			assert.Equal(t, models.ItemPurchasedEvent{UserID: 1, Username: "buyer", ProductID: 1, Product: "hoody", Price: 500}, event)
 		}},
 
-		{"BuyItem - Not Enough Coins", func(t *testing.T) {
+		{"BuyItem - Retired product is not charged", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectQuery(`SELECT price FROM "product" WHERE id = \$1`).
-				WithArgs(1).
-				WillReturnRows(sqlmock.NewRows([]string{"price"}).AddRow(500))
+			mock.ExpectQuery(`SELECT name, price FROM "product"`).WithArgs(2).WillReturnError(sql.ErrNoRows)
+			mock.ExpectRollback()
 
-			mock.ExpectExec(`UPDATE "user" SET coins = coins - \$1 WHERE id = \$2`).
+			_, err := repo.BuyItem(ctx, 1, 2)
+			assert.ErrorIs(t, err, models.ErrNotFound)
+		}},
+
+		{"BuyItem - Not Enough Coins", func(t *testing.T) {
+			mock.ExpectBegin()
+			expectHoody()
+			mock.ExpectQuery(`UPDATE "user" SET coins = coins - \$1 WHERE id = \$2`).
 				WithArgs(500, 1).
-				WillReturnError(errors.New("not enough coins to buy"))
+				WillReturnError(&pq.Error{Code: "23514"})
 
 			mock.ExpectRollback()
 
-			err := repo.BuyItem(context.WithValue(context.Background(), middleware.IdKey, uint(1)), 1)
-			assert.Error(t, err)
+			_, err := repo.BuyItem(ctx, 1, 1)
+			assert.ErrorIs(t, err, models.ErrNotEnough)
 		}},
 
-		{"BuyItem - Product Not Found", func(t *testing.T) {
+		{"BuyItem - Product Deleted", func(t *testing.T) {
 			mock.ExpectBegin()
-			mock.ExpectQuery(`SELECT price FROM "product" WHERE id = \$1`).
-				WithArgs(999).
-				WillReturnError(sql.ErrNoRows)
-
+			expectHoody()
+			mock.ExpectQuery(`UPDATE "user" SET coins = coins - \$1 WHERE id = \$2`).
+				WithArgs(500, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"username"}).AddRow("buyer"))
+			mock.ExpectExec(`INSERT INTO "purchase"`).
+				WithArgs(1, 1).
+				WillReturnError(&pq.Error{Code: "23503"})
 			mock.ExpectRollback()
 
-			err := repo.BuyItem(context.WithValue(context.Background(), middleware.IdKey, uint(1)), 999)
-			assert.Error(t, err)
+			_, err := repo.BuyItem(ctx, 1, 1)
+			assert.ErrorIs(t, err, models.ErrNotFound)
+		}},
+
+		{"BuyItem - Joins the caller's transaction", func(t *testing.T) {
+			mock.ExpectBegin()
+			expectHoody()
+			mock.ExpectQuery(`UPDATE "user" SET coins = coins - \$1`).
+				WithArgs(500, 1).
+				WillReturnRows(sqlmock.NewRows([]string{"username"}).AddRow("buyer"))
+			mock.ExpectExec(`INSERT INTO "purchase"`).WithArgs(1, 1).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectExec(`INSERT INTO "outbox_event"`).WillReturnResult(sqlmock.NewResult(0, 1))
+			mock.ExpectCommit()
+
+			err := database.NewTxManager(db).WithinTx(ctx, func(ctx context.Context) error {
+				if _, err := repo.BuyItem(ctx, 1, 1); err != nil {
+					return err
+				}
+				_, err := database.Conn(ctx, db).ExecContext(ctx, `INSERT INTO "outbox_event" (event_type, payload) VALUES ($1, $2)`, "test", "{}")
+				return err
+			})
+			assert.NoError(t, err)
 		}},
 	}
 
@@ -1,22 +1,61 @@
 package usecase
 
 import (
+	"Merch_store-Avito_test_task/internal/models"
 	"Merch_store-Avito_test_task/internal/pkg/payments"
 	"context"
 )
 
 type PaymentsUsecaseImpl struct {
-	repo payments.PaymentsRepository
+	repo   payments.PaymentsRepository
+	outbox payments.EventOutbox
+	uow    payments.UnitOfWork
+	cache  payments.UserInfoInvalidator
 }
 
-func NewPaymentsUsecase(repo payments.PaymentsRepository) *PaymentsUsecaseImpl {
-	return &PaymentsUsecaseImpl{repo}
+// NewPaymentsUsecase creates the usecase; cache may be nil when user info is not cached.
+func NewPaymentsUsecase(repo payments.PaymentsRepository, outbox payments.EventOutbox, uow payments.UnitOfWork,
+	cache payments.UserInfoInvalidator) *PaymentsUsecaseImpl {
+	return &PaymentsUsecaseImpl{repo: repo, outbox: outbox, uow: uow, cache: cache}
 }
 
-func (r *PaymentsUsecaseImpl) SendCoins(ctx context.Context, toUser string, amount uint) error {
-	return r.repo.Transfer(ctx, toUser, amount)
+// SendCoins records the transfer and its event in one transaction.
+func (r *PaymentsUsecaseImpl) SendCoins(ctx context.Context, fromUserID uint, toUser string, amount uint) error {
+	var event models.CoinsTransferredEvent
+	err := r.uow.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if event, err = r.repo.Transfer(ctx, fromUserID, toUser, amount); err != nil {
+			return err
+		}
+		return r.outbox.Enqueue(ctx, models.EventCoinsTransferred, event)
+	})
+	if err != nil {
+		return err
+	}
+	r.invalidate(event.FromUserID, event.ToUserID)
+	return nil
 }
 
-func (r *PaymentsUsecaseImpl) BuyItem(ctx context.Context, itemId uint) error {
-	return r.repo.BuyItem(ctx, itemId)
+// BuyItem records the purchase and its event in one transaction. The price is read in that
+// transaction too, never from the product cache.
+func (r *PaymentsUsecaseImpl) BuyItem(ctx context.Context, userID, itemId uint) error {
+	var event models.ItemPurchasedEvent
+	err := r.uow.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		if event, err = r.repo.BuyItem(ctx, userID, itemId); err != nil {
+			return err
+		}
+		return r.outbox.Enqueue(ctx, models.EventItemPurchased, event)
+	})
+	if err != nil {
+		return err
+	}
+	r.invalidate(event.UserID)
+	return nil
+}
+
+func (r *PaymentsUsecaseImpl) invalidate(userIDs ...uint) {
+	if r.cache != nil {
+		r.cache.InvalidateUserInfo(userIDs...)
+	}
 }
@@ -0,0 +1,93 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+	dbMocks "Merch_store-Avito_test_task/internal/pkg/database/mocks"
+	mocks "Merch_store-Avito_test_task/internal/pkg/payments/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPaymentsUsecase(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockPaymentsRepository(ctrl)
+	outbox := mocks.NewMockEventOutbox(ctrl)
+	cache := mocks.NewMockUserInfoInvalidator(ctrl)
+	uow := &dbMocks.FakeTxManager{}
+	uc := NewPaymentsUsecase(repo, outbox, uow, cache)
+	ctx := context.Background()
+	inTx := func(ctx context.Context) { assert.True(t, dbMocks.InFakeTx(ctx)) }
+
+	t.Run("Transfer and its event commit together", func(t *testing.T) {
+		event := models.CoinsTransferredEvent{FromUserID: 1, ToUserID: 2}
+		repo.EXPECT().Transfer(gomock.Any(), uint(1), "bob", uint(100)).DoAndReturn(
+			func(ctx context.Context, _ uint, _ string, _ uint) (models.CoinsTransferredEvent, error) {
+				inTx(ctx)
+				return event, nil
+			})
+		outbox.EXPECT().Enqueue(gomock.Any(), models.EventCoinsTransferred, event).DoAndReturn(
+			func(ctx context.Context, _ string, _ any) error {
+				inTx(ctx)
+				return nil
+			})
+		cache.EXPECT().InvalidateUserInfo(uint(1), uint(2))
+		commits := uow.Commits()
+		assert.NoError(t, uc.SendCoins(ctx, 1, "bob", 100))
+		assert.Equal(t, commits+1, uow.Commits())
+	})
+
+	t.Run("Failed transfer keeps the cache", func(t *testing.T) {
+		repo.EXPECT().Transfer(gomock.Any(), uint(1), "bob", uint(5000)).Return(models.CoinsTransferredEvent{}, models.ErrNotEnough)
+		rollbacks := uow.Rollbacks()
+		assert.ErrorIs(t, uc.SendCoins(ctx, 1, "bob", 5000), models.ErrNotEnough)
+		assert.Equal(t, rollbacks+1, uow.Rollbacks())
+	})
+
+	t.Run("Failed enqueue rolls the purchase back", func(t *testing.T) {
+		failure := errors.New("outbox is down")
+		repo.EXPECT().BuyItem(gomock.Any(), uint(1), uint(3)).Return(models.ItemPurchasedEvent{UserID: 1, ProductID: 3}, nil)
+		outbox.EXPECT().Enqueue(gomock.Any(), models.EventItemPurchased, gomock.Any()).Return(failure)
+		rollbacks := uow.Rollbacks()
+		assert.ErrorIs(t, uc.BuyItem(ctx, 1, 3), failure)
+		assert.Equal(t, rollbacks+1, uow.Rollbacks())
+	})
+
+	t.Run("Purchase and its event commit together", func(t *testing.T) {
+		event := models.ItemPurchasedEvent{UserID: 1, ProductID: 3}
+		repo.EXPECT().BuyItem(gomock.Any(), uint(1), uint(3)).DoAndReturn(
+			func(ctx context.Context, _, _ uint) (models.ItemPurchasedEvent, error) {
+				inTx(ctx)
+				return event, nil
+			})
+		outbox.EXPECT().Enqueue(gomock.Any(), models.EventItemPurchased, event)
+		cache.EXPECT().InvalidateUserInfo(uint(1))
+		assert.NoError(t, uc.BuyItem(ctx, 1, 3))
+	})
+
+	t.Run("Failed commit keeps the cache", func(t *testing.T) {
+		uow := &dbMocks.FakeTxManager{CommitErr: errors.New("connection lost")}
+		uc := NewPaymentsUsecase(repo, outbox, uow, cache)
+		repo.EXPECT().Transfer(gomock.Any(), uint(1), "bob", uint(1)).Return(models.CoinsTransferredEvent{FromUserID: 1, ToUserID: 2}, nil)
+		outbox.EXPECT().Enqueue(gomock.Any(), models.EventCoinsTransferred, gomock.Any())
+		assert.ErrorIs(t, uc.SendCoins(ctx, 1, "bob", 1), uow.CommitErr)
+	})
+
+	t.Run("Unknown product", func(t *testing.T) {
+		repo.EXPECT().BuyItem(gomock.Any(), uint(1), uint(9)).Return(models.ItemPurchasedEvent{}, models.ErrNotFound)
+		assert.ErrorIs(t, uc.BuyItem(ctx, 1, 9), models.ErrNotFound)
+	})
+
+	t.Run("Without cache", func(t *testing.T) {
+		uc := NewPaymentsUsecase(repo, outbox, uow, nil)
+		repo.EXPECT().Transfer(gomock.Any(), uint(1), "bob", uint(1)).Return(models.CoinsTransferredEvent{FromUserID: 1, ToUserID: 2}, nil)
+		outbox.EXPECT().Enqueue(gomock.Any(), models.EventCoinsTransferred, gomock.Any())
+		assert.NoError(t, uc.SendCoins(ctx, 1, "bob", 1))
+	})
+}
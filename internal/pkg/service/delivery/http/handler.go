@@ -8,6 +8,8 @@ import (
 	"net/http"
 )
 
+var errUnauthorized = httpresponses.NewError(http.StatusUnauthorized, httpresponses.CodeUnauthorized, "user is not authorized")
+
 type ServiceHandler struct {
 	uc     service.ServiceUsecase
 	logger *slog.Logger
@@ -19,22 +21,16 @@ func NewServiceHandler(uc service.ServiceUsecase, logger *slog.Logger) *ServiceH
 
 func (h *ServiceHandler) GetUserInfo(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	_, ok := ctx.Value(middleware.IdKey).(uint)
+	userID, ok := middleware.UserID(ctx)
 	if !ok {
 		h.logger.ErrorContext(ctx, "failed to retrieve user id from context")
-		response := httpresponses.Response{
-			Message: "User is not authorized",
-		}
-		httpresponses.SendJSONResponse(ctx, w, response, http.StatusUnauthorized, h.logger)
+		httpresponses.SendError(w, r, errUnauthorized, h.logger)
 		return
 	}
-	info, err := h.uc.GetUserInfo(ctx)
+	info, err := h.uc.GetUserInfo(ctx, userID)
 	if err != nil {
-		h.logger.ErrorContext(ctx, "failed to retrieve user info from context")
-		response := httpresponses.Response{
-			Message: "Failed to get user info",
-		}
-		httpresponses.SendJSONResponse(ctx, w, response, http.StatusInternalServerError, h.logger)
+		h.logger.ErrorContext(ctx, "failed to get user info", slog.String("err", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
 		return
 	}
 	httpresponses.SendJSONResponse(ctx, w, info, http.StatusOK, h.logger)
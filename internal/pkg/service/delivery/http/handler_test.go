@@ -31,34 +31,33 @@ func TestServiceHandler_GetUserInfo(t *testing.T) {
 		name           string
 		mockSetup      func()
 		expectedStatus int
-		expectedBody   httpresponses.Response
+		expectedBody   httpresponses.Error
 		ctx            context.Context
 	}{
 		{
 			name: "Successful user info retrieval",
 			mockSetup: func() {
-				mockService.EXPECT().GetUserInfo(gomock.Any()).Return(models.UserData{
+				mockService.EXPECT().GetUserInfo(gomock.Any(), uint(1)).Return(models.UserData{
 					Coins: 5000,
 				}, nil)
 			},
 			expectedStatus: http.StatusOK,
-			expectedBody:   httpresponses.Response{Message: ""},
 			ctx:            context.WithValue(context.Background(), middleware.IdKey, uint(1)),
 		},
 		{
 			name:           "User not authorized",
 			mockSetup:      func() {},
 			expectedStatus: http.StatusUnauthorized,
-			expectedBody:   httpresponses.Response{Message: "User is not authorized"},
+			expectedBody:   httpresponses.Error{Code: httpresponses.CodeUnauthorized, Message: "user is not authorized"},
 			ctx:            context.Background(), // Нет userID
 		},
 		{
 			name: "Failed to retrieve user info",
 			mockSetup: func() {
-				mockService.EXPECT().GetUserInfo(gomock.Any()).Return(models.UserData{}, errors.New("DB error"))
+				mockService.EXPECT().GetUserInfo(gomock.Any(), uint(1)).Return(models.UserData{}, errors.New("DB error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
-			expectedBody:   httpresponses.Response{Message: "Failed to get user info"},
+			expectedBody:   httpresponses.Error{Code: httpresponses.CodeInternal, Message: "internal server error"},
 			ctx:            context.WithValue(context.Background(), middleware.IdKey, uint(1)),
 		},
 	}
@@ -77,9 +76,10 @@ func TestServiceHandler_GetUserInfo(t *testing.T) {
 
 			assert.Equal(t, tt.expectedStatus, rr.Code)
 
-			var response httpresponses.Response
+			var response httpresponses.Error
 			err = json.Unmarshal(rr.Body.Bytes(), &response)
 			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedBody.Code, response.Code)
 			assert.Equal(t, tt.expectedBody.Message, response.Message)
 		})
 	}
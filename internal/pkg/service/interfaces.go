@@ -7,9 +7,20 @@ import (
 
 //go:generate mockgen -source=interfaces.go -destination=mocks/mock.go
 type ServiceUsecase interface {
-	GetUserInfo(ctx context.Context) (models.UserData, error)
+	GetUserInfo(ctx context.Context, userID uint) (models.UserData, error)
+	ListProducts(ctx context.Context) ([]models.Product, error)
 }
 
 type ServiceRepository interface {
 	GetUserInfo(ctx context.Context, userID uint) (models.UserData, error)
+	ListProducts(ctx context.Context) ([]models.Product, error)
+	// GetProduct returns a product that can be bought; unknown and retired products are ErrNotFound.
+	GetProduct(ctx context.Context, productID uint) (models.Product, error)
+}
+
+// CacheInvalidator is implemented by ServiceRepository caches; writers call it to drop the entries
+// their committed changes made stale.
+type CacheInvalidator interface {
+	InvalidateUserInfo(userIDs ...uint)
+	InvalidateProducts()
 }
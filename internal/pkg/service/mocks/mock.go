@@ -36,18 +36,33 @@ func (m *MockServiceUsecase) EXPECT() *MockServiceUsecaseMockRecorder {
 }
 
 // GetUserInfo mocks base method.
-func (m *MockServiceUsecase) GetUserInfo(ctx context.Context) (models.UserData, error) {
+func (m *MockServiceUsecase) GetUserInfo(ctx context.Context, userID uint) (models.UserData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserInfo", ctx)
+	ret := m.ctrl.Call(m, "GetUserInfo", ctx, userID)
 	ret0, _ := ret[0].(models.UserData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserInfo indicates an expected call of GetUserInfo.
-func (mr *MockServiceUsecaseMockRecorder) GetUserInfo(ctx interface{}) *gomock.Call {
+func (mr *MockServiceUsecaseMockRecorder) GetUserInfo(ctx, userID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInfo", reflect.TypeOf((*MockServiceUsecase)(nil).GetUserInfo), ctx)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInfo", reflect.TypeOf((*MockServiceUsecase)(nil).GetUserInfo), ctx, userID)
+}
+
+// ListProducts mocks base method.
+func (m *MockServiceUsecase) ListProducts(ctx context.Context) ([]models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProducts", ctx)
+	ret0, _ := ret[0].([]models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProducts indicates an expected call of ListProducts.
+func (mr *MockServiceUsecaseMockRecorder) ListProducts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProducts", reflect.TypeOf((*MockServiceUsecase)(nil).ListProducts), ctx)
 }
 
 // MockServiceRepository is a mock of ServiceRepository interface.
@@ -73,6 +88,21 @@ func (m *MockServiceRepository) EXPECT() *MockServiceRepositoryMockRecorder {
 	return m.recorder
 }
 
+// GetProduct mocks base method.
+func (m *MockServiceRepository) GetProduct(ctx context.Context, productID uint) (models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProduct", ctx, productID)
+	ret0, _ := ret[0].(models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProduct indicates an expected call of GetProduct.
+func (mr *MockServiceRepositoryMockRecorder) GetProduct(ctx, productID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockServiceRepository)(nil).GetProduct), ctx, productID)
+}
+
 // GetUserInfo mocks base method.
 func (m *MockServiceRepository) GetUserInfo(ctx context.Context, userID uint) (models.UserData, error) {
 	m.ctrl.T.Helper()
@@ -87,3 +117,69 @@ func (mr *MockServiceRepositoryMockRecorder) GetUserInfo(ctx, userID interface{}
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserInfo", reflect.TypeOf((*MockServiceRepository)(nil).GetUserInfo), ctx, userID)
 }
+
+// ListProducts mocks base method.
+func (m *MockServiceRepository) ListProducts(ctx context.Context) ([]models.Product, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProducts", ctx)
+	ret0, _ := ret[0].([]models.Product)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProducts indicates an expected call of ListProducts.
+func (mr *MockServiceRepositoryMockRecorder) ListProducts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProducts", reflect.TypeOf((*MockServiceRepository)(nil).ListProducts), ctx)
+}
+
+// MockCacheInvalidator is a mock of CacheInvalidator interface.
+type MockCacheInvalidator struct {
+	ctrl     *gomock.Controller
+	recorder *MockCacheInvalidatorMockRecorder
+}
+
+// MockCacheInvalidatorMockRecorder is the mock recorder for MockCacheInvalidator.
+type MockCacheInvalidatorMockRecorder struct {
+	mock *MockCacheInvalidator
+}
+
+// NewMockCacheInvalidator creates a new mock instance.
+func NewMockCacheInvalidator(ctrl *gomock.Controller) *MockCacheInvalidator {
+	mock := &MockCacheInvalidator{ctrl: ctrl}
+	mock.recorder = &MockCacheInvalidatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCacheInvalidator) EXPECT() *MockCacheInvalidatorMockRecorder {
+	return m.recorder
+}
+
+// InvalidateProducts mocks base method.
+func (m *MockCacheInvalidator) InvalidateProducts() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "InvalidateProducts")
+}
+
+// InvalidateProducts indicates an expected call of InvalidateProducts.
+func (mr *MockCacheInvalidatorMockRecorder) InvalidateProducts() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateProducts", reflect.TypeOf((*MockCacheInvalidator)(nil).InvalidateProducts))
+}
+
+// InvalidateUserInfo mocks base method.
+func (m *MockCacheInvalidator) InvalidateUserInfo(userIDs ...uint) {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{}
+	for _, a := range userIDs {
+		varargs = append(varargs, a)
+	}
+	m.ctrl.Call(m, "InvalidateUserInfo", varargs...)
+}
+
+// InvalidateUserInfo indicates an expected call of InvalidateUserInfo.
+func (mr *MockCacheInvalidatorMockRecorder) InvalidateUserInfo(userIDs ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InvalidateUserInfo", reflect.TypeOf((*MockCacheInvalidator)(nil).InvalidateUserInfo), userIDs...)
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/cache"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/service"
+	"context"
+)
+
+// CachedServiceRepo is a read-through cache in front of a ServiceRepository for user info and
+// product lookups. Payments invalidate the entries they change once committed; other changes, such
+// as those made by another replica or merchctl, show up when the entry expires.
+type CachedServiceRepo struct {
+	repo     service.ServiceRepository
+	userInfo *cache.LRU[uint, models.UserData]
+	products *cache.LRU[uint, models.Product]
+}
+
+// NewCachedServiceRepo wraps repo and publishes the cache stats as cache.user_info and cache.products.
+func NewCachedServiceRepo(repo service.ServiceRepository, cfg config.Cache) *CachedServiceRepo {
+	r := &CachedServiceRepo{
+		repo:     repo,
+		userInfo: cache.NewLRU[uint, models.UserData](cfg.UserInfoSize, cfg.UserInfoTTL, nil),
+		products: cache.NewLRU[uint, models.Product](cfg.ProductsSize, cfg.ProductsTTL, nil),
+	}
+	cache.Publish("user_info", r.userInfo)
+	cache.Publish("products", r.products)
+	return r
+}
+
+func (r *CachedServiceRepo) GetUserInfo(ctx context.Context, userID uint) (models.UserData, error) {
+	return r.userInfo.GetOrLoad(userID, func() (models.UserData, error) {
+		return r.repo.GetUserInfo(ctx, userID)
+	})
+}
+
+func (r *CachedServiceRepo) ListProducts(ctx context.Context) ([]models.Product, error) {
+	return r.repo.ListProducts(ctx)
+}
+
+func (r *CachedServiceRepo) GetProduct(ctx context.Context, productID uint) (models.Product, error) {
+	return r.products.GetOrLoad(productID, func() (models.Product, error) {
+		return r.repo.GetProduct(ctx, productID)
+	})
+}
+
+// InvalidateUserInfo drops the cached info of users whose balance or history changed.
+func (r *CachedServiceRepo) InvalidateUserInfo(userIDs ...uint) {
+	r.userInfo.Delete(userIDs...)
+}
+
+// InvalidateProducts drops every cached product, e.g. after a catalog sync.
+func (r *CachedServiceRepo) InvalidateProducts() {
+	r.products.Purge()
+}
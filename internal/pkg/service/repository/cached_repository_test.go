@@ -0,0 +1,63 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/service"
+	mocks "Merch_store-Avito_test_task/internal/pkg/service/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCachedServiceRepo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mocks.NewMockServiceRepository(ctrl)
+	repo := NewCachedServiceRepo(mockRepo, config.Cache{UserInfoSize: 10, UserInfoTTL: time.Minute, ProductsSize: 10, ProductsTTL: time.Minute})
+	ctx := context.Background()
+	assert.Implements(t, (*service.CacheInvalidator)(nil), repo)
+
+	t.Run("User info is read through", func(t *testing.T) {
+		mockRepo.EXPECT().GetUserInfo(ctx, uint(1)).Return(models.UserData{Coins: 1000}, nil).Times(1)
+		for range 2 {
+			data, err := repo.GetUserInfo(ctx, 1)
+			assert.NoError(t, err)
+			assert.Equal(t, 1000, data.Coins)
+		}
+
+		repo.InvalidateUserInfo(1)
+		mockRepo.EXPECT().GetUserInfo(ctx, uint(1)).Return(models.UserData{Coins: 900}, nil)
+		data, err := repo.GetUserInfo(ctx, 1)
+		assert.NoError(t, err)
+		assert.Equal(t, 900, data.Coins)
+	})
+
+	t.Run("Unknown products are not cached", func(t *testing.T) {
+		mockRepo.EXPECT().GetProduct(ctx, uint(9)).Return(models.Product{}, models.ErrNotFound).Times(2)
+		for range 2 {
+			_, err := repo.GetProduct(ctx, 9)
+			assert.ErrorIs(t, err, models.ErrNotFound)
+		}
+	})
+
+	t.Run("Products are dropped after a catalog change", func(t *testing.T) {
+		mockRepo.EXPECT().GetProduct(ctx, uint(2)).Return(models.Product{ID: 2, Name: "cup", Price: 20}, nil)
+		product, err := repo.GetProduct(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 20, product.Price)
+		_, err = repo.GetProduct(ctx, 2)
+		assert.NoError(t, err)
+
+		repo.InvalidateProducts()
+		mockRepo.EXPECT().GetProduct(ctx, uint(2)).Return(models.Product{ID: 2, Name: "cup", Price: 25}, nil)
+		product, err = repo.GetProduct(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, 25, product.Price)
+	})
+}
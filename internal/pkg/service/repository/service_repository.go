@@ -4,6 +4,8 @@ import (
 	"Merch_store-Avito_test_task/internal/models"
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 )
 
@@ -15,66 +17,82 @@ func NewServiceRepo(db *sql.DB) *ServiceRepoImpl {
 	return &ServiceRepoImpl{db}
 }
 
-func (r *ServiceRepoImpl) GetUserInfo(ctx context.Context, userID uint) (models.UserData, error) {
-	var data models.UserData
-	query := `SELECT coins from "user" WHERE id = $1`
-	row := r.db.QueryRowContext(ctx, query, userID)
-	err := row.Scan(&data.Coins)
-	if err != nil {
-		return models.UserData{}, fmt.Errorf("failed to get balance: %w", err)
-	}
-
-	query = `SELECT p.name, COUNT(p.id)
+// userInfoQuery reads the balance, inventory and coin history in one statement, so they all come
+// from the same snapshot and a concurrent transfer cannot make them disagree. The lists are
+// aggregated to JSON arrays, empty ones included.
+const userInfoQuery = `WITH inventory AS (
+		SELECT p.name, COUNT(*) AS quantity
 		FROM "purchase" pu
 		JOIN "product" p ON pu.product_id = p.id
 		WHERE pu.user_id = $1
-		GROUP BY p.name`
-	rows, err := r.db.QueryContext(ctx, query, userID)
+		GROUP BY p.name
+	), received AS (
+		SELECT u.username, t.amount, t.created_at
+		FROM "transaction" t
+		JOIN "user" u ON t.from_user_id = u.id
+		WHERE t.to_user_id = $1
+	), sent AS (
+		SELECT u.username, t.amount, t.created_at
+		FROM "transaction" t
+		JOIN "user" u ON t.to_user_id = u.id
+		WHERE t.from_user_id = $1
+	)
+	SELECT u.coins,
+		(SELECT COALESCE(json_agg(json_build_object('type', name, 'quantity', quantity) ORDER BY name), '[]') FROM inventory),
+		(SELECT COALESCE(json_agg(json_build_object('fromUser', username, 'amount', amount) ORDER BY created_at DESC), '[]') FROM received),
+		(SELECT COALESCE(json_agg(json_build_object('toUser', username, 'amount', amount) ORDER BY created_at DESC), '[]') FROM sent)
+	FROM "user" u
+	WHERE u.id = $1`
+
+func (r *ServiceRepoImpl) GetUserInfo(ctx context.Context, userID uint) (models.UserData, error) {
+	var data models.UserData
+	var inventory, received, sent []byte
+	err := r.db.QueryRowContext(ctx, userInfoQuery, userID).Scan(&data.Coins, &inventory, &received, &sent)
 	if err != nil {
-		return models.UserData{}, fmt.Errorf("failed to get purchases: %w", err)
+		return models.UserData{}, fmt.Errorf("failed to get user info: %w", err)
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var inventory models.Inventory
-		if err = rows.Scan(&inventory.Type, &inventory.Quantity); err != nil {
-			return models.UserData{}, fmt.Errorf("failed to scan inventory: %w", err)
-		}
-		data.Inventory = append(data.Inventory, inventory)
+	if err = json.Unmarshal(inventory, &data.Inventory); err != nil {
+		return models.UserData{}, fmt.Errorf("failed to decode inventory: %w", err)
+	}
+	if err = json.Unmarshal(received, &data.CoinHistory.Received); err != nil {
+		return models.UserData{}, fmt.Errorf("failed to decode transactions: %w", err)
 	}
+	if err = json.Unmarshal(sent, &data.CoinHistory.Sent); err != nil {
+		return models.UserData{}, fmt.Errorf("failed to decode transactions: %w", err)
+	}
+	return data, nil
+}
 
-	query = `SELECT u.username, t.amount
-		FROM transaction t
-		JOIN "user" u ON t.from_user_id = u.id
-		WHERE t.to_user_id = $1 ORDER BY t.created_at DESC`
-	rows, err = r.db.QueryContext(ctx, query, userID)
+func (r *ServiceRepoImpl) ListProducts(ctx context.Context) ([]models.Product, error) {
+	query := `SELECT id, name, price FROM "product" WHERE retired_at IS NULL ORDER BY id`
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
-		return models.UserData{}, fmt.Errorf("failed to get transactions: %w", err)
+		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
 	defer rows.Close()
+	products := []models.Product{}
 	for rows.Next() {
-		var transaction models.Transaction
-		err = rows.Scan(&transaction.FromUser, &transaction.Amount)
-		if err != nil {
-			return models.UserData{}, fmt.Errorf("failed to scan transactions: %w", err)
+		var product models.Product
+		if err = rows.Scan(&product.ID, &product.Name, &product.Price); err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
 		}
-		data.CoinHistory.Received = append(data.CoinHistory.Received, transaction)
+		products = append(products, product)
 	}
-	query = `SELECT u.username, t.amount
-		FROM transaction t
-		JOIN "user" u ON t.to_user_id = u.id
-		WHERE t.from_user_id = $1 ORDER BY t.created_at DESC`
-	rows, err = r.db.QueryContext(ctx, query, userID)
-	if err != nil {
-		return models.UserData{}, fmt.Errorf("failed to get transactions: %w", err)
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
 	}
-	defer rows.Close()
-	for rows.Next() {
-		var transaction models.Transaction
-		err = rows.Scan(&transaction.ToUser, &transaction.Amount)
-		if err != nil {
-			return models.UserData{}, fmt.Errorf("failed to scan transactions: %w", err)
-		}
-		data.CoinHistory.Sent = append(data.CoinHistory.Sent, transaction)
+	return products, nil
+}
+
+func (r *ServiceRepoImpl) GetProduct(ctx context.Context, productID uint) (models.Product, error) {
+	product := models.Product{ID: productID}
+	query := `SELECT name, price FROM "product" WHERE id = $1 AND retired_at IS NULL`
+	err := r.db.QueryRowContext(ctx, query, productID).Scan(&product.Name, &product.Price)
+	if errors.Is(err, sql.ErrNoRows) {
+		return models.Product{}, fmt.Errorf("product %d: %w", productID, models.ErrNotFound)
 	}
-	return data, nil
+	if err != nil {
+		return models.Product{}, fmt.Errorf("failed to get product: %w", err)
+	}
+	return product, nil
 }
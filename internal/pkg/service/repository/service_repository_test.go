@@ -5,130 +5,135 @@ import (
 	"database/sql"
 	"testing"
 
+	"Merch_store-Avito_test_task/internal/models"
+
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestGetUserInfo(t *testing.T) {
-	// Создаём мок базы данных
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	defer db.Close()
 
 	repo := NewServiceRepo(db)
 	userID := uint(1)
+	ctx := context.Background()
 
 	t.Run("Successful user info retrieval", func(t *testing.T) {
-		ctx := context.Background()
-
-		mock.ExpectQuery(`SELECT coins from "user" WHERE id = \$1`).
-			WithArgs(userID).
-			WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
-
-		mock.ExpectQuery(`SELECT p.name, COUNT\(p.id\) FROM "purchase" pu JOIN "product" p ON pu.product_id = p.id WHERE pu.user_id = \$1 GROUP BY p.name`).
+		mock.ExpectQuery(`WITH inventory AS \(.+\) SELECT u.coins, .+ FROM "user" u\s+WHERE u.id = \$1`).
 			WithArgs(userID).
-			WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).
-				AddRow("Sword", 2).
-				AddRow("Shield", 1))
+			WillReturnRows(sqlmock.NewRows([]string{"coins", "inventory", "received", "sent"}).AddRow(1000,
+				`[{"type":"Shield","quantity":1},{"type":"Sword","quantity":2}]`,
+				`[{"fromUser":"Alice","amount":500},{"fromUser":"Bob","amount":300}]`,
+				`[{"toUser":"Charlie","amount":200}]`))
 
-		mock.ExpectQuery(`SELECT u.username, t.amount FROM transaction t JOIN "user" u ON t.from_user_id = u.id WHERE t.to_user_id = \$1 ORDER BY t.created_at DESC`).
-			WithArgs(userID).
-			WillReturnRows(sqlmock.NewRows([]string{"username", "amount"}).
-				AddRow("Alice", 500).
-				AddRow("Bob", 300))
+		data, err := repo.GetUserInfo(ctx, userID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.UserData{
+			Coins:     1000,
+			Inventory: []models.Inventory{{Type: "Shield", Quantity: 1}, {Type: "Sword", Quantity: 2}},
+			CoinHistory: models.CoinHistory{
+				Received: []models.Transaction{{FromUser: "Alice", Amount: 500}, {FromUser: "Bob", Amount: 300}},
+				Sent:     []models.Transaction{{ToUser: "Charlie", Amount: 200}},
+			},
+		}, data)
+	})
 
-		mock.ExpectQuery(`SELECT u.username, t.amount FROM transaction t JOIN "user" u ON t.to_user_id = u.id WHERE t.from_user_id = \$1 ORDER BY t.created_at DESC`).
+	t.Run("Empty lists are not null", func(t *testing.T) {
+		mock.ExpectQuery(`WITH inventory AS`).
 			WithArgs(userID).
-			WillReturnRows(sqlmock.NewRows([]string{"username", "amount"}).
-				AddRow("Charlie", 200).
-				AddRow("David", 100))
+			WillReturnRows(sqlmock.NewRows([]string{"coins", "inventory", "received", "sent"}).AddRow(1000, `[]`, `[]`, `[]`))
 
 		data, err := repo.GetUserInfo(ctx, userID)
-
 		assert.NoError(t, err)
-
-		assert.Equal(t, 1000, data.Coins)
-
-		assert.Len(t, data.Inventory, 2)
-		assert.Equal(t, "Sword", data.Inventory[0].Type)
-		assert.Equal(t, 2, data.Inventory[0].Quantity)
-		assert.Equal(t, "Shield", data.Inventory[1].Type)
-		assert.Equal(t, 1, data.Inventory[1].Quantity)
-
-		assert.Len(t, data.CoinHistory.Received, 2)
-		assert.Equal(t, "Alice", data.CoinHistory.Received[0].FromUser)
-		assert.Equal(t, 500, data.CoinHistory.Received[0].Amount)
-		assert.Equal(t, "Bob", data.CoinHistory.Received[1].FromUser)
-		assert.Equal(t, 300, data.CoinHistory.Received[1].Amount)
-
-		assert.Len(t, data.CoinHistory.Sent, 2)
-		assert.Equal(t, "Charlie", data.CoinHistory.Sent[0].ToUser)
-		assert.Equal(t, 200, data.CoinHistory.Sent[0].Amount)
-		assert.Equal(t, "David", data.CoinHistory.Sent[1].ToUser)
-		assert.Equal(t, 100, data.CoinHistory.Sent[1].Amount)
+		assert.NotNil(t, data.Inventory)
+		assert.NotNil(t, data.CoinHistory.Received)
+		assert.NotNil(t, data.CoinHistory.Sent)
 	})
 
 	t.Run("User not found", func(t *testing.T) {
-		ctx := context.Background()
-
-		mock.ExpectQuery(`SELECT coins from "user" WHERE id = \$1`).
+		mock.ExpectQuery(`WITH inventory AS`).
 			WithArgs(userID).
 			WillReturnError(sql.ErrNoRows)
 
 		data, err := repo.GetUserInfo(ctx, userID)
-
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrNoRows)
 		assert.Empty(t, data)
 	})
 
-	t.Run("Database error on balance retrieval", func(t *testing.T) {
-		ctx := context.Background()
-
-		mock.ExpectQuery(`SELECT coins from "user" WHERE id = \$1`).
+	t.Run("Database error", func(t *testing.T) {
+		mock.ExpectQuery(`WITH inventory AS`).
 			WithArgs(userID).
 			WillReturnError(sql.ErrConnDone)
 
 		data, err := repo.GetUserInfo(ctx, userID)
-
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
 		assert.Empty(t, data)
 	})
 
-	t.Run("Database error on purchase retrieval", func(t *testing.T) {
-		ctx := context.Background()
-
-		mock.ExpectQuery(`SELECT coins from "user" WHERE id = \$1`).
+	t.Run("Malformed aggregate", func(t *testing.T) {
+		mock.ExpectQuery(`WITH inventory AS`).
 			WithArgs(userID).
-			WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+			WillReturnRows(sqlmock.NewRows([]string{"coins", "inventory", "received", "sent"}).AddRow(1000, `{`, `[]`, `[]`))
 
-		mock.ExpectQuery(`SELECT p.name, COUNT\(p.id\) FROM "purchase" pu JOIN "product" p ON pu.product_id = p.id WHERE pu.user_id = \$1 GROUP BY p.name`).
-			WithArgs(userID).
-			WillReturnError(sql.ErrConnDone)
+		_, err := repo.GetUserInfo(ctx, userID)
+		assert.ErrorContains(t, err, "failed to decode inventory")
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
 
-		data, err := repo.GetUserInfo(ctx, userID)
+func TestListProducts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
 
-		assert.Error(t, err)
-		assert.Empty(t, data)
+	repo := NewServiceRepo(db)
+	ctx := context.Background()
+
+	t.Run("Products ordered by id", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, name, price FROM "product" WHERE retired_at IS NULL ORDER BY id`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "price"}).
+				AddRow(1, "t-shirt", 80).
+				AddRow(2, "cup", 20))
+
+		products, err := repo.ListProducts(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.Product{{ID: 1, Name: "t-shirt", Price: 80}, {ID: 2, Name: "cup", Price: 20}}, products)
 	})
 
-	t.Run("Database error on transaction retrieval", func(t *testing.T) {
-		ctx := context.Background()
+	t.Run("Database error", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, name, price FROM "product"`).WillReturnError(sql.ErrConnDone)
 
-		mock.ExpectQuery(`SELECT coins from "user" WHERE id = \$1`).
-			WithArgs(userID).
-			WillReturnRows(sqlmock.NewRows([]string{"coins"}).AddRow(1000))
+		_, err := repo.ListProducts(ctx)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+	})
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
 
-		mock.ExpectQuery(`SELECT p.name, COUNT\(p.id\) FROM "purchase" pu JOIN "product" p ON pu.product_id = p.id WHERE pu.user_id = \$1 GROUP BY p.name`).
-			WithArgs(userID).
-			WillReturnRows(sqlmock.NewRows([]string{"name", "count"}).AddRow("Sword", 2))
+func TestGetProduct(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
 
-		mock.ExpectQuery(`SELECT u.username, t.amount FROM transaction t JOIN "user" u ON t.from_user_id = u.id WHERE t.to_user_id = \$1 ORDER BY t.created_at DESC`).
-			WithArgs(userID).
-			WillReturnError(sql.ErrConnDone)
+	repo := NewServiceRepo(db)
+	ctx := context.Background()
 
-		data, err := repo.GetUserInfo(ctx, userID)
+	t.Run("Active product", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT name, price FROM "product" WHERE id = \$1 AND retired_at IS NULL`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows([]string{"name", "price"}).AddRow("cup", 20))
 
-		assert.Error(t, err)
-		assert.Empty(t, data)
+		product, err := repo.GetProduct(ctx, 2)
+		assert.NoError(t, err)
+		assert.Equal(t, models.Product{ID: 2, Name: "cup", Price: 20}, product)
+	})
+
+	t.Run("Unknown or retired product", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT name, price FROM "product"`).WithArgs(9).WillReturnError(sql.ErrNoRows)
+
+		_, err := repo.GetProduct(ctx, 9)
+		assert.ErrorIs(t, err, models.ErrNotFound)
 	})
+	assert.NoError(t, mock.ExpectationsWereMet())
 }
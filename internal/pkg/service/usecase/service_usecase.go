@@ -2,7 +2,6 @@ package service
 
 import (
 	"Merch_store-Avito_test_task/internal/models"
-	"Merch_store-Avito_test_task/internal/pkg/middleware"
 	"Merch_store-Avito_test_task/internal/pkg/service"
 	"context"
 )
@@ -15,7 +14,10 @@ func NewServiceUsecase(repo service.ServiceRepository) *ServiceUsecaseImpl {
 	return &ServiceUsecaseImpl{repo}
 }
 
-func (u *ServiceUsecaseImpl) GetUserInfo(ctx context.Context) (models.UserData, error) {
-	userID := ctx.Value(middleware.IdKey).(uint)
+func (u *ServiceUsecaseImpl) GetUserInfo(ctx context.Context, userID uint) (models.UserData, error) {
 	return u.repo.GetUserInfo(ctx, userID)
 }
+
+func (u *ServiceUsecaseImpl) ListProducts(ctx context.Context) ([]models.Product, error) {
+	return u.repo.ListProducts(ctx)
+}
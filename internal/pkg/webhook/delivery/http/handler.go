@@ -0,0 +1,133 @@
+package http
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/httpresponses"
+	"Merch_store-Avito_test_task/internal/pkg/webhook"
+	"encoding/json"
+	"github.com/gorilla/mux"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+var (
+	errInvalidBody  = httpresponses.NewError(http.StatusBadRequest, httpresponses.CodeInvalidRequest, "invalid request body")
+	errInvalidID    = httpresponses.NewError(http.StatusBadRequest, httpresponses.CodeInvalidRequest, "invalid id")
+	errInvalidLimit = httpresponses.NewError(http.StatusBadRequest, httpresponses.CodeInvalidRequest, "invalid limit")
+)
+
+type WebhookHandler struct {
+	uc     webhook.WebhookUsecase
+	logger *slog.Logger
+}
+
+func NewWebhookHandler(uc webhook.WebhookUsecase, logger *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{uc: uc, logger: logger}
+}
+
+// Subscribe creates a subscription. The signing secret is part of this response only.
+func (h *WebhookHandler) Subscribe(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var data struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+		Secret string   `json:"secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		h.logger.WarnContext(ctx, "failed to decode webhook subscription", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, errInvalidBody, h.logger)
+		return
+	}
+	sub, err := h.uc.Subscribe(ctx, data.URL, data.Events, data.Secret)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to create webhook subscription", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	h.logger.InfoContext(ctx, "webhook subscription created", slog.Int("subscriptionID", int(sub.ID)), slog.String("url", sub.URL))
+	httpresponses.SendJSONResponse(ctx, w, sub, http.StatusCreated, h.logger)
+}
+
+func (h *WebhookHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	subs, err := h.uc.List(ctx)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to list webhook subscriptions", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	httpresponses.SendJSONResponse(ctx, w, struct {
+		Subscriptions []models.WebhookSubscription `json:"subscriptions"`
+	}{subs}, http.StatusOK, h.logger)
+}
+
+func (h *WebhookHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	if err := h.uc.Delete(ctx, uint(id)); err != nil {
+		h.logger.ErrorContext(ctx, "failed to delete webhook subscription", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	h.logger.InfoContext(ctx, "webhook subscription deleted", slog.Int64("subscriptionID", id))
+	httpresponses.SendJSONResponse(ctx, w, struct{}{}, http.StatusOK, h.logger)
+}
+
+// Deliveries returns the delivery log of a subscription, newest first.
+func (h *WebhookHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	limit := 0
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil || limit <= 0 {
+			httpresponses.SendError(w, r, errInvalidLimit, h.logger)
+			return
+		}
+	}
+	deliveries, err := h.uc.Deliveries(ctx, uint(id), limit)
+	if err != nil {
+		h.logger.ErrorContext(ctx, "failed to list webhook deliveries", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	httpresponses.SendJSONResponse(ctx, w, struct {
+		Deliveries []models.WebhookDelivery `json:"deliveries"`
+	}{deliveries}, http.StatusOK, h.logger)
+}
+
+func (h *WebhookHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, ok := h.pathID(w, r, "id")
+	if !ok {
+		return
+	}
+	deliveryID, ok := h.pathID(w, r, "delivery")
+	if !ok {
+		return
+	}
+	if err := h.uc.Redeliver(ctx, uint(id), deliveryID); err != nil {
+		h.logger.ErrorContext(ctx, "failed to schedule webhook redelivery", slog.String("error", err.Error()))
+		httpresponses.SendError(w, r, err, h.logger)
+		return
+	}
+	h.logger.InfoContext(ctx, "webhook redelivery scheduled", slog.Int64("deliveryID", deliveryID))
+	httpresponses.SendJSONResponse(ctx, w, struct{}{}, http.StatusAccepted, h.logger)
+}
+
+func (h *WebhookHandler) pathID(w http.ResponseWriter, r *http.Request, name string) (int64, bool) {
+	id, err := strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+	if err != nil || id <= 0 {
+		h.logger.WarnContext(r.Context(), "invalid path id", slog.String("param", name))
+		httpresponses.SendError(w, r, errInvalidID, h.logger)
+		return 0, false
+	}
+	return id, true
+}
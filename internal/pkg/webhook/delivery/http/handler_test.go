@@ -0,0 +1,137 @@
+package http
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"Merch_store-Avito_test_task/internal/models"
+	mocks "Merch_store-Avito_test_task/internal/pkg/webhook/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHandler(t *testing.T) (*WebhookHandler, *mocks.MockWebhookUsecase) {
+	ctrl := gomock.NewController(t)
+	t.Cleanup(ctrl.Finish)
+	mockUsecase := mocks.NewMockWebhookUsecase(ctrl)
+	return NewWebhookHandler(mockUsecase, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))), mockUsecase
+}
+
+func TestSubscribe(t *testing.T) {
+	handler, mockUsecase := newTestHandler(t)
+
+	tests := []struct {
+		name           string
+		body           string
+		setupMock      func()
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name: "Created with secret",
+			body: `{"url":"https://hooks.example.com","events":["item.purchased"]}`,
+			setupMock: func() {
+				mockUsecase.EXPECT().Subscribe(gomock.Any(), "https://hooks.example.com", []string{models.EventItemPurchased}, "").
+					Return(models.WebhookSubscription{ID: 1, URL: "https://hooks.example.com", Events: []string{models.EventItemPurchased}, Secret: "whsec_x"}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `"secret":"whsec_x"`,
+		},
+		{
+			name: "Invalid url",
+			body: `{"url":"nope","events":["*"]}`,
+			setupMock: func() {
+				mockUsecase.EXPECT().Subscribe(gomock.Any(), "nope", []string{"*"}, "").Return(models.WebhookSubscription{}, models.ErrInvalidInput)
+			},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"code":"invalid_request"`,
+		},
+		{
+			name:           "Malformed body",
+			body:           `{"url":`,
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   `"message":"invalid request body"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+			rr := httptest.NewRecorder()
+			handler.Subscribe(rr, httptest.NewRequest(http.MethodPost, "/api/admin/webhooks", strings.NewReader(tt.body)))
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.expectedBody)
+		})
+	}
+}
+
+func TestDeliveries(t *testing.T) {
+	handler, mockUsecase := newTestHandler(t)
+
+	t.Run("Delivery log", func(t *testing.T) {
+		mockUsecase.EXPECT().Deliveries(gomock.Any(), uint(1), 10).
+			Return([]models.WebhookDelivery{{ID: 4, SubscriptionID: 1, Status: models.DeliveryFailed, LastStatusCode: 500}}, nil)
+
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/admin/webhooks/1/deliveries?limit=10", nil), map[string]string{"id": "1"})
+		rr := httptest.NewRecorder()
+		handler.Deliveries(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code)
+		assert.Contains(t, rr.Body.String(), `"lastStatusCode":500`)
+	})
+
+	t.Run("Invalid limit", func(t *testing.T) {
+		req := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/api/admin/webhooks/1/deliveries?limit=-1", nil), map[string]string{"id": "1"})
+		rr := httptest.NewRecorder()
+		handler.Deliveries(rr, req)
+
+		assert.Equal(t, http.StatusBadRequest, rr.Code)
+	})
+}
+
+func TestRedeliver(t *testing.T) {
+	handler, mockUsecase := newTestHandler(t)
+
+	tests := []struct {
+		name           string
+		vars           map[string]string
+		setupMock      func()
+		expectedStatus int
+	}{
+		{
+			name:           "Scheduled",
+			vars:           map[string]string{"id": "1", "delivery": "40"},
+			setupMock:      func() { mockUsecase.EXPECT().Redeliver(gomock.Any(), uint(1), int64(40)).Return(nil) },
+			expectedStatus: http.StatusAccepted,
+		},
+		{
+			name:           "Unknown delivery",
+			vars:           map[string]string{"id": "1", "delivery": "41"},
+			setupMock:      func() { mockUsecase.EXPECT().Redeliver(gomock.Any(), uint(1), int64(41)).Return(models.ErrNotFound) },
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:           "Invalid delivery id",
+			vars:           map[string]string{"id": "1", "delivery": "x"},
+			setupMock:      func() {},
+			expectedStatus: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.setupMock()
+			req := mux.SetURLVars(httptest.NewRequest(http.MethodPost, "/api/admin/webhooks/1/deliveries/40/redeliver", nil), tt.vars)
+			rr := httptest.NewRecorder()
+			handler.Redeliver(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
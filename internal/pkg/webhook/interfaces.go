@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"context"
+	"time"
+)
+
+//go:generate mockgen -source=interfaces.go -destination=mocks/mock.go
+type WebhookUsecase interface {
+	// Subscribe registers url for events; an empty secret is generated. The result carries the secret.
+	Subscribe(ctx context.Context, url string, events []string, secret string) (models.WebhookSubscription, error)
+	List(ctx context.Context) ([]models.WebhookSubscription, error)
+	Delete(ctx context.Context, id uint) error
+	Deliveries(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error)
+	// Redeliver schedules a delivery to be sent again right away with a fresh retry budget.
+	Redeliver(ctx context.Context, subscriptionID uint, deliveryID int64) error
+	// Publish queues event for every matching subscription; it makes the usecase an outbox publisher.
+	Publish(ctx context.Context, event models.Event) error
+}
+
+type WebhookRepository interface {
+	CreateSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uint) error
+	// EnqueueDeliveries creates a delivery of body per matching subscription. Events already queued are
+	// skipped, so publishing the same event twice is harmless.
+	EnqueueDeliveries(ctx context.Context, event models.Event, body []byte) (int64, error)
+	ListDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error)
+	Redeliver(ctx context.Context, subscriptionID uint, deliveryID int64) error
+
+	// Claim returns up to limit due deliveries and hides them from other dispatchers for lease.
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]Job, error)
+	MarkDelivered(ctx context.Context, id int64, statusCode int) error
+	MarkFailed(ctx context.Context, id int64, statusCode int, reason string, retryIn time.Duration, dead bool) error
+}
+
+// Job is a claimed delivery together with what is needed to send it.
+type Job struct {
+	DeliveryID int64
+	EventType  string
+	Attempts   int
+	URL        string
+	Secret     string
+	Body       []byte
+}
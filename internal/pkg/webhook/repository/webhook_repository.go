@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/webhook"
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/lib/pq"
+	"slices"
+	"time"
+)
+
+type WebhookRepositoryImpl struct {
+	db *sql.DB
+}
+
+func NewWebhookRepositoryImpl(db *sql.DB) *WebhookRepositoryImpl {
+	return &WebhookRepositoryImpl{db: db}
+}
+
+func (repo *WebhookRepositoryImpl) CreateSubscription(ctx context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+	query := `INSERT INTO "webhook_subscription" (url, events, secret) VALUES ($1, $2, $3) RETURNING id, created_at`
+	err := repo.db.QueryRowContext(ctx, query, sub.URL, pq.Array(sub.Events), sub.Secret).Scan(&sub.ID, &sub.CreatedAt)
+	if err != nil {
+		return models.WebhookSubscription{}, fmt.Errorf("creating webhook subscription failed: %w", err)
+	}
+	return sub, nil
+}
+
+// ListSubscriptions returns every subscription without its secret.
+func (repo *WebhookRepositoryImpl) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	rows, err := repo.db.QueryContext(ctx, `SELECT id, url, events, created_at FROM "webhook_subscription" ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook subscriptions failed: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []models.WebhookSubscription{}
+	for rows.Next() {
+		var sub models.WebhookSubscription
+		if err = rows.Scan(&sub.ID, &sub.URL, pq.Array(&sub.Events), &sub.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scanning webhook subscription failed: %w", err)
+		}
+		subs = append(subs, sub)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing webhook subscriptions failed: %w", err)
+	}
+	return subs, nil
+}
+
+// DeleteSubscription removes a subscription together with its delivery log.
+func (repo *WebhookRepositoryImpl) DeleteSubscription(ctx context.Context, id uint) error {
+	res, err := repo.db.ExecContext(ctx, `DELETE FROM "webhook_subscription" WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("deleting webhook subscription failed: %w", err)
+	}
+	return checkAffected(res, "webhook subscription", int64(id))
+}
+
+func (repo *WebhookRepositoryImpl) EnqueueDeliveries(ctx context.Context, event models.Event, body []byte) (int64, error) {
+	query := `INSERT INTO "webhook_delivery" (subscription_id, event_id, event_type, body)
+		SELECT id, $1, $2, $3 FROM "webhook_subscription" WHERE $2 = ANY(events) OR '*' = ANY(events)
+		ON CONFLICT (subscription_id, event_id) DO NOTHING`
+	res, err := repo.db.ExecContext(ctx, query, event.ID, event.Type, body)
+	if err != nil {
+		return 0, fmt.Errorf("queueing webhook deliveries failed: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// ListDeliveries returns the latest deliveries of a subscription, newest first.
+func (repo *WebhookRepositoryImpl) ListDeliveries(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error) {
+	query := `SELECT id, subscription_id, event_id, event_type, status, attempts, last_status_code, last_error, created_at, delivered_at
+		FROM "webhook_delivery" WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2`
+	rows, err := repo.db.QueryContext(ctx, query, subscriptionID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("listing webhook deliveries failed: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.WebhookDelivery{}
+	for rows.Next() {
+		var d models.WebhookDelivery
+		var statusCode sql.NullInt64
+		var lastError sql.NullString
+		var deliveredAt sql.NullTime
+		err = rows.Scan(&d.ID, &d.SubscriptionID, &d.EventID, &d.EventType, &d.Status, &d.Attempts, &statusCode, &lastError,
+			&d.CreatedAt, &deliveredAt)
+		if err != nil {
+			return nil, fmt.Errorf("scanning webhook delivery failed: %w", err)
+		}
+		d.LastStatusCode = int(statusCode.Int64)
+		d.LastError = lastError.String
+		if deliveredAt.Valid {
+			d.DeliveredAt = &deliveredAt.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("listing webhook deliveries failed: %w", err)
+	}
+	return deliveries, nil
+}
+
+func (repo *WebhookRepositoryImpl) Redeliver(ctx context.Context, subscriptionID uint, deliveryID int64) error {
+	query := `UPDATE "webhook_delivery" SET status = 'pending', attempts = 0, next_attempt_at = NOW()
+		WHERE id = $1 AND subscription_id = $2`
+	res, err := repo.db.ExecContext(ctx, query, deliveryID, subscriptionID)
+	if err != nil {
+		return fmt.Errorf("scheduling webhook redelivery failed: %w", err)
+	}
+	return checkAffected(res, "webhook delivery", deliveryID)
+}
+
+// Claim leases due deliveries the same way the outbox relay leases events.
+func (repo *WebhookRepositoryImpl) Claim(ctx context.Context, limit int, lease time.Duration) ([]webhook.Job, error) {
+	query := `UPDATE "webhook_delivery" d SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM "webhook_subscription" s
+		WHERE s.id = d.subscription_id AND d.id IN (
+			SELECT id FROM "webhook_delivery" WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED
+		)
+		RETURNING d.id, d.event_type, d.attempts, d.body, s.url, s.secret`
+	rows, err := repo.db.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("claiming webhook deliveries failed: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []webhook.Job
+	for rows.Next() {
+		var job webhook.Job
+		if err = rows.Scan(&job.DeliveryID, &job.EventType, &job.Attempts, &job.Body, &job.URL, &job.Secret); err != nil {
+			return nil, fmt.Errorf("scanning webhook delivery failed: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("claiming webhook deliveries failed: %w", err)
+	}
+	slices.SortFunc(jobs, func(a, b webhook.Job) int { return int(a.DeliveryID - b.DeliveryID) })
+	return jobs, nil
+}
+
+func (repo *WebhookRepositoryImpl) MarkDelivered(ctx context.Context, id int64, statusCode int) error {
+	query := `UPDATE "webhook_delivery" SET status = 'delivered', attempts = attempts + 1, last_status_code = $2,
+		last_error = NULL, delivered_at = NOW() WHERE id = $1`
+	if _, err := repo.db.ExecContext(ctx, query, id, statusCode); err != nil {
+		return fmt.Errorf("marking webhook delivery %d delivered failed: %w", id, err)
+	}
+	return nil
+}
+
+// MarkFailed records a failed attempt. A zero statusCode means no response was received.
+func (repo *WebhookRepositoryImpl) MarkFailed(ctx context.Context, id int64, statusCode int, reason string, retryIn time.Duration, dead bool) error {
+	status := models.DeliveryPending
+	if dead {
+		status = models.DeliveryFailed
+	}
+	query := `UPDATE "webhook_delivery" SET status = $2, attempts = attempts + 1, last_status_code = NULLIF($3, 0),
+		last_error = $4, next_attempt_at = NOW() + make_interval(secs => $5) WHERE id = $1`
+	if _, err := repo.db.ExecContext(ctx, query, id, status, statusCode, reason, retryIn.Seconds()); err != nil {
+		return fmt.Errorf("marking webhook delivery %d failed: %w", id, err)
+	}
+	return nil
+}
+
+func checkAffected(res sql.Result, what string, id int64) error {
+	rowsAffected, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("getting rows affected failed: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%s %d: %w", what, id, models.ErrNotFound)
+	}
+	return nil
+}
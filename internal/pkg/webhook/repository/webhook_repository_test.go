@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/models"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRepository(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	defer db.Close()
+
+	repo := NewWebhookRepositoryImpl(db)
+	ctx := context.Background()
+	created := time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC)
+
+	t.Run("CreateSubscription", func(t *testing.T) {
+		mock.ExpectQuery(`INSERT INTO "webhook_subscription" \(url, events, secret\) VALUES \(\$1, \$2, \$3\) RETURNING id, created_at`).
+			WithArgs("https://hooks.example.com", `{"*"}`, "whsec_secret").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, created))
+
+		sub, err := repo.CreateSubscription(ctx, models.WebhookSubscription{URL: "https://hooks.example.com", Events: []string{"*"}, Secret: "whsec_secret"})
+		assert.NoError(t, err)
+		assert.Equal(t, uint(1), sub.ID)
+		assert.Equal(t, "whsec_secret", sub.Secret)
+	})
+
+	t.Run("ListSubscriptions leaves out secrets", func(t *testing.T) {
+		mock.ExpectQuery(`SELECT id, url, events, created_at FROM "webhook_subscription" ORDER BY id`).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "url", "events", "created_at"}).
+				AddRow(1, "https://hooks.example.com", "{item.purchased}", created))
+
+		subs, err := repo.ListSubscriptions(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []models.WebhookSubscription{{ID: 1, URL: "https://hooks.example.com", Events: []string{models.EventItemPurchased}, CreatedAt: created}}, subs)
+	})
+
+	t.Run("DeleteSubscription unknown", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM "webhook_subscription" WHERE id = \$1`).WithArgs(9).WillReturnResult(sqlmock.NewResult(0, 0))
+		assert.ErrorIs(t, repo.DeleteSubscription(ctx, 9), models.ErrNotFound)
+	})
+
+	t.Run("EnqueueDeliveries fans out idempotently", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO "webhook_delivery" \(subscription_id, event_id, event_type, body\)\s+SELECT id, \$1, \$2, \$3 FROM "webhook_subscription" WHERE \$2 = ANY\(events\) OR '\*' = ANY\(events\)\s+ON CONFLICT \(subscription_id, event_id\) DO NOTHING`).
+			WithArgs(5, models.EventItemPurchased, []byte(`{}`)).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+
+		n, err := repo.EnqueueDeliveries(ctx, models.Event{ID: 5, Type: models.EventItemPurchased}, []byte(`{}`))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), n)
+	})
+
+	t.Run("ListDeliveries", func(t *testing.T) {
+		mock.ExpectQuery(`FROM "webhook_delivery" WHERE subscription_id = \$1 ORDER BY id DESC LIMIT \$2`).
+			WithArgs(1, 20).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "subscription_id", "event_id", "event_type", "status", "attempts",
+				"last_status_code", "last_error", "created_at", "delivered_at"}).
+				AddRow(12, 1, 5, models.EventItemPurchased, models.DeliveryFailed, 8, 503, "unexpected status 503", created, nil).
+				AddRow(11, 1, 4, models.EventItemPurchased, models.DeliveryDelivered, 1, 200, nil, created, created))
+
+		deliveries, err := repo.ListDeliveries(ctx, 1, 20)
+		assert.NoError(t, err)
+		assert.Len(t, deliveries, 2)
+		assert.Equal(t, 503, deliveries[0].LastStatusCode)
+		assert.Nil(t, deliveries[0].DeliveredAt)
+		assert.Equal(t, &created, deliveries[1].DeliveredAt)
+	})
+
+	t.Run("Redeliver of another subscription's delivery", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "webhook_delivery" SET status = 'pending', attempts = 0, next_attempt_at = NOW\(\)\s+WHERE id = \$1 AND subscription_id = \$2`).
+			WithArgs(12, 2).WillReturnResult(sqlmock.NewResult(0, 0))
+		assert.ErrorIs(t, repo.Redeliver(ctx, 2, 12), models.ErrNotFound)
+	})
+
+	t.Run("Claim", func(t *testing.T) {
+		mock.ExpectQuery(`UPDATE "webhook_delivery" d SET next_attempt_at = NOW\(\) \+ make_interval\(secs => \$2\)\s+FROM "webhook_subscription" s`).
+			WithArgs(10, 60.0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "attempts", "body", "url", "secret"}).
+				AddRow(13, models.EventItemPurchased, 0, []byte(`{}`), "https://b.example.com", "s2").
+				AddRow(12, models.EventItemPurchased, 3, []byte(`{}`), "https://a.example.com", "s1"))
+
+		jobs, err := repo.Claim(ctx, 10, time.Minute)
+		assert.NoError(t, err)
+		assert.Len(t, jobs, 2)
+		assert.Equal(t, int64(12), jobs[0].DeliveryID)
+		assert.Equal(t, "s1", jobs[0].Secret)
+	})
+
+	t.Run("MarkFailed without response", func(t *testing.T) {
+		mock.ExpectExec(`UPDATE "webhook_delivery" SET status = \$2, attempts = attempts \+ 1, last_status_code = NULLIF\(\$3, 0\)`).
+			WithArgs(12, models.DeliveryPending, 0, "connection refused", 10.0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		assert.NoError(t, repo.MarkFailed(ctx, 12, 0, "connection refused", 10*time.Second, false))
+	})
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,35 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1738400000, 0)
+	body := []byte(`{"id":1,"type":"item.purchased"}`)
+	signature := Sign("whsec_0123456789abcdef", now, body)
+
+	assert.Regexp(t, `^t=1738400000,v1=[0-9a-f]{64}$`, signature)
+	assert.NoError(t, Verify("whsec_0123456789abcdef", signature, body, 5*time.Minute, now.Add(time.Minute)))
+
+	tests := []struct {
+		name      string
+		secret    string
+		signature string
+		body      []byte
+		now       time.Time
+	}{
+		{"Wrong secret", "another-secret-value", signature, body, now},
+		{"Tampered body", "whsec_0123456789abcdef", signature, []byte(`{"id":2}`), now},
+		{"Replayed late", "whsec_0123456789abcdef", signature, body, now.Add(time.Hour)},
+		{"Garbage", "whsec_0123456789abcdef", "v1=zz", body, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Error(t, Verify(tt.secret, tt.signature, tt.body, 5*time.Minute, tt.now))
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package usecase
+
+import (
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/webhook"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxResponseBody bounds how much of a receiver's response is read before the connection is reused.
+const maxResponseBody = 64 << 10
+
+// Dispatcher sends queued webhook deliveries. A 2xx response counts as delivered; anything else,
+// redirects included, is retried with exponential backoff until MaxAttempts.
+type Dispatcher struct {
+	repo   webhook.WebhookRepository
+	client *http.Client
+	cfg    config.Webhooks
+	logger *slog.Logger
+	now    func() time.Time
+}
+
+// NewDispatcher creates a dispatcher; client may be nil to use one with cfg.Timeout.
+func NewDispatcher(repo webhook.WebhookRepository, client *http.Client, cfg config.Webhooks, logger *slog.Logger) *Dispatcher {
+	if client == nil {
+		client = &http.Client{
+			Timeout: cfg.Timeout,
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		}
+	}
+	return &Dispatcher{repo: repo, client: client, cfg: cfg, logger: logger, now: time.Now}
+}
+
+// Run dispatches deliveries every PollInterval until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(d.cfg.PollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := d.DispatchOnce(ctx)
+			if err != nil {
+				d.logger.ErrorContext(ctx, "webhook dispatch failed", slog.String("error", err.Error()))
+				break
+			}
+			if n < d.cfg.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchOnce sends one batch of due deliveries concurrently and returns how many were claimed.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	jobs, err := d.repo.Claim(ctx, d.cfg.BatchSize, d.cfg.Lease)
+	if err != nil {
+		return 0, err
+	}
+	var wg sync.WaitGroup
+	errs := make([]error, len(jobs))
+	for i, job := range jobs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = d.deliver(ctx, job)
+		}()
+	}
+	wg.Wait()
+	for _, err = range errs {
+		if err != nil {
+			return len(jobs), err
+		}
+	}
+	return len(jobs), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, job webhook.Job) error {
+	statusCode, sendErr := d.send(ctx, job)
+	if sendErr == nil {
+		return d.repo.MarkDelivered(ctx, job.DeliveryID, statusCode)
+	}
+
+	attempts := job.Attempts + 1
+	dead := attempts >= d.cfg.MaxAttempts
+	d.logger.WarnContext(ctx, "webhook delivery failed", slog.Int64("delivery", job.DeliveryID), slog.String("url", job.URL),
+		slog.Int("attempts", attempts), slog.Bool("gaveUp", dead), slog.String("error", sendErr.Error()))
+	if err := d.repo.MarkFailed(ctx, job.DeliveryID, statusCode, sendErr.Error(), d.backoff(attempts), dead); err != nil {
+		return fmt.Errorf("recording webhook failure: %w", err)
+	}
+	return nil
+}
+
+func (d *Dispatcher) send(ctx context.Context, job webhook.Job) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, job.URL, bytes.NewReader(job.Body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "merch-store-webhooks/1")
+	req.Header.Set(webhook.EventHeader, job.EventType)
+	req.Header.Set(webhook.DeliveryHeader, strconv.FormatInt(job.DeliveryID, 10))
+	req.Header.Set(webhook.SignatureHeader, webhook.Sign(job.Secret, d.now(), job.Body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, maxResponseBody))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	b := d.cfg.BaseBackoff
+	for i := 1; i < attempts && b < d.cfg.MaxBackoff; i++ {
+		b *= 2
+	}
+	return min(b, d.cfg.MaxBackoff)
+}
@@ -0,0 +1,133 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/webhook"
+	mocks "Merch_store-Avito_test_task/internal/pkg/webhook/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+var dispatcherConfig = config.Webhooks{
+	PollInterval: time.Millisecond,
+	BatchSize:    10,
+	MaxAttempts:  3,
+	BaseBackoff:  10 * time.Second,
+	MaxBackoff:   time.Minute,
+	Timeout:      time.Second,
+	Lease:        time.Minute,
+}
+
+// receiver is a webhook endpoint that verifies signatures like a real subscriber would.
+type receiver struct {
+	mu       sync.Mutex
+	secret   string
+	status   int
+	received []*http.Request
+	bodies   []string
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if err := webhook.Verify(rc.secret, r.Header.Get(webhook.SignatureHeader), body, time.Minute, time.Now()); err != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	rc.received = append(rc.received, r)
+	rc.bodies = append(rc.bodies, string(body))
+	w.WriteHeader(rc.status)
+}
+
+func TestDispatchOnce(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	rc := &receiver{secret: "whsec_receiver-secret", status: http.StatusNoContent}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	dispatcher := NewDispatcher(repo, nil, dispatcherConfig, slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil)))
+	ctx := context.Background()
+	job := webhook.Job{
+		DeliveryID: 11,
+		EventType:  models.EventItemPurchased,
+		URL:        srv.URL + "/hooks",
+		Secret:     rc.secret,
+		Body:       []byte(`{"id":5,"type":"item.purchased","payload":{"user":"alice","product":"hoody"}}`),
+	}
+
+	t.Run("Signed delivery is accepted", func(t *testing.T) {
+		repo.EXPECT().Claim(ctx, 10, time.Minute).Return([]webhook.Job{job}, nil)
+		repo.EXPECT().MarkDelivered(ctx, int64(11), http.StatusNoContent).Return(nil)
+
+		n, err := dispatcher.DispatchOnce(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Len(t, rc.received, 1)
+		assert.Equal(t, models.EventItemPurchased, rc.received[0].Header.Get(webhook.EventHeader))
+		assert.Equal(t, "11", rc.received[0].Header.Get(webhook.DeliveryHeader))
+		assert.Equal(t, string(job.Body), rc.bodies[0])
+	})
+
+	t.Run("Wrong secret is rejected and retried with backoff", func(t *testing.T) {
+		forged := job
+		forged.Secret = "whsec_some-other-secret"
+		forged.Attempts = 1
+		repo.EXPECT().Claim(ctx, 10, time.Minute).Return([]webhook.Job{forged}, nil)
+		repo.EXPECT().MarkFailed(ctx, int64(11), http.StatusUnauthorized, "unexpected status 401", 20*time.Second, false).Return(nil)
+
+		_, err := dispatcher.DispatchOnce(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Server error on the last attempt fails the delivery", func(t *testing.T) {
+		rc.status = http.StatusInternalServerError
+		defer func() { rc.status = http.StatusNoContent }()
+		last := job
+		last.Attempts = 2
+		repo.EXPECT().Claim(ctx, 10, time.Minute).Return([]webhook.Job{last}, nil)
+		repo.EXPECT().MarkFailed(ctx, int64(11), http.StatusInternalServerError, "unexpected status 500", 40*time.Second, true).Return(nil)
+
+		_, err := dispatcher.DispatchOnce(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Redirects are not followed", func(t *testing.T) {
+		redirect := httptest.NewServer(http.RedirectHandler(srv.URL, http.StatusFound))
+		defer redirect.Close()
+		moved := job
+		moved.URL = redirect.URL
+		repo.EXPECT().Claim(ctx, 10, time.Minute).Return([]webhook.Job{moved}, nil)
+		repo.EXPECT().MarkFailed(ctx, int64(11), http.StatusFound, "unexpected status 302", 10*time.Second, false).Return(nil)
+
+		_, err := dispatcher.DispatchOnce(ctx)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Unreachable endpoint has no status code", func(t *testing.T) {
+		closed := httptest.NewServer(http.NotFoundHandler())
+		closed.Close()
+		down := job
+		down.URL = closed.URL
+		repo.EXPECT().Claim(ctx, 10, time.Minute).Return([]webhook.Job{down}, nil)
+		repo.EXPECT().MarkFailed(ctx, int64(11), 0, gomock.Any(), 10*time.Second, false).Return(nil)
+
+		_, err := dispatcher.DispatchOnce(ctx)
+		assert.NoError(t, err)
+	})
+}
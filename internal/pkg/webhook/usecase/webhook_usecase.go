@@ -0,0 +1,91 @@
+package usecase
+
+import (
+	"Merch_store-Avito_test_task/internal/models"
+	"Merch_store-Avito_test_task/internal/pkg/webhook"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+const (
+	minSecretLength   = 16
+	maxDeliveriesPage = 100
+)
+
+type WebhookUsecaseImpl struct {
+	repo webhook.WebhookRepository
+}
+
+func NewWebhookUsecase(repo webhook.WebhookRepository) *WebhookUsecaseImpl {
+	return &WebhookUsecaseImpl{repo: repo}
+}
+
+func (uc *WebhookUsecaseImpl) Subscribe(ctx context.Context, rawURL string, events []string, secret string) (models.WebhookSubscription, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return models.WebhookSubscription{}, fmt.Errorf("%w: url must be an absolute http(s) url", models.ErrInvalidInput)
+	}
+	if len(events) == 0 {
+		return models.WebhookSubscription{}, fmt.Errorf("%w: at least one event type is required", models.ErrInvalidInput)
+	}
+	for _, event := range events {
+		if !models.ValidEventType(event) {
+			return models.WebhookSubscription{}, fmt.Errorf("%w: unknown event type %q", models.ErrInvalidInput, event)
+		}
+	}
+	if secret == "" {
+		if secret, err = generateSecret(); err != nil {
+			return models.WebhookSubscription{}, err
+		}
+	} else if len(secret) < minSecretLength {
+		return models.WebhookSubscription{}, fmt.Errorf("%w: secret must be at least %d characters", models.ErrInvalidInput, minSecretLength)
+	}
+
+	events = slices.Clone(events)
+	slices.Sort(events)
+	sub := models.WebhookSubscription{URL: u.String(), Events: slices.Compact(events), Secret: secret}
+	return uc.repo.CreateSubscription(ctx, sub)
+}
+
+func (uc *WebhookUsecaseImpl) List(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return uc.repo.ListSubscriptions(ctx)
+}
+
+func (uc *WebhookUsecaseImpl) Delete(ctx context.Context, id uint) error {
+	return uc.repo.DeleteSubscription(ctx, id)
+}
+
+func (uc *WebhookUsecaseImpl) Deliveries(ctx context.Context, subscriptionID uint, limit int) ([]models.WebhookDelivery, error) {
+	if limit <= 0 || limit > maxDeliveriesPage {
+		limit = maxDeliveriesPage
+	}
+	return uc.repo.ListDeliveries(ctx, subscriptionID, limit)
+}
+
+func (uc *WebhookUsecaseImpl) Redeliver(ctx context.Context, subscriptionID uint, deliveryID int64) error {
+	return uc.repo.Redeliver(ctx, subscriptionID, deliveryID)
+}
+
+// Publish stores the body every subscriber receives: the event with its id, which receivers use to
+// drop duplicates.
+func (uc *WebhookUsecaseImpl) Publish(ctx context.Context, event models.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("encoding webhook body failed: %w", err)
+	}
+	_, err = uc.repo.EnqueueDeliveries(ctx, event, body)
+	return err
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generating webhook secret failed: %w", err)
+	}
+	return "whsec_" + base64.RawURLEncoding.EncodeToString(b), nil
+}
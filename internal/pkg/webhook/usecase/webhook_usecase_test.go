@@ -0,0 +1,92 @@
+package usecase
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"Merch_store-Avito_test_task/internal/models"
+	mocks "Merch_store-Avito_test_task/internal/pkg/webhook/mocks"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	uc := NewWebhookUsecase(repo)
+	ctx := context.Background()
+
+	t.Run("Generates a secret", func(t *testing.T) {
+		repo.EXPECT().CreateSubscription(ctx, gomock.Any()).DoAndReturn(
+			func(_ context.Context, sub models.WebhookSubscription) (models.WebhookSubscription, error) {
+				sub.ID = 1
+				return sub, nil
+			})
+
+		sub, err := uc.Subscribe(ctx, "https://hooks.example.com/merch", []string{models.EventItemPurchased, models.EventItemPurchased}, "")
+		assert.NoError(t, err)
+		assert.True(t, strings.HasPrefix(sub.Secret, "whsec_"))
+		assert.Equal(t, []string{models.EventItemPurchased}, sub.Events)
+	})
+
+	tests := []struct {
+		name   string
+		url    string
+		events []string
+		secret string
+	}{
+		{"Relative url", "/hooks", []string{models.AllEvents}, ""},
+		{"Unsupported scheme", "ftp://hooks.example.com", []string{models.AllEvents}, ""},
+		{"No events", "https://hooks.example.com", nil, ""},
+		{"Unknown event", "https://hooks.example.com", []string{"user.deleted"}, ""},
+		{"Short secret", "https://hooks.example.com", []string{models.AllEvents}, "short"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := uc.Subscribe(ctx, tt.url, tt.events, tt.secret)
+			assert.ErrorIs(t, err, models.ErrInvalidInput)
+		})
+	}
+}
+
+func TestPublish(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	uc := NewWebhookUsecase(repo)
+	event := models.Event{
+		ID:        3,
+		Type:      models.EventCoinsTransferred,
+		Payload:   json.RawMessage(`{"amount":10}`),
+		CreatedAt: time.Date(2025, 2, 1, 10, 0, 0, 0, time.UTC),
+	}
+
+	repo.EXPECT().EnqueueDeliveries(gomock.Any(), event, gomock.Any()).DoAndReturn(
+		func(_ context.Context, _ models.Event, body []byte) (int64, error) {
+			assert.JSONEq(t, `{"id":3,"type":"coins.transferred","payload":{"amount":10},"createdAt":"2025-02-01T10:00:00Z"}`, string(body))
+			return 2, nil
+		})
+	assert.NoError(t, uc.Publish(context.Background(), event))
+}
+
+func TestDeliveriesLimit(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	repo := mocks.NewMockWebhookRepository(ctrl)
+	uc := NewWebhookUsecase(repo)
+
+	repo.EXPECT().ListDeliveries(gomock.Any(), uint(1), maxDeliveriesPage).Return(nil, nil)
+	repo.EXPECT().ListDeliveries(gomock.Any(), uint(1), 5).Return(nil, nil)
+	_, err := uc.Deliveries(context.Background(), 1, 0)
+	assert.NoError(t, err)
+	_, err = uc.Deliveries(context.Background(), 1, 5)
+	assert.NoError(t, err)
+}
@@ -9,7 +9,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
@@ -19,8 +21,12 @@ import (
 	authHandler "Merch_store-Avito_test_task/internal/pkg/auth/delivery/http"
 	authRepo "Merch_store-Avito_test_task/internal/pkg/auth/repository"
 	authUsecase "Merch_store-Avito_test_task/internal/pkg/auth/usecase"
+	"Merch_store-Avito_test_task/internal/pkg/config"
+	"Merch_store-Avito_test_task/internal/pkg/database"
 	"Merch_store-Avito_test_task/internal/pkg/jwt"
 	"Merch_store-Avito_test_task/internal/pkg/middleware"
+	"Merch_store-Avito_test_task/internal/pkg/openapi"
+	outboxRepo "Merch_store-Avito_test_task/internal/pkg/outbox/repository"
 	paymentsHandler "Merch_store-Avito_test_task/internal/pkg/payments/delivery/http"
 	paymentsRepo "Merch_store-Avito_test_task/internal/pkg/payments/repository"
 	paymentsUsecase "Merch_store-Avito_test_task/internal/pkg/payments/usecase"
@@ -30,6 +36,7 @@ type IntegrationTestSuite struct {
 	suite.Suite
 	db              *sql.DB
 	router          *mux.Router
+	handler         http.Handler
 	server          *httptest.Server
 	paymentsHandler *paymentsHandler.PaymentsHandler
 	authHandler     *authHandler.AuthHandler
@@ -45,14 +52,7 @@ func (s *IntegrationTestSuite) SetupSuite() {
 	s.logger = slog.New(slog.NewJSONHandler(os.Stdout, nil))
 
 	// Подключение к тестовой БД
-	db, err := sql.Open("postgres", fmt.Sprintf(
-		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		5433,
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASS"),
-		os.Getenv("DB_NAME"),
-	))
+	db, err := openTestDB()
 	require.NoError(s.T(), err)
 	s.db = db
 
@@ -61,23 +61,36 @@ func (s *IntegrationTestSuite) SetupSuite() {
 
 	// Инициализация слоев
 	// Auth
+	loginGuard := authUsecase.NewLoginGuard(authRepo.NewMemoryAttemptStore(), config.LoginGuard{
+		MaxUserFailures: 5, MaxIPFailures: 20, BaseLockout: time.Second, MaxLockout: time.Minute, Window: time.Minute,
+	})
 	authRepo := authRepo.NewAuthRepositoryImpl(s.db)
-	authUc := authUsecase.NewAuthUsecase(authRepo)
-	s.authHandler = authHandler.NewAuthHandler(authUc, s.logger, s.jwtHandler)
+	authUc := authUsecase.NewAuthUsecase(authRepo, config.Password{})
+	s.authHandler = authHandler.NewAuthHandler(authUc, s.logger, s.jwtHandler, loginGuard)
 
 	// Payments
 	paymentsRepo := paymentsRepo.NewAuthRepositoryImpl(s.db)
-	paymentsUc := paymentsUsecase.NewPaymentsUsecase(paymentsRepo)
+	paymentsUc := paymentsUsecase.NewPaymentsUsecase(paymentsRepo, outboxRepo.NewOutboxRepositoryImpl(s.db),
+		database.NewTxManager(s.db), nil)
 	s.paymentsHandler = paymentsHandler.NewPaymentsHandler(paymentsUc, s.logger)
 
 	// Router setup
 	s.router = mux.NewRouter().PathPrefix("/api").Subrouter()
 	s.setupRoutes()
 
-	s.server = httptest.NewServer(s.router)
+	// Every request and response is checked against the API document; drift fails the running test.
+	spec, err := openapi.Load()
+	require.NoError(s.T(), err)
+	validator, err := middleware.NewOpenAPIValidator(spec, func(r *http.Request, err error) {
+		s.T().Errorf("OpenAPI violation: %v", err)
+	})
+	require.NoError(s.T(), err)
+	s.handler = middleware.OpenAPIValidationMiddleware(validator, s.router, s.logger)
+
+	s.server = httptest.NewServer(s.handler)
 
 	// Создание тестовых таблиц
-	err = s.createTestTables()
+	err = createTestTables(s.db)
 	require.NoError(s.T(), err)
 }
 
@@ -89,8 +102,21 @@ func (s *IntegrationTestSuite) setupRoutes() {
 		http.HandlerFunc(s.paymentsHandler.BuyItem), s.logger)).Methods(http.MethodGet)
 }
 
-func (s *IntegrationTestSuite) createTestTables() error {
+// openTestDB подключается к тестовой БД из переменных окружения DB_*.
+func openTestDB() (*sql.DB, error) {
+	return sql.Open("postgres", fmt.Sprintf(
+		"host=%s port=%d user=%s password=%s dbname=%s sslmode=disable",
+		os.Getenv("DB_HOST"),
+		5433,
+		os.Getenv("DB_USER"),
+		os.Getenv("DB_PASS"),
+		os.Getenv("DB_NAME"),
+	))
+}
+
+func createTestTables(db *sql.DB) error {
 	queries := []string{
+		`DROP TABLE IF EXISTS "outbox_event" CASCADE`,
 		`DROP TABLE IF EXISTS "transaction" CASCADE`,
 		`DROP TABLE IF EXISTS "purchase" CASCADE`,
 		`DROP TABLE IF EXISTS "product" CASCADE`,
@@ -103,7 +129,8 @@ func (s *IntegrationTestSuite) createTestTables() error {
 		`CREATE TABLE "product" (
             id SERIAL PRIMARY KEY,
             name VARCHAR(255) NOT NULL,
-            price INTEGER NOT NULL
+            price INTEGER NOT NULL,
+            retired_at TIMESTAMP
         )`,
 		`CREATE TABLE "purchase" (
             id SERIAL PRIMARY KEY,
@@ -117,11 +144,22 @@ func (s *IntegrationTestSuite) createTestTables() error {
             from_user_id INTEGER REFERENCES "user"(id),
             to_user_id INTEGER REFERENCES "user"(id),
             created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE TABLE "outbox_event" (
+            id BIGINT GENERATED ALWAYS AS IDENTITY PRIMARY KEY,
+            event_type TEXT NOT NULL,
+            payload JSONB NOT NULL,
+            status TEXT NOT NULL DEFAULT 'pending',
+            attempts INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT,
+            next_attempt_at TIMESTAMP NOT NULL DEFAULT NOW(),
+            created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+            published_at TIMESTAMP
         )`,
 	}
 
 	for _, query := range queries {
-		_, err := s.db.Exec(query)
+		_, err := db.Exec(query)
 		if err != nil {
 			return err
 		}
@@ -136,7 +174,7 @@ func (s *IntegrationTestSuite) TearDownSuite() {
 
 func (s *IntegrationTestSuite) TearDownTest() {
 	// Очистка таблиц после каждого теста
-	tables := []string{"transaction", "purchase", "product", "user"}
+	tables := []string{"outbox_event", "transaction", "purchase", "product", "user"}
 	for _, table := range tables {
 		_, err := s.db.Exec(fmt.Sprintf(`TRUNCATE TABLE "%s" CASCADE`, table))
 		require.NoError(s.T(), err)
@@ -157,7 +195,7 @@ func (s *IntegrationTestSuite) TestSuccessfulPurchase() {
 	req.Header.Set("Access-Token", token)
 
 	w := httptest.NewRecorder()
-	s.router.ServeHTTP(w, req)
+	s.handler.ServeHTTP(w, req)
 
 	// Проверки
 	s.Equal(http.StatusOK, w.Code)
@@ -190,7 +228,7 @@ func (s *IntegrationTestSuite) TestPurchaseBadRequest() {
 	req.Header.Set("Access-Token", token)
 
 	w := httptest.NewRecorder()
-	s.router.ServeHTTP(w, req)
+	s.handler.ServeHTTP(w, req)
 
 	// Проверки
 	s.Equal(http.StatusBadRequest, w.Code)
@@ -222,10 +260,10 @@ func (s *IntegrationTestSuite) TestPurchaseNonExistingProduct() {
 	req.Header.Set("Access-Token", token)
 
 	w := httptest.NewRecorder()
-	s.router.ServeHTTP(w, req)
+	s.handler.ServeHTTP(w, req)
 
 	// Проверки
-	s.Equal(http.StatusInternalServerError, w.Code)
+	s.Equal(http.StatusNotFound, w.Code)
 
 	// Проверка баланса пользователя
 	var balance int
@@ -251,7 +289,7 @@ func (s *IntegrationTestSuite) TestInsufficientFundsPurchase() {
 	req.Header.Set("Access-Token", token)
 
 	w := httptest.NewRecorder()
-	s.router.ServeHTTP(w, req)
+	s.handler.ServeHTTP(w, req)
 
 	s.Equal(http.StatusForbidden, w.Code)
 
@@ -280,7 +318,7 @@ func (s *IntegrationTestSuite) TestSuccessfulCoinTransfer() {
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
-	s.router.ServeHTTP(w, req)
+	s.handler.ServeHTTP(w, req)
 
 	s.Equal(http.StatusOK, w.Code)
 
@@ -313,7 +351,7 @@ func (s *IntegrationTestSuite) TestTransferToNonExistentUser() {
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
-	s.router.ServeHTTP(w, req)
+	s.handler.ServeHTTP(w, req)
 
 	s.Equal(http.StatusNotFound, w.Code)
 
@@ -343,7 +381,7 @@ func (s *IntegrationTestSuite) TestCoinTransferBadRequest() {
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
-	s.router.ServeHTTP(w, req)
+	s.handler.ServeHTTP(w, req)
 
 	s.Equal(http.StatusBadRequest, w.Code)
 
@@ -358,6 +396,57 @@ func (s *IntegrationTestSuite) TestCoinTransferBadRequest() {
 	s.Equal(0, receiverBalance)
 }
 
+// Стресс-тест: встречные переводы между всеми парами пользователей не должны ни падать
+// с дедлоком, ни терять обновления баланса
+func (s *IntegrationTestSuite) TestConcurrentTransfersNoLostUpdates() {
+	const users, transfersPerPair, initialCoins = 4, 25, 1000
+	ids := make([]uint, users)
+	tokens := make([]string, users)
+	for i := range users {
+		username := fmt.Sprintf("stress%d", i)
+		ids[i] = s.createTestUser(username, initialCoins)
+		tokens[i] = s.generateTestToken(ids[i], username)
+	}
+
+	var wg sync.WaitGroup
+	codes := make(chan int, users*(users-1)*transfersPerPair)
+	for from := range users {
+		for to := range users {
+			if from == to {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				body := fmt.Sprintf(`{"toUser":"stress%d","amount":1}`, to)
+				for range transfersPerPair {
+					req := httptest.NewRequest(http.MethodPost, "/api/sendCoin", bytes.NewBufferString(body))
+					req.Header.Set("Access-Token", tokens[from])
+					req.Header.Set("Content-Type", "application/json")
+					w := httptest.NewRecorder()
+					s.handler.ServeHTTP(w, req)
+					codes <- w.Code
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	close(codes)
+
+	for code := range codes {
+		s.Equal(http.StatusOK, code)
+	}
+	// Каждый отправил и получил одинаковую сумму, поэтому балансы не должны измениться
+	for i, id := range ids {
+		var balance int
+		s.NoError(s.db.QueryRow(`SELECT coins FROM "user" WHERE id = $1`, id).Scan(&balance))
+		s.Equal(initialCoins, balance, "balance of stress%d", i)
+	}
+	var transactions int
+	s.NoError(s.db.QueryRow(`SELECT COUNT(*) FROM "transaction"`).Scan(&transactions))
+	s.Equal(users*(users-1)*transfersPerPair, transactions)
+}
+
 func (s *IntegrationTestSuite) createTestUser(username string, coins int) uint {
 	var id uint
 	err := s.db.QueryRow(
@@ -379,7 +468,7 @@ func (s *IntegrationTestSuite) createTestProduct(name string, price int) uint {
 }
 
 func (s *IntegrationTestSuite) generateTestToken(userID uint, username string) string {
-	token, err := s.jwtHandler.GenerateToken(userID, username)
+	token, err := s.jwtHandler.GenerateToken(userID, username, 0)
 	s.NoError(err)
 	return token
 }
@@ -0,0 +1,99 @@
+package integration_test
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	serviceRepo "Merch_store-Avito_test_task/internal/pkg/service/repository"
+)
+
+// Запросы GetUserInfo до перехода на один CTE-запрос: баланс и три списка, каждый отдельным запросом.
+var perQueryUserInfo = []string{
+	`SELECT coins from "user" WHERE id = $1`,
+	`SELECT p.name, COUNT(p.id) FROM "purchase" pu JOIN "product" p ON pu.product_id = p.id
+		WHERE pu.user_id = $1 GROUP BY p.name`,
+	`SELECT u.username, t.amount FROM transaction t JOIN "user" u ON t.from_user_id = u.id
+		WHERE t.to_user_id = $1 ORDER BY t.created_at DESC`,
+	`SELECT u.username, t.amount FROM transaction t JOIN "user" u ON t.to_user_id = u.id
+		WHERE t.from_user_id = $1 ORDER BY t.created_at DESC`,
+}
+
+// BenchmarkGetUserInfo сравнивает прежние четыре запроса с текущим GetUserInfo на тестовой БД
+// (make test-env-up и переменные DB_*, как для интеграционных тестов).
+func BenchmarkGetUserInfo(b *testing.B) {
+	if os.Getenv("DB_HOST") == "" {
+		b.Skip("DB_HOST is not set; the benchmark needs the integration test database")
+	}
+	db, err := openTestDB()
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	if err = createTestTables(db); err != nil {
+		b.Fatal(err)
+	}
+	userID := seedUserInfo(b, db)
+	ctx := context.Background()
+
+	b.Run("PerQuery", func(b *testing.B) {
+		for range b.N {
+			var coins int
+			if err := db.QueryRowContext(ctx, perQueryUserInfo[0], userID).Scan(&coins); err != nil {
+				b.Fatal(err)
+			}
+			for _, query := range perQueryUserInfo[1:] {
+				rows, err := db.QueryContext(ctx, query, userID)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for rows.Next() {
+					var name string
+					var n int
+					if err = rows.Scan(&name, &n); err != nil {
+						b.Fatal(err)
+					}
+				}
+				rows.Close()
+			}
+		}
+	})
+
+	b.Run("SingleQuery", func(b *testing.B) {
+		repo := serviceRepo.NewServiceRepo(db)
+		for range b.N {
+			if _, err := repo.GetUserInfo(ctx, userID); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// seedUserInfo создаёт пользователя с 50 покупками и по 100 входящих и исходящих переводов.
+func seedUserInfo(b *testing.B, db *sql.DB) uint {
+	queries := []string{
+		`INSERT INTO "user" (username, coins) SELECT 'user' || i, 1000 FROM generate_series(1, 10) i`,
+		`INSERT INTO "product" (name, price) SELECT 'item' || i, 10 * i FROM generate_series(1, 5) i`,
+		`INSERT INTO "purchase" (user_id, product_id)
+			SELECT (SELECT id FROM "user" WHERE username = 'user1'), p.id FROM "product" p, generate_series(1, 10)`,
+		`INSERT INTO "transaction" (amount, from_user_id, to_user_id)
+			SELECT i, u.id, me.id FROM generate_series(1, 100) i
+			JOIN "user" u ON u.username = 'user' || (2 + i % 9)
+			CROSS JOIN (SELECT id FROM "user" WHERE username = 'user1') me`,
+		`INSERT INTO "transaction" (amount, from_user_id, to_user_id)
+			SELECT i, me.id, u.id FROM generate_series(1, 100) i
+			JOIN "user" u ON u.username = 'user' || (2 + i % 9)
+			CROSS JOIN (SELECT id FROM "user" WHERE username = 'user1') me`,
+	}
+	for _, query := range queries {
+		if _, err := db.Exec(query); err != nil {
+			b.Fatal(err)
+		}
+	}
+	var userID uint
+	if err := db.QueryRow(`SELECT id FROM "user" WHERE username = 'user1'`).Scan(&userID); err != nil {
+		b.Fatal(err)
+	}
+	return userID
+}